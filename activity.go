@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ── Tool Activity ─────────────────────────────────────────────────
+//
+// Agents launched on a backend with settings_flag get generated hook
+// settings that run `agent-tui hook` before and after every tool use. The
+// hook forwards the event to the control API, which keeps a feed of
+// recent tool uses per agent and sets its task line to what it is doing
+// now ("Editing src/auth.go", "Running go test ./..."). The feed is shown
+// in the activity panel (a) and served at GET /agents/{id}/activity.
+
+const hooksFileName = "hooks.json"
+
+// Tool use phases reported by the hook.
+const (
+	ToolUseStart = "pre"
+	ToolUseEnd   = "post"
+)
+
+// maxActivity is how many tool uses an agent's feed keeps.
+const maxActivity = 100
+
+// toolEvent is one hook call, as posted to /agents/{id}/activity.
+type toolEvent struct {
+	Phase string         `json:"phase"` // ToolUseStart or ToolUseEnd
+	Tool  string         `json:"tool"`
+	Input map[string]any `json:"input,omitempty"`
+	Cwd   string         `json:"cwd,omitempty"` // paths in Input are shown relative to it
+}
+
+// toolActivity is one tool use in an agent's feed.
+type toolActivity struct {
+	Tool     string    `json:"tool"`
+	Text     string    `json:"text"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"` // zero while the tool runs
+}
+
+// activityFeed is an agent's recent tool uses, oldest first.
+type activityFeed []toolActivity
+
+// record adds a tool start, or finishes the latest running use of the
+// same tool, and returns its description.
+func (f *activityFeed) record(ev toolEvent) string {
+	text := describeToolUse(ev.Tool, ev.Input, ev.Cwd)
+	now := time.Now()
+	if ev.Phase == ToolUseEnd {
+		for i := len(*f) - 1; i >= 0; i-- {
+			a := &(*f)[i]
+			if a.Tool == ev.Tool && a.Finished.IsZero() {
+				a.Finished = now
+				return a.Text
+			}
+		}
+		return text // started before the feed was listening
+	}
+	*f = append(*f, toolActivity{Tool: ev.Tool, Text: text, Started: now})
+	if n := len(*f) - maxActivity; n > 0 {
+		*f = (*f)[n:]
+	}
+	return text
+}
+
+// recordToolUse adds ev to inst's feed and, while it runs, shows what it
+// is doing on its task line.
+func (inst *AgentInstance) recordToolUse(ev toolEvent) {
+	text := inst.Activity.record(ev)
+	if inst.Status == "running" {
+		inst.Task = text
+	}
+}
+
+// describeToolUse says what a tool call is doing in a few words.
+func describeToolUse(tool string, input map[string]any, cwd string) string {
+	arg := func(keys ...string) string {
+		for _, k := range keys {
+			if s, _ := input[k].(string); s != "" {
+				return s
+			}
+		}
+		return ""
+	}
+	path := func(keys ...string) string {
+		p := arg(keys...)
+		if p == "" {
+			return "a file"
+		}
+		if rel, err := filepath.Rel(cwd, p); cwd != "" && err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+		return p
+	}
+	brief := func(s string) string {
+		s, _, _ = strings.Cut(strings.TrimSpace(s), "\n")
+		return truncLine(s, 60)
+	}
+
+	switch tool {
+	case "Edit", "MultiEdit":
+		return "Editing " + path("file_path")
+	case "NotebookEdit":
+		return "Editing " + path("notebook_path")
+	case "Write":
+		return "Writing " + path("file_path")
+	case "Read":
+		return "Reading " + path("file_path")
+	case "Bash":
+		if cmd := arg("command"); cmd != "" {
+			return "Running " + brief(cmd)
+		}
+	case "Grep":
+		return "Searching for " + brief(arg("pattern"))
+	case "Glob":
+		return "Finding " + brief(arg("pattern"))
+	case "WebFetch":
+		return "Fetching " + brief(arg("url"))
+	case "WebSearch":
+		return "Searching the web for " + brief(arg("query"))
+	case "Task":
+		return "Delegating: " + brief(arg("description", "prompt"))
+	case "TodoWrite":
+		return "Updating the todo list"
+	}
+	if rest, ok := strings.CutPrefix(tool, "mcp__"); ok {
+		if _, name, ok := strings.Cut(rest, "__"); ok {
+			return "Using " + name
+		}
+	}
+	return "Using " + tool
+}
+
+// forgeHookArgs configures a launch of agentID on b to report tool use
+// to the control API at addr: it returns the args that load generated
+// hook settings from sessionDir. The settings are only written if write
+// is set, so a dry run can describe them. Backends without settings_flag,
+// or an empty addr, get no hooks.
+func forgeHookArgs(b *BackendConfig, addr, agentID, sessionDir string, write bool) ([]string, error) {
+	if b.SettingsFlag == "" || addr == "" {
+		return nil, nil
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, nil
+	}
+	path := filepath.Join(sessionDir, hooksFileName)
+	if write {
+		command := shellJoin([]string{exe, "hook", "--agent", agentID, "--api", addr})
+		hook := []map[string]any{{
+			"matcher": "*",
+			"hooks":   []map[string]any{{"type": "command", "command": command, "timeout": 5}},
+		}}
+		settings := map[string]any{
+			"hooks": map[string]any{"PreToolUse": hook, "PostToolUse": hook},
+		}
+		data, _ := json.MarshalIndent(settings, "", "  ")
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return nil, fmt.Errorf("writing hook settings: %w", err)
+		}
+	}
+	return appendFlag(nil, b.SettingsFlag, path), nil
+}
+
+// ── hook Subcommand ───────────────────────────────────────────────
+
+const hookUsage = "--agent <id> [--api <addr>] < event.json"
+
+// hookTimeout bounds how long a tool waits on the hook.
+const hookTimeout = 2 * time.Second
+
+// runHook reads one PreToolUse/PostToolUse hook payload from stdin and
+// forwards it. It never fails the tool: errors reaching forge are dropped.
+func runHook(args []string) error {
+	var agentID, addr string
+	fs := newFlagSet("hook", hookUsage)
+	fs.StringVar(&agentID, "agent", "", "agent ID the event belongs to (required)")
+	fs.StringVar(&addr, "api", "", "control API address (default: the forge socket)")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	if agentID == "" {
+		fs.Usage()
+		return fmt.Errorf("hook: --agent is required")
+	}
+
+	var payload struct {
+		Event string         `json:"hook_event_name"`
+		Tool  string         `json:"tool_name"`
+		Input map[string]any `json:"tool_input"`
+		Cwd   string         `json:"cwd"`
+	}
+	if err := json.NewDecoder(os.Stdin).Decode(&payload); err != nil || payload.Tool == "" {
+		return nil
+	}
+	ev := toolEvent{Phase: ToolUseStart, Tool: payload.Tool, Input: map[string]any{}, Cwd: payload.Cwd}
+	if payload.Event == "PostToolUse" {
+		ev.Phase = ToolUseEnd
+	}
+	// Only short string arguments are described; skip file contents
+	for k, v := range payload.Input {
+		if s, ok := v.(string); ok {
+			if len(s) > 512 {
+				s = s[:512]
+			}
+			ev.Input[k] = s
+		}
+	}
+	c := newAPIClient(addr)
+	c.hc.Timeout = hookTimeout
+	c.post("/agents/"+url.PathEscape(agentID)+"/activity", ev, nil)
+	return nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestToolUseShowsOnTaskLineAndActivityPanel(t *testing.T) {
+	h := newHarness(t, PartyFile{Name: "busy", Slots: []PartySlotConfig{{Agent: "Builder"}}})
+	h.WaitFor(fakeAgentReady)
+	h.Send(apiRequestMsg{reply: make(chan apiReply, 1), fn: func(m *Model) (any, tea.Cmd, error) {
+		m.agent().recordToolUse(toolEvent{Phase: ToolUseStart, Tool: "Edit",
+			Input: map[string]any{"file_path": "/src/project/auth.go"}, Cwd: "/src/project"})
+		return nil, nil, nil
+	}})
+	h.WaitFor("Editing auth.go")
+	h.Keys("a")
+	h.WaitFor("ACTIVITY · Builder")
+}
+
+func TestDescribeToolUse(t *testing.T) {
+	tests := []struct {
+		tool  string
+		input map[string]any
+		want  string
+	}{
+		{"Edit", map[string]any{"file_path": "/src/app/auth/login.go"}, "Editing auth/login.go"},
+		{"Write", map[string]any{"file_path": "/etc/hosts"}, "Writing /etc/hosts"},
+		{"Read", nil, "Reading a file"},
+		{"Bash", map[string]any{"command": "go test ./...\necho done"}, "Running go test ./..."},
+		{"Grep", map[string]any{"pattern": "TODO"}, "Searching for TODO"},
+		{"Task", map[string]any{"prompt": "review the diff"}, "Delegating: review the diff"},
+		{"mcp__forge__party_status", nil, "Using party_status"},
+		{"Frobnicate", nil, "Using Frobnicate"},
+	}
+	for _, tt := range tests {
+		if got := describeToolUse(tt.tool, tt.input, "/src/app"); got != tt.want {
+			t.Errorf("%s %v: %q, want %q", tt.tool, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestActivityFeedRecord(t *testing.T) {
+	var f activityFeed
+	f.record(toolEvent{Phase: ToolUseStart, Tool: "Bash", Input: map[string]any{"command": "make"}})
+	f.record(toolEvent{Phase: ToolUseStart, Tool: "Read", Input: map[string]any{"file_path": "a.go"}})
+	if got := f.record(toolEvent{Phase: ToolUseEnd, Tool: "Bash"}); got != "Running make" {
+		t.Errorf("finishing Bash returned %q, want its start description", got)
+	}
+	if len(f) != 2 || f[0].Finished.IsZero() || !f[1].Finished.IsZero() {
+		t.Errorf("feed %+v", f)
+	}
+
+	for range maxActivity + 5 {
+		f.record(toolEvent{Phase: ToolUseStart, Tool: "Glob"})
+	}
+	if len(f) != maxActivity || f[0].Tool != "Glob" {
+		t.Errorf("feed not capped at %d: %d entries, oldest %s", maxActivity, len(f), f[0].Tool)
+	}
+}
+
+func TestForgeHookArgs(t *testing.T) {
+	dir := t.TempDir()
+	claude, _ := (&ForgeConfig{}).Backend("claude")
+	args, err := forgeHookArgs(claude, "127.0.0.1:7777", "p-0-Builder", dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, hooksFileName)
+	if !slices.Equal(args, []string{"--settings", path}) {
+		t.Errorf("args %q", args)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var settings struct {
+		Hooks map[string][]struct {
+			Hooks []struct{ Command string } `json:"hooks"`
+		} `json:"hooks"`
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		t.Fatal(err)
+	}
+	for _, event := range []string{"PreToolUse", "PostToolUse"} {
+		h := settings.Hooks[event]
+		if len(h) != 1 || len(h[0].Hooks) != 1 || !strings.Contains(h[0].Hooks[0].Command, "hook --agent p-0-Builder --api 127.0.0.1:7777") {
+			t.Errorf("%s hook %+v", event, h)
+		}
+	}
+
+	codex, _ := (&ForgeConfig{}).Backend("codex")
+	if args, _ := forgeHookArgs(codex, "127.0.0.1:7777", "id", dir, true); args != nil {
+		t.Errorf("backend without settings_flag got %q", args)
+	}
+}
@@ -0,0 +1,903 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ── Control API ───────────────────────────────────────────────────
+//
+// A local HTTP/JSON API for scripting agent-tui from other tools. It binds
+// to a Unix socket (default ~/.agent-forge/api.sock) or a loopback TCP
+// address set with api_listen in config.yaml:
+//
+//	GET  /parties                  parties with slot/bench agents
+//	GET  /agents/{id}              one agent's status, task and context
+//	POST /agents/{id}/start        start an idle or exited agent ({"resume": true})
+//	POST /agents/{id}/stop         SIGTERM a running agent
+//	POST /agents/{id}/input        {"text": "...", "submit": true}
+//	POST /agents/{id}/handoff      {"to": "<agent>", "summary": "..."} (no summary: last screen)
+//	GET  /agents/{id}/screen       rendered screen (?ansi=1 keeps escapes)
+//	POST /agents/{id}/message      {"to": "<agent>|all", "text": "..."} from this agent
+//	POST /agents/{id}/inbox/read   unread messages to this agent; marks them read
+//	POST /agents/{id}/task         {"text": "..."} sets the agent's task line
+//	POST /agents/{id}/review       {"reviewer": "<agent>", "summary": "..."}
+//	POST /agents/{id}/activity     {"phase": "pre|post", "tool": "Edit", "input": {...}, "cwd": "..."}
+//	GET  /agents/{id}/activity     recent tool uses, oldest first
+//
+// Agents reach the coordination endpoints through the forge MCP server
+// (see mcp.go), so <agent> may be a teammate's name as well as an ID.
+//
+// Requests with a body must be sent as application/json, which a web page
+// can't do cross-origin without a CORS preflight the API never answers.
+// On a TCP address any local process, browsers included, can connect, so
+// requests also need "Authorization: Bearer <token>". The token is
+// generated per listener, written to ~/.agent-forge/api.token (0600) and
+// exported to agents as AGENT_FORGE_API_TOKEN.
+//
+// The TUI and raid mode each provide a ControlTarget.
+
+var (
+	errAgentNotFound = errors.New("agent not found")
+	errUnsupported   = errors.New("not supported in this mode")
+)
+
+// apiAgent is the JSON view of an AgentInstance.
+type apiAgent struct {
+	ID            string `json:"id"`
+	Agent         string `json:"agent"`
+	Class         string `json:"class"`
+	Backend       string `json:"backend"`
+	Status        string `json:"status"`
+	Task          string `json:"task"`
+	ContextTokens int    `json:"context_tokens"`
+	ContextMax    int    `json:"context_max"`
+	ContextBytes  int64  `json:"context_bytes"`
+	Worktree      string `json:"worktree,omitempty"`
+	Branch        string `json:"branch,omitempty"`
+	Unread        int    `json:"unread,omitempty"`
+	Review        string `json:"review_requested,omitempty"`
+	Approval      string `json:"approval,omitempty"` // permission prompt the agent is waiting on
+}
+
+// Kinds of forgeMessage.
+const (
+	MessageNote    = "message"
+	MessageHandoff = "handoff"
+	MessageReview  = "review"
+)
+
+// forgeMessage is one message between agents of a party.
+type forgeMessage struct {
+	From  string    `json:"from"`  // sender's agent ID
+	Agent string    `json:"agent"` // sender's name
+	Kind  string    `json:"kind"`
+	Text  string    `json:"text"`
+	Sent  time.Time `json:"sent"`
+}
+
+// apiParty is the JSON view of a Party. Empty slots are null.
+type apiParty struct {
+	Name    string      `json:"name"`
+	Project string      `json:"project"`
+	Slots   []*apiAgent `json:"slots"`
+	Bench   []*apiAgent `json:"bench"`
+}
+
+// ControlTarget is the set of operations the API drives.
+type ControlTarget interface {
+	Parties() ([]apiParty, error)
+	Agent(id string) (*apiAgent, error)
+	Start(id string, resume bool) error
+	Stop(id string) error
+	SendInput(id string, data []byte) error
+	Handoff(from, to, summary string) error
+	Screen(id string, ansi bool) (string, error)
+
+	PostMessage(from, to, text string) error
+	ReadInbox(id string) ([]forgeMessage, error)
+	SetTask(id, text string) error
+	RequestReview(from, reviewer, summary string) (string, error) // returns the reviewer's ID, "" if none
+
+	RecordToolUse(id string, ev toolEvent) error
+	Activity(id string) ([]toolActivity, error)
+}
+
+// ── Server ────────────────────────────────────────────────────────
+
+// apiTokenEnv carries the TCP listener's bearer token to API clients.
+const apiTokenEnv = "AGENT_FORGE_API_TOKEN"
+
+// listenAPI binds addr: "unix:<path>", a loopback host:port, or empty for
+// the default socket. Non-loopback TCP addresses are refused.
+func listenAPI(addr string) (net.Listener, error) {
+	if addr == "" {
+		addr = "unix:" + apiSocketPath()
+	}
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is already in use", path)
+		}
+		os.Remove(path) // stale socket
+		return net.Listen("unix", path)
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return nil, fmt.Errorf("api address %s is not loopback", addr)
+	}
+	return net.Listen("tcp", addr)
+}
+
+// startAPI serves target in the background. Returns a cleanup func, or an
+// error if the address could not be bound.
+func startAPI(addr string, target ControlTarget) (func(), error) {
+	ln, err := listenAPI(addr)
+	if err != nil {
+		return nil, err
+	}
+	var token string
+	if _, ok := ln.Addr().(*net.TCPAddr); ok {
+		token = rand.Text()
+		if err := os.WriteFile(apiTokenPath(), []byte(token+"\n"), 0600); err != nil {
+			ln.Close()
+			return nil, fmt.Errorf("writing API token: %w", err)
+		}
+		os.Setenv(apiTokenEnv, token) // inherited by agents, their MCP server and hooks
+	}
+	srv := &http.Server{Handler: guardAPI(apiHandler(target), token)}
+	go srv.Serve(ln)
+	controlAddr = "unix:" + apiSocketPath()
+	if addr != "" {
+		controlAddr = addr
+	}
+	if ta, ok := ln.Addr().(*net.TCPAddr); ok {
+		controlAddr = ta.String() // resolves port 0
+	}
+	return func() {
+		controlAddr = ""
+		srv.Close()
+		if ua, ok := ln.Addr().(*net.UnixAddr); ok {
+			os.Remove(ua.Name)
+		}
+		if token != "" {
+			os.Unsetenv(apiTokenEnv)
+			// Leave a newer listener's token alone
+			if data, err := os.ReadFile(apiTokenPath()); err == nil && strings.TrimSpace(string(data)) == token {
+				os.Remove(apiTokenPath())
+			}
+		}
+	}, nil
+}
+
+// apiWarningMsg tells the TUI its control API could not start.
+type apiWarningMsg string
+
+// apiUnavailable explains a startAPI failure: without the API, agents get
+// neither the forge MCP tools nor the tool-use hooks.
+func apiUnavailable(err error) string {
+	return fmt.Sprintf("control API unavailable (%v): agents run without forge tools and activity hooks", err)
+}
+
+// guardAPI rejects requests a web page could forge: bodies that aren't
+// JSON and, when token is set, requests without it.
+func guardAPI(h http.Handler, token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				http.Error(w, "missing or invalid API token", http.StatusUnauthorized)
+				return
+			}
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead && hasBody(r) {
+			if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mt != "application/json" {
+				http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+				return
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// hasBody reports whether r carries a body. Body-less POSTs (stop,
+// inbox/read, a plain start) need no Content-Type, so a bare
+// `curl -X POST` works.
+func hasBody(r *http.Request) bool {
+	return r.ContentLength != 0 || len(r.TransferEncoding) > 0
+}
+
+func apiHandler(t ControlTarget) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /parties", func(w http.ResponseWriter, r *http.Request) {
+		parties, err := t.Parties()
+		writeAPI(w, parties, err)
+	})
+	mux.HandleFunc("GET /agents/{id}", func(w http.ResponseWriter, r *http.Request) {
+		a, err := t.Agent(r.PathValue("id"))
+		writeAPI(w, a, err)
+	})
+	mux.HandleFunc("POST /agents/{id}/start", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Resume bool `json:"resume"` // continue the last conversation
+		}
+		json.NewDecoder(r.Body).Decode(&body) // body is optional
+		writeAPI(w, nil, t.Start(r.PathValue("id"), body.Resume))
+	})
+	mux.HandleFunc("POST /agents/{id}/stop", func(w http.ResponseWriter, r *http.Request) {
+		writeAPI(w, nil, t.Stop(r.PathValue("id")))
+	})
+	mux.HandleFunc("POST /agents/{id}/input", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Text   string `json:"text"`
+			Submit bool   `json:"submit"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		text := body.Text
+		if body.Submit {
+			text += "\r"
+		}
+		writeAPI(w, nil, t.SendInput(r.PathValue("id"), []byte(text)))
+	})
+	mux.HandleFunc("POST /agents/{id}/handoff", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			To      string `json:"to"`
+			Summary string `json:"summary"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.To == "" {
+			http.Error(w, `expected {"to": "<agent>"}`, http.StatusBadRequest)
+			return
+		}
+		writeAPI(w, nil, t.Handoff(r.PathValue("id"), body.To, body.Summary))
+	})
+	mux.HandleFunc("GET /agents/{id}/screen", func(w http.ResponseWriter, r *http.Request) {
+		screen, err := t.Screen(r.PathValue("id"), r.URL.Query().Get("ansi") == "1")
+		if err != nil {
+			writeAPI(w, nil, err)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprint(w, screen)
+	})
+	mux.HandleFunc("POST /agents/{id}/message", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			To   string `json:"to"`
+			Text string `json:"text"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Text == "" {
+			http.Error(w, `expected {"to": "<agent>|all", "text": "..."}`, http.StatusBadRequest)
+			return
+		}
+		writeAPI(w, nil, t.PostMessage(r.PathValue("id"), body.To, body.Text))
+	})
+	mux.HandleFunc("POST /agents/{id}/inbox/read", func(w http.ResponseWriter, r *http.Request) {
+		msgs, err := t.ReadInbox(r.PathValue("id"))
+		writeAPI(w, msgs, err)
+	})
+	mux.HandleFunc("POST /agents/{id}/task", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Text string `json:"text"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Text == "" {
+			http.Error(w, `expected {"text": "..."}`, http.StatusBadRequest)
+			return
+		}
+		writeAPI(w, nil, t.SetTask(r.PathValue("id"), body.Text))
+	})
+	mux.HandleFunc("POST /agents/{id}/review", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Reviewer string `json:"reviewer"` // optional; default is a teammate whose class reviews
+			Summary  string `json:"summary"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Summary == "" {
+			http.Error(w, `expected {"summary": "...", "reviewer": "<agent>"}`, http.StatusBadRequest)
+			return
+		}
+		reviewer, err := t.RequestReview(r.PathValue("id"), body.Reviewer, body.Summary)
+		writeAPI(w, map[string]string{"reviewer": reviewer}, err)
+	})
+	mux.HandleFunc("POST /agents/{id}/activity", func(w http.ResponseWriter, r *http.Request) {
+		var ev toolEvent
+		if err := json.NewDecoder(r.Body).Decode(&ev); err != nil || ev.Tool == "" ||
+			(ev.Phase != ToolUseStart && ev.Phase != ToolUseEnd) {
+			http.Error(w, `expected {"phase": "pre|post", "tool": "..."}`, http.StatusBadRequest)
+			return
+		}
+		writeAPI(w, nil, t.RecordToolUse(r.PathValue("id"), ev))
+	})
+	mux.HandleFunc("GET /agents/{id}/activity", func(w http.ResponseWriter, r *http.Request) {
+		feed, err := t.Activity(r.PathValue("id"))
+		writeAPI(w, feed, err)
+	})
+
+	return mux
+}
+
+// writeAPI encodes v as JSON, or maps err to a status code.
+func writeAPI(w http.ResponseWriter, v any, err error) {
+	if err != nil {
+		code := http.StatusConflict
+		switch {
+		case errors.Is(err, errAgentNotFound):
+			code = http.StatusNotFound
+		case errors.Is(err, errUnsupported):
+			code = http.StatusNotImplemented
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+	if v == nil {
+		v = map[string]bool{"ok": true}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func toAPIAgent(inst *AgentInstance) *apiAgent {
+	if inst == nil {
+		return nil
+	}
+	return &apiAgent{
+		ID:            inst.ID,
+		Agent:         inst.AgentName,
+		Class:         inst.ClassName,
+		Backend:       inst.Backend,
+		Status:        inst.Status,
+		Task:          inst.Task,
+		ContextTokens: inst.ContextTokens,
+		ContextMax:    inst.ContextMax,
+		ContextBytes:  inst.ContextBytes,
+		Worktree:      inst.Worktree,
+		Branch:        inst.Branch,
+		Unread:        inst.Inbox.Unread,
+		Review:        inst.ReviewRequest,
+		Approval:      approvalPrompt(inst),
+	}
+}
+
+// ── TUI Target ────────────────────────────────────────────────────
+
+// apiRequestMsg runs fn inside the bubbletea loop so API calls see and
+// mutate the same state as key handlers.
+type apiRequestMsg struct {
+	fn    func(m *Model) (any, tea.Cmd, error)
+	reply chan apiReply
+}
+
+type apiReply struct {
+	v   any
+	err error
+}
+
+func (m Model) handleAPIRequest(msg apiRequestMsg) (tea.Model, tea.Cmd) {
+	v, cmd, err := msg.fn(&m)
+	msg.reply <- apiReply{v, err}
+	return m, cmd
+}
+
+// tuiTarget forwards API calls to a running tea.Program.
+type tuiTarget struct {
+	p *tea.Program
+}
+
+func (t tuiTarget) call(fn func(m *Model) (any, tea.Cmd, error)) (any, error) {
+	reply := make(chan apiReply, 1)
+	go t.p.Send(apiRequestMsg{fn: fn, reply: reply})
+	select {
+	case r := <-reply:
+		return r.v, r.err
+	case <-time.After(5 * time.Second):
+		return nil, errors.New("timed out waiting for the TUI")
+	}
+}
+
+// withAgent runs fn on the agent with the given ID.
+func (t tuiTarget) withAgent(id string, fn func(m *Model, inst *AgentInstance) (any, tea.Cmd, error)) (any, error) {
+	return t.call(func(m *Model) (any, tea.Cmd, error) {
+		inst := m.agentByID(id)
+		if inst == nil {
+			return nil, nil, errAgentNotFound
+		}
+		return fn(m, inst)
+	})
+}
+
+func (t tuiTarget) Parties() ([]apiParty, error) {
+	v, err := t.call(func(m *Model) (any, tea.Cmd, error) {
+		parties := make([]apiParty, 0, len(m.parties))
+		for _, p := range m.parties {
+			ap := apiParty{Name: p.Name, Project: p.Project, Bench: []*apiAgent{}}
+			for _, inst := range p.Slots {
+				ap.Slots = append(ap.Slots, toAPIAgent(inst))
+			}
+			for _, inst := range p.Bench {
+				ap.Bench = append(ap.Bench, toAPIAgent(inst))
+			}
+			parties = append(parties, ap)
+		}
+		return parties, nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]apiParty), nil
+}
+
+func (t tuiTarget) Agent(id string) (*apiAgent, error) {
+	v, err := t.withAgent(id, func(m *Model, inst *AgentInstance) (any, tea.Cmd, error) {
+		return toAPIAgent(inst), nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*apiAgent), nil
+}
+
+func (t tuiTarget) Start(id string, resume bool) error {
+	_, err := t.withAgent(id, func(m *Model, inst *AgentInstance) (any, tea.Cmd, error) {
+		if resume && !m.canResume(inst) {
+			return nil, nil, fmt.Errorf("%s has no resumable conversation", id)
+		}
+		cmd := m.startInstance(inst, resume)
+		if cmd == nil {
+			return nil, nil, fmt.Errorf("cannot start %s (status %s)", id, inst.Status)
+		}
+		return nil, cmd, nil
+	})
+	return err
+}
+
+func (t tuiTarget) Stop(id string) error {
+	_, err := t.withAgent(id, func(m *Model, inst *AgentInstance) (any, tea.Cmd, error) {
+		if inst.Status != "running" {
+			return nil, nil, fmt.Errorf("%s is not running", id)
+		}
+		m.stopInstance(inst)
+		return nil, nil, nil
+	})
+	return err
+}
+
+func (t tuiTarget) SendInput(id string, data []byte) error {
+	_, err := t.withAgent(id, func(m *Model, inst *AgentInstance) (any, tea.Cmd, error) {
+		if inst.Status != "running" || inst.proc == nil {
+			return nil, nil, fmt.Errorf("%s is not running", id)
+		}
+		inst.proc.Write(data)
+		inst.ContextBytes += int64(len(data))
+		return nil, nil, nil
+	})
+	return err
+}
+
+func (t tuiTarget) Handoff(from, to, summary string) error {
+	_, err := t.withAgent(from, func(m *Model, src *AgentInstance) (any, tea.Cmd, error) {
+		dst := m.teammate(src, to)
+		if dst == nil {
+			return nil, nil, fmt.Errorf("%s: %w", to, errAgentNotFound)
+		}
+		output := summary
+		if output == "" {
+			if src.Status == "running" && src.emulator != nil {
+				src.LastOutput = snapshotOutput(src.emulator)
+			}
+			output = src.LastOutput
+		}
+		if output == "" {
+			return nil, nil, fmt.Errorf("%s has no output to hand off", from)
+		}
+		dst.HandoffContext = handoffSection(src.AgentName, src.ClassName, output)
+		m.deliver(src, dst, MessageHandoff, output)
+		m.savePartyState(m.partyForAgent(dst))
+		return nil, nil, nil
+	})
+	return err
+}
+
+func (t tuiTarget) Screen(id string, ansi bool) (string, error) {
+	v, err := t.withAgent(id, func(m *Model, inst *AgentInstance) (any, tea.Cmd, error) {
+		if inst.emulator == nil {
+			return inst.LastOutput, nil, nil
+		}
+		if ansi {
+			return inst.emulator.Render(), nil, nil
+		}
+		return emulatorText(inst.emulator), nil, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return v.(string), nil
+}
+
+func (t tuiTarget) PostMessage(from, to, text string) error {
+	_, err := t.withAgent(from, func(m *Model, src *AgentInstance) (any, tea.Cmd, error) {
+		if to == "" || to == "all" {
+			p := m.partyForAgent(src)
+			if p == nil {
+				return nil, nil, fmt.Errorf("%s is not in a party", from)
+			}
+			for _, dst := range p.Slots {
+				if dst != nil && dst != src {
+					m.deliver(src, dst, MessageNote, text)
+				}
+			}
+			return nil, nil, nil
+		}
+		dst := m.teammate(src, to)
+		if dst == nil {
+			return nil, nil, fmt.Errorf("%s: %w", to, errAgentNotFound)
+		}
+		m.deliver(src, dst, MessageNote, text)
+		return nil, nil, nil
+	})
+	return err
+}
+
+func (t tuiTarget) ReadInbox(id string) ([]forgeMessage, error) {
+	v, err := t.withAgent(id, func(m *Model, inst *AgentInstance) (any, tea.Cmd, error) {
+		return inst.Inbox.read(), nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]forgeMessage), nil
+}
+
+func (t tuiTarget) SetTask(id, text string) error {
+	_, err := t.withAgent(id, func(m *Model, inst *AgentInstance) (any, tea.Cmd, error) {
+		inst.Task = text
+		return nil, nil, nil
+	})
+	return err
+}
+
+func (t tuiTarget) RequestReview(from, reviewer, summary string) (string, error) {
+	v, err := t.withAgent(from, func(m *Model, src *AgentInstance) (any, tea.Cmd, error) {
+		var dst *AgentInstance
+		if reviewer != "" {
+			if dst = m.teammate(src, reviewer); dst == nil {
+				return nil, nil, fmt.Errorf("%s: %w", reviewer, errAgentNotFound)
+			}
+		} else if p := m.partyForAgent(src); p != nil {
+			dst = defaultReviewer(src, p.Slots[:])
+		}
+		src.ReviewRequest = summary
+		src.reviewer = ""
+		if dst == nil {
+			return "", nil, nil
+		}
+		src.reviewer = dst.ID
+		m.deliver(src, dst, MessageReview, summary)
+		return dst.ID, nil, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return v.(string), nil
+}
+
+func (t tuiTarget) RecordToolUse(id string, ev toolEvent) error {
+	_, err := t.withAgent(id, func(m *Model, inst *AgentInstance) (any, tea.Cmd, error) {
+		inst.recordToolUse(ev)
+		return nil, nil, nil
+	})
+	return err
+}
+
+func (t tuiTarget) Activity(id string) ([]toolActivity, error) {
+	v, err := t.withAgent(id, func(m *Model, inst *AgentInstance) (any, tea.Cmd, error) {
+		return append([]toolActivity{}, inst.Activity...), nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]toolActivity), nil
+}
+
+// ── Coordination ──────────────────────────────────────────────────
+
+// maxInbox is how many messages an agent's inbox keeps.
+const maxInbox = 50
+
+// teammate resolves ref, an agent ID or the name of an agent in src's party.
+func (m *Model) teammate(src *AgentInstance, ref string) *AgentInstance {
+	if inst := m.agentByID(ref); inst != nil {
+		return inst
+	}
+	p := m.partyForAgent(src)
+	if p == nil {
+		return nil
+	}
+	for _, inst := range append(p.Slots[:], p.Bench...) {
+		if inst != nil && strings.EqualFold(inst.AgentName, ref) {
+			return inst
+		}
+	}
+	return nil
+}
+
+// agentInbox holds the messages sent to one agent.
+type agentInbox struct {
+	Messages []forgeMessage // oldest first
+	Unread   int            // how many trailing Messages the agent hasn't read
+}
+
+func (b *agentInbox) add(msg forgeMessage) {
+	b.Messages = append(b.Messages, msg)
+	b.Unread++
+	if n := len(b.Messages) - maxInbox; n > 0 {
+		b.Messages = b.Messages[n:]
+		b.Unread = min(b.Unread, maxInbox)
+	}
+}
+
+// read returns the unread messages and marks them read.
+func (b *agentInbox) read() []forgeMessage {
+	unread := append([]forgeMessage{}, b.Messages[len(b.Messages)-b.Unread:]...)
+	b.Unread = 0
+	return unread
+}
+
+func newMessage(fromID, fromName, kind, text string) forgeMessage {
+	return forgeMessage{From: fromID, Agent: fromName, Kind: kind, Text: text, Sent: time.Now()}
+}
+
+// deliver adds a message from src to dst's inbox. A message from the
+// agent dst asked for a review answers the request.
+func (m *Model) deliver(src, dst *AgentInstance, kind, text string) {
+	dst.Inbox.add(newMessage(src.ID, src.AgentName, kind, text))
+	if dst.reviewer == src.ID {
+		dst.ReviewRequest = ""
+		dst.reviewer = ""
+	}
+}
+
+// defaultReviewer picks the first of candidates, other than src, whose
+// class reviews code.
+func defaultReviewer(src *AgentInstance, candidates []*AgentInstance) *AgentInstance {
+	for _, inst := range candidates {
+		if inst != nil && inst != src && strings.Contains(strings.ToLower(inst.ClassName), "review") {
+			return inst
+		}
+	}
+	return nil
+}
+
+// ── Raid Target ───────────────────────────────────────────────────
+
+// raidTarget exposes headless raid agents. Raid processes write straight
+// to stdout with no PTY, so there is no input or screen; agents that have
+// started can still message each other through the forge tools.
+type raidTarget struct {
+	mu      sync.Mutex
+	party   string
+	project string
+	agents  []*raidAgent
+}
+
+type raidAgent struct {
+	info     apiAgent
+	stop     func() // asks the raid to stop the agent; nil until it runs
+	inbox    agentInbox
+	reviewer string // ID of the agent asked to review, if any
+	activity activityFeed
+}
+
+func (t *raidTarget) add(a *raidAgent) {
+	t.mu.Lock()
+	t.agents = append(t.agents, a)
+	t.mu.Unlock()
+}
+
+// setStatus updates an agent's status and task under the target lock.
+func (t *raidTarget) setStatus(a *raidAgent, status, task string) {
+	t.mu.Lock()
+	a.info.Status = status
+	a.info.Task = task
+	t.mu.Unlock()
+}
+
+func (t *raidTarget) find(id string) *raidAgent {
+	for _, a := range t.agents {
+		if a.info.ID == id {
+			return a
+		}
+	}
+	return nil
+}
+
+// teammate resolves ref, an agent ID or name.
+func (t *raidTarget) teammate(ref string) *raidAgent {
+	for _, a := range t.agents {
+		if a.info.ID == ref || strings.EqualFold(a.info.Agent, ref) {
+			return a
+		}
+	}
+	return nil
+}
+
+// deliver is Model.deliver for raid agents. Called with t.mu held.
+func (t *raidTarget) deliver(src, dst *raidAgent, kind, text string) {
+	dst.inbox.add(newMessage(src.info.ID, src.info.Agent, kind, text))
+	dst.info.Unread = dst.inbox.Unread
+	if dst.reviewer == src.info.ID {
+		dst.info.Review = ""
+		dst.reviewer = ""
+	}
+}
+
+func (t *raidTarget) Parties() ([]apiParty, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p := apiParty{Name: t.party, Project: t.project, Bench: []*apiAgent{}}
+	for _, a := range t.agents {
+		info := a.info
+		p.Slots = append(p.Slots, &info)
+	}
+	return []apiParty{p}, nil
+}
+
+func (t *raidTarget) Agent(id string) (*apiAgent, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	a := t.find(id)
+	if a == nil {
+		return nil, errAgentNotFound
+	}
+	info := a.info
+	return &info, nil
+}
+
+func (t *raidTarget) Stop(id string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	a := t.find(id)
+	if a == nil {
+		return errAgentNotFound
+	}
+	if a.info.Status != "running" || a.stop == nil {
+		return fmt.Errorf("%s is not running", id)
+	}
+	a.info.Task = "Stopping..."
+	a.stop()
+	return nil
+}
+
+// Handoff messages a summary to a running teammate. Raid agents don't
+// restart, so there is no prompt to carry it into; use after: for that.
+func (t *raidTarget) Handoff(from, to, summary string) error {
+	if summary == "" {
+		return fmt.Errorf("handoff in a raid needs a summary: %w", errUnsupported)
+	}
+	return t.send(from, to, MessageHandoff, summary)
+}
+
+func (t *raidTarget) PostMessage(from, to, text string) error {
+	return t.send(from, to, MessageNote, text)
+}
+
+func (t *raidTarget) send(from, to, kind, text string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	src := t.find(from)
+	if src == nil {
+		return errAgentNotFound
+	}
+	if to == "" || to == "all" {
+		for _, dst := range t.agents {
+			if dst != src {
+				t.deliver(src, dst, kind, text)
+			}
+		}
+		return nil
+	}
+	dst := t.teammate(to)
+	if dst == nil {
+		return fmt.Errorf("%s: %w", to, errAgentNotFound)
+	}
+	t.deliver(src, dst, kind, text)
+	return nil
+}
+
+func (t *raidTarget) ReadInbox(id string) ([]forgeMessage, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	a := t.find(id)
+	if a == nil {
+		return nil, errAgentNotFound
+	}
+	a.info.Unread = 0
+	return a.inbox.read(), nil
+}
+
+func (t *raidTarget) SetTask(id, text string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	a := t.find(id)
+	if a == nil {
+		return errAgentNotFound
+	}
+	a.info.Task = text
+	return nil
+}
+
+func (t *raidTarget) RequestReview(from, reviewer, summary string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	src := t.find(from)
+	if src == nil {
+		return "", errAgentNotFound
+	}
+	var dst *raidAgent
+	if reviewer != "" {
+		if dst = t.teammate(reviewer); dst == nil {
+			return "", fmt.Errorf("%s: %w", reviewer, errAgentNotFound)
+		}
+	} else {
+		for _, a := range t.agents {
+			if a != src && strings.Contains(strings.ToLower(a.info.Class), "review") {
+				dst = a
+				break
+			}
+		}
+	}
+	src.info.Review = summary
+	src.reviewer = ""
+	if dst == nil {
+		return "", nil
+	}
+	src.reviewer = dst.info.ID
+	t.deliver(src, dst, MessageReview, summary)
+	return dst.info.ID, nil
+}
+
+func (t *raidTarget) RecordToolUse(id string, ev toolEvent) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	a := t.find(id)
+	if a == nil {
+		return errAgentNotFound
+	}
+	text := a.activity.record(ev)
+	if a.info.Status == "running" {
+		a.info.Task = text
+	}
+	return nil
+}
+
+func (t *raidTarget) Activity(id string) ([]toolActivity, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	a := t.find(id)
+	if a == nil {
+		return nil, errAgentNotFound
+	}
+	return append([]toolActivity{}, a.activity...), nil
+}
+
+func (t *raidTarget) Start(id string, resume bool) error     { return errUnsupported }
+func (t *raidTarget) SendInput(id string, data []byte) error { return errUnsupported }
+func (t *raidTarget) Screen(id string, ansi bool) (string, error) {
+	return "", errUnsupported
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGuardAPI(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	tests := []struct {
+		name        string
+		token       string
+		method      string
+		contentType string
+		auth        string
+		want        int
+	}{
+		{"socket json post", "", "POST", "application/json", "", http.StatusOK},
+		{"socket json with charset", "", "POST", "application/json; charset=utf-8", "", http.StatusOK},
+		{"socket form post", "", "POST", "application/x-www-form-urlencoded", "", http.StatusUnsupportedMediaType},
+		{"socket text post", "", "POST", "text/plain", "", http.StatusUnsupportedMediaType},
+		{"socket post without type", "", "POST", "", "", http.StatusUnsupportedMediaType},
+		{"socket get", "", "GET", "", "", http.StatusOK},
+		{"tcp without token", "s3cret", "GET", "", "", http.StatusUnauthorized},
+		{"tcp wrong token", "s3cret", "POST", "application/json", "Bearer nope", http.StatusUnauthorized},
+		{"tcp token", "s3cret", "POST", "application/json", "Bearer s3cret", http.StatusOK},
+		{"tcp token, form post", "s3cret", "POST", "text/plain", "Bearer s3cret", http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/agents/a/input", strings.NewReader(`{"text":"x"}`))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			guardAPI(ok, tt.token).ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestGuardAPIBodylessPost(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	tests := []struct {
+		name    string
+		body    string
+		chunked bool
+		want    int
+	}{
+		{"no body", "", false, http.StatusOK},
+		{"body", `{"text":"x"}`, false, http.StatusUnsupportedMediaType},
+		{"chunked body", `{"text":"x"}`, true, http.StatusUnsupportedMediaType},
+		{"chunked, empty", "", true, http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/agents/a/stop", strings.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = -1
+				req.TransferEncoding = []string{"chunked"}
+			}
+			rec := httptest.NewRecorder()
+			guardAPI(ok, "").ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+
+	// Over the wire, as `curl -X POST .../stop` sends it
+	stopped := ""
+	srv := httptest.NewServer(guardAPI(apiHandler(stopStub{stopped: &stopped}), ""))
+	defer srv.Close()
+	for _, path := range []string{"/agents/a/stop", "/agents/a/start"} {
+		resp, err := http.Post(srv.URL+path, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("body-less POST %s: status %d", path, resp.StatusCode)
+		}
+	}
+	if stopped != "a" {
+		t.Errorf("stop reached the target for %q", stopped)
+	}
+}
+
+// stopStub records Stop and accepts Start.
+type stopStub struct {
+	stopped *string
+	ControlTarget
+}
+
+func (s stopStub) Stop(id string) error               { *s.stopped = id; return nil }
+func (s stopStub) Start(id string, resume bool) error { return nil }
+
+func TestStartAPIOnTCPRequiresToken(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := ensureForgeDir(); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(apiTokenEnv, "")
+	stop, err := startAPI("127.0.0.1:0", targetStub{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	resp, err := http.Get("http://" + controlAddr + "/parties")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("request without token: status %d, want 401", resp.StatusCode)
+	}
+
+	var parties []apiParty
+	if err := newAPIClient(controlAddr).get("/parties", &parties); err != nil {
+		t.Errorf("client with the listener's token: %v", err)
+	}
+}
+
+// targetStub answers Parties; everything else is unused here.
+type targetStub struct{ ControlTarget }
+
+func (targetStub) Parties() ([]apiParty, error) { return []apiParty{{Name: "stub"}}, nil }
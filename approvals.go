@@ -0,0 +1,266 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ── Approvals ─────────────────────────────────────────────────────
+//
+// Agent CLIs stop at permission prompts ("Do you want to proceed?") until
+// someone answers in their terminal. Every running agent's screen is
+// scanned shortly after it prints, using the backend's approval_pattern;
+// an agent at a prompt shows as WAITING on its card, and the approvals
+// panel (p) lists every waiting agent across parties so each request can
+// be approved or denied by typing the backend's approve_keys or deny_keys
+// into its terminal, without entering insert mode.
+
+// approvalCheckDelay is how soon after output an agent's screen is
+// scanned for a prompt. Output keeps coming while an agent works, so the
+// scan that matters is the one after it goes quiet.
+const approvalCheckDelay = 200 * time.Millisecond
+
+// approvalRequest is a permission prompt waiting on an agent's screen.
+type approvalRequest struct {
+	Prompt string // the question and the lines describing what it's for
+	Since  time.Time
+}
+
+// approvalPrompt is inst's waiting prompt, or "".
+func approvalPrompt(inst *AgentInstance) string {
+	if inst.Approval == nil {
+		return ""
+	}
+	return inst.Approval.Prompt
+}
+
+// approvalCheckMsg asks for one agent's screen to be scanned.
+type approvalCheckMsg struct {
+	ID string
+}
+
+func approvalCheck(id string) tea.Cmd {
+	return tea.Tick(approvalCheckDelay, func(time.Time) tea.Msg {
+		return approvalCheckMsg{ID: id}
+	})
+}
+
+// FindApproval looks for a permission prompt in plain screen text using
+// the backend's approval_pattern. The prompt is the matched question with
+// up to four lines of context above it.
+func (b *BackendConfig) FindApproval(screen string) (string, bool) {
+	if b.approvalRe == nil {
+		return "", false
+	}
+	loc := b.approvalRe.FindStringIndex(screen)
+	if loc == nil {
+		return "", false
+	}
+	above := strings.Split(screen[:loc[0]], "\n")
+	question, _, _ := strings.Cut(screen[loc[0]:], "\n")
+	question = cleanPromptLine(above[len(above)-1] + question)
+
+	lines := []string{question}
+	for i := len(above) - 2; i >= 0 && len(lines) < 5; i-- {
+		l := cleanPromptLine(above[i])
+		if strings.Trim(l, "─━╭╮╰╯┌┐└┘ ") == "" {
+			if l != "" {
+				break // top border of the prompt box
+			}
+			continue
+		}
+		lines = append([]string{l}, lines...)
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+// cleanPromptLine drops box borders and padding around a screen line.
+func cleanPromptLine(l string) string {
+	return strings.TrimSpace(strings.Trim(strings.TrimSpace(l), "│┃"))
+}
+
+// scanApproval updates inst.Approval from its screen.
+func scanApproval(inst *AgentInstance) {
+	if inst.emulator == nil || inst.backend == nil {
+		return
+	}
+	prompt, ok := inst.backend.FindApproval(emulatorText(inst.emulator))
+	switch {
+	case !ok:
+		inst.Approval = nil
+	case inst.Approval == nil || inst.Approval.Prompt != prompt:
+		inst.Approval = &approvalRequest{Prompt: prompt, Since: time.Now()}
+	}
+}
+
+func (m Model) handleApprovalCheck(msg approvalCheckMsg) (tea.Model, tea.Cmd) {
+	inst := m.agentByID(msg.ID)
+	if inst == nil {
+		return m, nil
+	}
+	inst.approvalCheckPending = false
+	if inst.Status == "running" {
+		scanApproval(inst)
+	}
+	if m.mode == ModeApprovals {
+		m.approvalCursor = min(m.approvalCursor, max(len(m.pendingApprovals())-1, 0))
+	}
+	return m, nil
+}
+
+// scheduleApprovalCheck returns a scan of inst's screen unless one is
+// already due.
+func scheduleApprovalCheck(inst *AgentInstance) tea.Cmd {
+	if inst.backend == nil || inst.backend.approvalRe == nil || inst.approvalCheckPending {
+		return nil
+	}
+	inst.approvalCheckPending = true
+	return approvalCheck(inst.ID)
+}
+
+// answerApproval types the backend's approve or deny keys into inst's
+// terminal and rescans once it has redrawn.
+func answerApproval(inst *AgentInstance, approve bool) tea.Cmd {
+	if inst.Approval == nil || inst.proc == nil || inst.backend == nil {
+		return nil
+	}
+	keys := inst.backend.DenyKeys
+	if approve {
+		keys = inst.backend.ApproveKeys
+	}
+	if keys == "" {
+		return nil
+	}
+	inst.proc.Write([]byte(keys))
+	inst.ContextBytes += int64(len(keys))
+	inst.Approval = nil
+	return scheduleApprovalCheck(inst)
+}
+
+// pendingApprovals lists waiting agents in party order, slots before
+// bench: a benched agent keeps running and can stop at a prompt too.
+func (m Model) pendingApprovals() []*AgentInstance {
+	var waiting []*AgentInstance
+	for _, p := range m.parties {
+		for _, inst := range append(p.Slots[:], p.Bench...) {
+			if inst != nil && inst.Status == "running" && inst.Approval != nil {
+				waiting = append(waiting, inst)
+			}
+		}
+	}
+	return waiting
+}
+
+// ── Approvals Mode ────────────────────────────────────────────────
+
+func (m Model) openApprovals() Model {
+	m.approvalCursor = 0
+	m.pushMode(ModeApprovals)
+	return m
+}
+
+func (m Model) handleApprovalsMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	waiting := m.pendingApprovals()
+	var sel *AgentInstance
+	if m.approvalCursor < len(waiting) {
+		sel = waiting[m.approvalCursor]
+	}
+
+	switch msg.String() {
+	case "esc", "q", "p":
+		m.popMode()
+	case "up", "k":
+		if m.approvalCursor > 0 {
+			m.approvalCursor--
+		}
+	case "down", "j":
+		if m.approvalCursor < len(waiting)-1 {
+			m.approvalCursor++
+		}
+	case "y":
+		if sel != nil {
+			cmd := answerApproval(sel, true)
+			m.approvalCursor = min(m.approvalCursor, max(len(waiting)-2, 0))
+			return m, cmd
+		}
+	case "n":
+		if sel != nil {
+			cmd := answerApproval(sel, false)
+			m.approvalCursor = min(m.approvalCursor, max(len(waiting)-2, 0))
+			return m, cmd
+		}
+	case "enter":
+		// Jump to the agent's terminal to answer it by hand
+		if sel != nil {
+			m.selectAgent(sel)
+			m.popMode()
+			m.focus = FocusMainPane
+		}
+	}
+	return m, nil
+}
+
+// selectAgent makes inst the selected agent, switching parties if needed.
+func (m *Model) selectAgent(inst *AgentInstance) {
+	for pi, p := range m.parties {
+		for si, s := range p.Slots {
+			if s == inst {
+				if pi != m.activeParty {
+					m.activeParty = pi
+					m.recomputeLayout()
+					m.resizeActivePartyAgents()
+				}
+				m.selectedAgent = si
+				return
+			}
+		}
+	}
+}
+
+// ── Approvals View ────────────────────────────────────────────────
+
+func (m Model) renderApprovals(tw, th int) string {
+	dim := lipgloss.NewStyle().Foreground(colorTextDim)
+	waiting := m.pendingApprovals()
+
+	var lines []string
+	lines = append(lines, csHeaderStyle.Render(fmt.Sprintf(" APPROVALS (%d)", len(waiting))), "")
+	if len(waiting) == 0 {
+		lines = append(lines, dim.Render("  No agent is waiting for permission."))
+	}
+	for i, inst := range waiting {
+		prefix := "   "
+		style := lipgloss.NewStyle().Foreground(colorText).Bold(true)
+		if i == m.approvalCursor {
+			prefix = " > "
+			style = lipgloss.NewStyle().Foreground(colorTextBright).Bold(true)
+		}
+		who := inst.AgentName
+		if p := m.partyForAgent(inst); p != nil {
+			who = p.Name + " / " + inst.AgentName
+			if slices.Contains(p.Bench, inst) {
+				who += " (bench)"
+			}
+		}
+		lines = append(lines, style.Render(prefix+who)+
+			dim.Render("  waiting "+formatDuration(time.Since(inst.Approval.Since))))
+		for _, l := range strings.Split(inst.Approval.Prompt, "\n") {
+			lines = append(lines, "     "+truncLine(l, tw-6))
+		}
+		lines = append(lines, "")
+	}
+
+	if len(lines) > th {
+		lines = lines[:th]
+	}
+	content := lipgloss.NewStyle().Width(tw).Height(th).Render(strings.Join(lines, "\n"))
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorRed).
+		Render(content)
+}
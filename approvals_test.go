@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestApprovalsPanelApprovesPermissionPrompt(t *testing.T) {
+	h := newHarness(t, PartyFile{Name: "gated", Slots: []PartySlotConfig{{Agent: "Builder"}}})
+	h.WaitFor(fakeAgentReady)
+	h.Keys("i")
+	h.Type("ask rm -rf build\r")
+	h.Keys("esc")
+	h.WaitFor("WAITING")
+	h.Keys("p")
+	h.WaitFor("APPROVALS (1)")
+	h.Keys("y")
+	h.WaitFor("No agent is waiting")
+	h.Keys("esc")
+	h.WaitFor("approved: rm -rf build")
+}
+
+func TestFindApproval(t *testing.T) {
+	claude, _ := (&ForgeConfig{}).Backend("claude")
+	screen := `> run the migration
+
+╭──────────────────────────────────────╮
+│ Bash command                         │
+│                                      │
+│   psql -f migrate.sql                │
+│   Apply the schema migration         │
+│                                      │
+│ Do you want to proceed?              │
+│ ❯ 1. Yes                             │
+│   2. No, and tell Claude what to do  │
+╰──────────────────────────────────────╯`
+	prompt, ok := claude.FindApproval(screen)
+	want := "Bash command\npsql -f migrate.sql\nApply the schema migration\nDo you want to proceed?"
+	if !ok || prompt != want {
+		t.Errorf("prompt %q, %v; want %q", prompt, ok, want)
+	}
+	if _, ok := claude.FindApproval("> Do you want to proceed? I can also stop here."); ok {
+		t.Error("question without the options matched")
+	}
+	if _, ok := (&BackendConfig{}).FindApproval(screen); ok {
+		t.Error("backend without approval_pattern matched")
+	}
+}
+
+func TestPendingApprovalsIncludesBench(t *testing.T) {
+	waiting := func(name string) *AgentInstance {
+		return &AgentInstance{AgentName: name, Status: "running", Approval: &approvalRequest{Prompt: "?"}}
+	}
+	slot, benched := waiting("Builder"), waiting("Scout")
+	idle := &AgentInstance{AgentName: "Planner", Status: "running"}
+	exited := waiting("Reviewer")
+	exited.Status = "exited"
+
+	p := &Party{Name: "p", Bench: []*AgentInstance{benched}}
+	p.Slots[0], p.Slots[1], p.Slots[2] = idle, slot, exited
+	m := Model{parties: []*Party{p}}
+
+	got := m.pendingApprovals()
+	if len(got) != 2 || got[0] != slot || got[1] != benched {
+		t.Errorf("pending %v, want Builder then the benched Scout", got)
+	}
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ── Agent CLI Backends ────────────────────────────────────────────
+//
+// A backend describes how to launch one agent CLI (claude, codex, aider, pi):
+// which binary to run, how the composed prompt, tool list and model are
+// passed, and how context usage is scraped from its screen output.
+// Backends are selected per agent or per class in YAML and fall back to
+// the forge-wide default, so one party can mix different agent CLIs.
+
+const defaultBackendName = "claude"
+
+// Prompt delivery modes for BackendConfig.PromptMode.
+const (
+	PromptModeFlag = "flag" // prompt text is passed as the flag value
+	PromptModeFile = "file" // prompt is written to a file and its path is passed
+	PromptModeNone = "none" // backend has no way to receive a system prompt
+)
+
+// BackendConfig is a single agent CLI definition from config.yaml.
+type BackendConfig struct {
+	Binary         string   `yaml:"binary"`
+	Args           []string `yaml:"args,omitempty"`            // always passed before generated flags
+	PromptFlag     string   `yaml:"prompt_flag,omitempty"`     // e.g. --append-system-prompt
+	PromptMode     string   `yaml:"prompt_mode,omitempty"`     // flag (default), file, none
+	ToolsFlag      string   `yaml:"tools_flag,omitempty"`      // empty = backend ignores tool profiles
+	ToolsSeparator string   `yaml:"tools_separator,omitempty"` // default ","
+	ModelFlag      string   `yaml:"model_flag,omitempty"`
+	ContextPattern string   `yaml:"context_pattern,omitempty"` // regex: (used)K / (max)K
+	SessionIDFlag  string   `yaml:"session_id_flag,omitempty"` // pins a new conversation ID, e.g. --session-id
+	ResumeFlag     string   `yaml:"resume_flag,omitempty"`     // resumes a conversation by ID, e.g. --resume
+	HeadlessArgs   []string `yaml:"headless_args,omitempty"`   // run one task and exit, e.g. [-p]; placed before Args
+	TaskFlag       string   `yaml:"task_flag,omitempty"`       // passes the headless task; empty = positional after HeadlessArgs
+	HeadlessFormat string   `yaml:"headless_format,omitempty"` // stdout in headless mode: text (default) or stream-json
+	Pricing        *Pricing `yaml:"pricing,omitempty"`         // estimates cost for raid budgets when the backend doesn't report it
+	MCPConfigFlag  string   `yaml:"mcp_config_flag,omitempty"` // loads an MCP config file, e.g. --mcp-config; gives agents the forge tools
+	MCPTools       string   `yaml:"mcp_tools,omitempty"`       // tools_flag entry allowing the forge tools, e.g. mcp__forge
+	SettingsFlag   string   `yaml:"settings_flag,omitempty"`   // loads a claude-style settings file, e.g. --settings; used for tool-use hooks
+
+	// Permission prompts (see approvals.go)
+	ApprovalPattern string `yaml:"approval_pattern,omitempty"` // regex matching a prompt's question on screen
+	ApproveKeys     string `yaml:"approve_keys,omitempty"`     // typed to approve, e.g. "\r"
+	DenyKeys        string `yaml:"deny_keys,omitempty"`        // typed to deny, e.g. "\x1b"
+
+	name       string
+	contextRe  *regexp.Regexp
+	approvalRe *regexp.Regexp
+	set        map[string]bool // keys given in config.yaml, even if empty
+}
+
+// Pricing is a backend's price in USD per million tokens.
+type Pricing struct {
+	Input      float64 `yaml:"input"`
+	Output     float64 `yaml:"output"`
+	CacheRead  float64 `yaml:"cache_read,omitempty"`
+	CacheWrite float64 `yaml:"cache_write,omitempty"`
+}
+
+// builtinBackends are always available, even if config.yaml predates backends.
+// Entries in config.yaml with the same name override them field by field.
+var builtinBackends = map[string]BackendConfig{
+	"claude": {
+		Binary:         "claude",
+		PromptFlag:     "--append-system-prompt",
+		ToolsFlag:      "--allowedTools",
+		ModelFlag:      "--model",
+		ContextPattern: contextPattern.String(),
+		SessionIDFlag:  "--session-id",
+		ResumeFlag:     "--resume",
+		HeadlessArgs:   []string{"-p", "--output-format", "stream-json", "--verbose"},
+		HeadlessFormat: HeadlessFormatStreamJSON,
+		MCPConfigFlag:  "--mcp-config",
+		MCPTools:       "mcp__" + mcpServerName,
+		SettingsFlag:   "--settings",
+		// "Do you want to proceed?" above a "❯ 1. Yes" option; enter picks
+		// the highlighted Yes, esc is claude's "No, and tell Claude..."
+		ApprovalPattern: `Do you want to [^\n?]*\?[\s│]*❯\s*1\. Yes`,
+		ApproveKeys:     "\r",
+		DenyKeys:        "\x1b",
+	},
+	"codex": {
+		Binary:       "codex",
+		PromptFlag:   "--config=experimental_instructions_file=",
+		PromptMode:   PromptModeFile,
+		ModelFlag:    "--model",
+		HeadlessArgs: []string{"exec"},
+	},
+	"aider": {
+		Binary:       "aider",
+		PromptFlag:   "--read",
+		PromptMode:   PromptModeFile,
+		ModelFlag:    "--model",
+		HeadlessArgs: []string{"--yes-always", "--no-pretty"},
+		TaskFlag:     "--message",
+	},
+	"pi": {
+		Binary:       "pi",
+		PromptFlag:   "--append-system-prompt",
+		ToolsFlag:    "--tools",
+		ModelFlag:    "--model",
+		HeadlessArgs: []string{"-p"},
+	},
+}
+
+// Name returns the registry key this backend was resolved from.
+func (b *BackendConfig) Name() string { return b.name }
+
+// promptFileName is where file-mode prompts are written in promptDir.
+const promptFileName = "system_prompt.md"
+
+// BuildArgs assembles the CLI arguments for a launch. File-mode prompts are
+// written into promptDir, which must already exist.
+func (b *BackendConfig) BuildArgs(prompt string, tools []string, model, promptDir string) ([]string, error) {
+	args, err := b.PlanArgs(prompt, tools, model, promptDir)
+	if err != nil {
+		return nil, err
+	}
+	if prompt != "" && b.PromptFlag != "" && b.PromptMode == PromptModeFile {
+		if err := os.WriteFile(filepath.Join(promptDir, promptFileName), []byte(prompt), 0644); err != nil {
+			return nil, fmt.Errorf("writing prompt file: %w", err)
+		}
+	}
+	return args, nil
+}
+
+// PlanArgs is BuildArgs without side effects: a file-mode prompt is
+// referenced in promptDir but not written, so it can describe a launch
+// that hasn't happened.
+func (b *BackendConfig) PlanArgs(prompt string, tools []string, model, promptDir string) ([]string, error) {
+	args := append([]string{}, b.Args...)
+
+	if prompt != "" && b.PromptFlag != "" {
+		switch b.PromptMode {
+		case "", PromptModeFlag:
+			args = appendFlag(args, b.PromptFlag, prompt)
+		case PromptModeFile:
+			args = appendFlag(args, b.PromptFlag, filepath.Join(promptDir, promptFileName))
+		case PromptModeNone:
+		default:
+			return nil, fmt.Errorf("backend %q: unknown prompt_mode %q", b.name, b.PromptMode)
+		}
+	}
+
+	if len(tools) > 0 && b.ToolsFlag != "" {
+		sep := b.ToolsSeparator
+		if sep == "" {
+			sep = ","
+		}
+		args = appendFlag(args, b.ToolsFlag, strings.Join(tools, sep))
+	}
+
+	if model != "" && b.ModelFlag != "" {
+		args = appendFlag(args, b.ModelFlag, model)
+	}
+
+	return args, nil
+}
+
+// appendFlag adds a flag/value pair. Flags ending in "=" are joined with
+// their value into a single argument (e.g. --config=key=value).
+func appendFlag(args []string, flag, value string) []string {
+	if strings.HasSuffix(flag, "=") {
+		return append(args, flag+value)
+	}
+	return append(args, flag, value)
+}
+
+// SupportsHeadless reports whether the backend can run a single task
+// non-interactively, as raid needs.
+func (b *BackendConfig) SupportsHeadless() bool { return len(b.HeadlessArgs) > 0 }
+
+// HeadlessTaskArgs wraps launch args from BuildArgs so the CLI runs task
+// without a terminal and exits when done. A positional task goes right
+// after HeadlessArgs, since variadic flags (claude's --allowedTools) would
+// swallow a trailing one.
+func (b *BackendConfig) HeadlessTaskArgs(args []string, task string) []string {
+	out := append([]string{}, b.HeadlessArgs...)
+	if b.TaskFlag != "" {
+		return appendFlag(append(out, args...), b.TaskFlag, task)
+	}
+	return append(append(out, task), args...)
+}
+
+// Cost returns the USD cost of usage u: the backend's own figure when it
+// reports one, otherwise an estimate from its pricing. ok is false when
+// neither is available.
+func (b *BackendConfig) Cost(u tokenUsage) (usd float64, ok bool) {
+	if u.CostUSD > 0 {
+		return u.CostUSD, true
+	}
+	if b.Pricing == nil {
+		return 0, false
+	}
+	p := b.Pricing
+	usd = float64(u.Input)*p.Input + float64(u.Output)*p.Output +
+		float64(u.CacheRead)*p.CacheRead + float64(u.CacheWrite)*p.CacheWrite
+	return usd / 1e6, true
+}
+
+// SupportsResume reports whether the backend lets us pick a conversation ID
+// up front and resume it later.
+func (b *BackendConfig) SupportsResume() bool {
+	return b.SessionIDFlag != "" && b.ResumeFlag != ""
+}
+
+// ConversationArgs returns the flags that start conversation id fresh or
+// resume it.
+func (b *BackendConfig) ConversationArgs(id string, resume bool) []string {
+	if resume {
+		return appendFlag(nil, b.ResumeFlag, id)
+	}
+	return appendFlag(nil, b.SessionIDFlag, id)
+}
+
+// newConversationID returns a random RFC 4122 version 4 UUID, the format
+// claude expects for --session-id.
+func newConversationID() string {
+	var u [16]byte
+	rand.Read(u[:])
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:])
+}
+
+// ParseContext extracts context usage from rendered screen text using the
+// backend's context_pattern. ok is false when the backend has no pattern
+// or nothing matched.
+func (b *BackendConfig) ParseContext(screen string) (used, max float64, ok bool) {
+	if b.contextRe == nil {
+		return 0, 0, false
+	}
+	m := b.contextRe.FindStringSubmatch(screen)
+	if len(m) < 3 {
+		return 0, 0, false
+	}
+	used, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	max, err = strconv.ParseFloat(m[2], 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return used, max, true
+}
+
+// ── Registry ──────────────────────────────────────────────────────
+
+// Backend resolves a backend by name, merging config.yaml entries over the
+// built-ins: fields a config entry sets replace the built-in's, fields it
+// leaves empty keep the built-in value. An empty name selects the forge
+// default.
+func (cfg *ForgeConfig) Backend(name string) (*BackendConfig, error) {
+	if name == "" {
+		name = cfg.DefaultBackend
+	}
+	if name == "" {
+		name = defaultBackendName
+	}
+
+	builtin, isBuiltin := builtinBackends[name]
+	c := cfg.Backends[name]
+	if !isBuiltin && c == nil {
+		return nil, fmt.Errorf("unknown backend %q", name)
+	}
+	b := builtin
+	if c != nil {
+		mergeBackend(&b, c)
+	}
+	if b.Binary == "" {
+		return nil, fmt.Errorf("backend %q has no binary", name)
+	}
+	b.name = name
+
+	if b.ContextPattern != "" {
+		re, err := regexp.Compile(b.ContextPattern)
+		if err != nil {
+			return nil, fmt.Errorf("backend %q: context_pattern: %w", name, err)
+		}
+		b.contextRe = re
+	}
+	if b.ApprovalPattern != "" {
+		re, err := regexp.Compile(b.ApprovalPattern)
+		if err != nil {
+			return nil, fmt.Errorf("backend %q: approval_pattern: %w", name, err)
+		}
+		b.approvalRe = re
+	}
+	return &b, nil
+}
+
+// UnmarshalYAML records which keys a backend entry sets, so mergeBackend
+// can tell an explicit empty value from one left out.
+func (b *BackendConfig) UnmarshalYAML(node *yaml.Node) error {
+	type plain BackendConfig
+	if err := node.Decode((*plain)(b)); err != nil {
+		return err
+	}
+	b.set = make(map[string]bool)
+	for i := 0; i+1 < len(node.Content) && node.Kind == yaml.MappingNode; i += 2 {
+		b.set[node.Content[i].Value] = true
+	}
+	return nil
+}
+
+// mergeBackend copies every field over sets onto b: its non-zero fields,
+// and any it sets to empty in YAML, so prompt_flag: "" or args: [] clears
+// a built-in value.
+func mergeBackend(b, over *BackendConfig) {
+	dst := reflect.ValueOf(b).Elem()
+	src := reflect.ValueOf(over).Elem()
+	for i := 0; i < src.NumField(); i++ {
+		f := dst.Type().Field(i)
+		key, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if f.IsExported() && (!src.Field(i).IsZero() || over.set[key]) {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+}
+
+// ResolveBackendName picks the backend for an agent: the agent's own
+// setting wins, then its class, then the forge default.
+func ResolveBackendName(cfg *ForgeConfig, agentBackend, className string) string {
+	if agentBackend != "" {
+		return agentBackend
+	}
+	if classCfg := cfg.Classes[className]; classCfg != nil && classCfg.Backend != "" {
+		return classCfg.Backend
+	}
+	if cfg.DefaultBackend != "" {
+		return cfg.DefaultBackend
+	}
+	return defaultBackendName
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"gopkg.in/yaml.v3"
+)
+
+func TestBackendConfigOverrideKeepsBuiltinFields(t *testing.T) {
+	cfg := &ForgeConfig{Backends: map[string]*BackendConfig{
+		"claude": {Binary: "/opt/claude/bin/claude"},
+	}}
+	b, err := cfg.Backend("claude")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Binary != "/opt/claude/bin/claude" {
+		t.Errorf("Binary = %q, want the override", b.Binary)
+	}
+	builtin := builtinBackends["claude"]
+	if b.PromptFlag != builtin.PromptFlag || b.ResumeFlag != builtin.ResumeFlag {
+		t.Errorf("override dropped built-in flags: prompt %q, resume %q", b.PromptFlag, b.ResumeFlag)
+	}
+	if !slices.Equal(b.HeadlessArgs, builtin.HeadlessArgs) {
+		t.Errorf("HeadlessArgs = %q, want %q", b.HeadlessArgs, builtin.HeadlessArgs)
+	}
+	if b.approvalRe == nil || b.contextRe == nil {
+		t.Error("built-in patterns not compiled")
+	}
+}
+
+func TestBackendConfigOverrideClearsExplicitEmpty(t *testing.T) {
+	var cfg ForgeConfig
+	err := yaml.Unmarshal([]byte(`
+backends:
+  claude:
+    binary: claude-wrapper
+    prompt_flag: ""
+    headless_args: []
+    pricing: null
+`), &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := cfg.Backend("claude")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Binary != "claude-wrapper" || b.PromptFlag != "" || len(b.HeadlessArgs) != 0 {
+		t.Errorf("binary %q, prompt flag %q, headless args %q", b.Binary, b.PromptFlag, b.HeadlessArgs)
+	}
+	if b.ToolsFlag != builtinBackends["claude"].ToolsFlag || b.ResumeFlag == "" {
+		t.Error("keys the override leaves out lost their built-in values")
+	}
+}
+
+func TestBackendConfigCustomAndUnknown(t *testing.T) {
+	cfg := &ForgeConfig{Backends: map[string]*BackendConfig{
+		"mine":   {Binary: "my-agent", ModelFlag: "-m"},
+		"broken": {ModelFlag: "-m"},
+	}}
+	b, err := cfg.Backend("mine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Name() != "mine" || b.ModelFlag != "-m" || b.PromptFlag != "" {
+		t.Errorf("custom backend resolved as %+v", b)
+	}
+	if _, err := cfg.Backend("broken"); err == nil {
+		t.Error("backend without a binary resolved")
+	}
+	if _, err := cfg.Backend("nope"); err == nil {
+		t.Error("unknown backend resolved")
+	}
+}
+
+func TestPlanArgs(t *testing.T) {
+	cfg := &ForgeConfig{}
+	resolve := func(name string) *BackendConfig {
+		b, err := cfg.Backend(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	tests := []struct {
+		name    string
+		backend *BackendConfig
+		prompt  string
+		tools   []string
+		model   string
+		want    []string
+	}{
+		{"claude, everything", resolve("claude"), "be brief", []string{"Read", "Edit"}, "opus",
+			[]string{"--append-system-prompt", "be brief", "--allowedTools", "Read,Edit", "--model", "opus"}},
+		{"claude, nothing", resolve("claude"), "", nil, "", []string{}},
+		{"codex, prompt file joined to its flag", resolve("codex"), "be brief", []string{"Read"}, "o3",
+			[]string{"--config=experimental_instructions_file=/run/system_prompt.md", "--model", "o3"}},
+		{"aider, prompt file", resolve("aider"), "be brief", nil, "",
+			[]string{"--read", "/run/system_prompt.md"}},
+		{"fixed args and separator",
+			&BackendConfig{Args: []string{"--quiet"}, PromptFlag: "-s", ToolsFlag: "--tools", ToolsSeparator: " "},
+			"p", []string{"a", "b"}, "", []string{"--quiet", "-s", "p", "--tools", "a b"}},
+		{"prompt mode none", &BackendConfig{PromptFlag: "-s", PromptMode: PromptModeNone}, "p", nil, "", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.backend.PlanArgs(tt.prompt, tt.tools, tt.model, "/run")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("args %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	bad := &BackendConfig{PromptFlag: "-s", PromptMode: "stdin"}
+	if _, err := bad.PlanArgs("p", nil, "", "/run"); err == nil {
+		t.Error("unknown prompt_mode accepted")
+	}
+}
+
+func TestBuildArgsWritesPromptFile(t *testing.T) {
+	dir := t.TempDir()
+	codex, err := (&ForgeConfig{}).Backend("codex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := codex.BuildArgs("be brief", nil, "", dir); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, promptFileName))
+	if err != nil || string(data) != "be brief" {
+		t.Errorf("prompt file %q, %v", data, err)
+	}
+
+	flagDir := t.TempDir()
+	claude, _ := (&ForgeConfig{}).Backend("claude")
+	if _, err := claude.BuildArgs("be brief", nil, "", flagDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(flagDir, promptFileName)); err == nil {
+		t.Error("flag-mode prompt written to a file")
+	}
+}
+
+func TestHeadlessTaskArgs(t *testing.T) {
+	cfg := &ForgeConfig{}
+	claude, _ := cfg.Backend("claude")
+	aider, _ := cfg.Backend("aider")
+	launch := []string{"--allowedTools", "Read"}
+
+	got := claude.HeadlessTaskArgs(launch, "fix it")
+	want := []string{"-p", "--output-format", "stream-json", "--verbose", "fix it", "--allowedTools", "Read"}
+	if !slices.Equal(got, want) {
+		t.Errorf("claude: %q, want the task before the variadic flag: %q", got, want)
+	}
+	got = aider.HeadlessTaskArgs(launch, "fix it")
+	want = []string{"--yes-always", "--no-pretty", "--allowedTools", "Read", "--message", "fix it"}
+	if !slices.Equal(got, want) {
+		t.Errorf("aider: %q, want %q", got, want)
+	}
+}
+
+func TestConversationArgs(t *testing.T) {
+	cfg := &ForgeConfig{}
+	claude, _ := cfg.Backend("claude")
+	if !claude.SupportsResume() {
+		t.Fatal("claude cannot resume")
+	}
+	if got := claude.ConversationArgs("conv-1", false); !slices.Equal(got, []string{"--session-id", "conv-1"}) {
+		t.Errorf("fresh start args %q", got)
+	}
+	if got := claude.ConversationArgs("conv-1", true); !slices.Equal(got, []string{"--resume", "conv-1"}) {
+		t.Errorf("resume args %q", got)
+	}
+	eq := &BackendConfig{SessionIDFlag: "--conversation=", ResumeFlag: "--continue="}
+	if got := eq.ConversationArgs("conv-1", true); !slices.Equal(got, []string{"--continue=conv-1"}) {
+		t.Errorf("resume args with an = flag %q", got)
+	}
+	if codex, _ := cfg.Backend("codex"); codex.SupportsResume() {
+		t.Error("codex reported as resumable")
+	}
+}
+
+func TestPrepareLaunchPinsOrResumesConversation(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := ensureForgeDir(); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &ForgeConfig{}
+	launch := func(backend, conversationID string) *preparedLaunch {
+		t.Helper()
+		pl, err := prepareLaunch(cfg, LaunchConfig{ID: "p-0-Builder", AgentName: "Builder", Backend: backend,
+			ConversationID: conversationID, ProjectDir: t.TempDir()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pl
+	}
+	flagValue := func(args []string, flag string) string {
+		if i := slices.Index(args, flag); i >= 0 && i+1 < len(args) {
+			return args[i+1]
+		}
+		return ""
+	}
+
+	pl := launch("claude", "conv-1")
+	if pl.ConversationID != "conv-1" || flagValue(pl.Command.Args, "--resume") != "conv-1" ||
+		slices.Contains(pl.Command.Args, "--session-id") {
+		t.Errorf("resume: conversation %q, args %q", pl.ConversationID, pl.Command.Args)
+	}
+
+	// Without a stored conversation the launch pins a new one
+	pl = launch("claude", "")
+	if pl.ConversationID == "" || flagValue(pl.Command.Args, "--session-id") != pl.ConversationID ||
+		slices.Contains(pl.Command.Args, "--resume") {
+		t.Errorf("fresh start: conversation %q, args %q", pl.ConversationID, pl.Command.Args)
+	}
+
+	pl = launch("codex", "conv-1")
+	if pl.ConversationID != "" || slices.Contains(pl.Command.Args, "conv-1") {
+		t.Errorf("backend without resume: conversation %q, args %q", pl.ConversationID, pl.Command.Args)
+	}
+}
+
+// launchRecorder records each LaunchConfig, then runs the fake agent.
+type launchRecorder chan LaunchConfig
+
+func (l launchRecorder) Launch(cfg *ForgeConfig, lc LaunchConfig) tea.Cmd {
+	l <- lc
+	return ScriptedLauncher{}.Launch(cfg, lc)
+}
+
+func TestRestartAsksToResume(t *testing.T) {
+	h := newHarness(t, PartyFile{Name: "solo", Slots: []PartySlotConfig{{Agent: "Builder"}}})
+	launches := make(launchRecorder, 4)
+	inst := h.Model().party().Slots[0]
+	exit := func() {
+		t.Helper()
+		h.WaitFor(fakeAgentReady)
+		h.Keys("i")
+		h.Type("exit\r")
+		h.WaitFor("How did it go?")
+		for h.Model().mode != ModeNormal {
+			h.Keys("esc")
+		}
+		h.WaitUntil("Builder exited", func(Model) bool { return inst.Status == "exited" })
+	}
+	exit()
+	DefaultLauncher = launches
+
+	// The backend reported a conversation during the first run
+	inst.ConversationID = "conv-1"
+	h.Keys("s")
+	h.WaitFor("Resume Builder's last conversation?")
+	if len(launches) != 0 {
+		t.Fatal("started before the resume question was answered")
+	}
+	h.Keys("r")
+	if lc := <-launches; lc.ConversationID != "conv-1" {
+		t.Errorf("resume launched with conversation %q", lc.ConversationID)
+	}
+
+	exit()
+	h.Keys("s")
+	h.WaitFor("Resume Builder's last conversation?")
+	h.Keys("f")
+	if lc := <-launches; lc.ConversationID != "" {
+		t.Errorf("fresh start launched with conversation %q", lc.ConversationID)
+	}
+
+	// Nothing to resume: s starts right away
+	exit()
+	inst.ConversationID = ""
+	h.Keys("s")
+	if lc := <-launches; lc.ConversationID != "" || h.Model().resumeAsk != nil {
+		t.Errorf("start without a conversation asked to resume (conversation %q)", lc.ConversationID)
+	}
+}
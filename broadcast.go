@@ -0,0 +1,111 @@
+package main
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ── Broadcast ─────────────────────────────────────────────────────
+//
+// Broadcast mode (I) is insert mode for several agents at once: every
+// keystroke, and every pasted block, is written to each target's PTY.
+// Targets are the active party's slots marked with b (B marks or clears
+// every running agent); with nothing marked, input goes to all running
+// agents in the party. Marked cards get a heavy border and a ⇶ badge,
+// and while broadcasting the targets' panes are outlined in red.
+
+// broadcastTargets lists the running agents broadcast input goes to.
+func (m Model) broadcastTargets() []*AgentInstance {
+	p := m.party()
+	if p == nil {
+		return nil
+	}
+	marked := false
+	for _, inst := range p.Slots {
+		if inst != nil && inst.broadcast {
+			marked = true
+		}
+	}
+	var targets []*AgentInstance
+	for _, inst := range p.Slots {
+		if inst == nil || inst.Status != "running" || inst.proc == nil {
+			continue
+		}
+		if inst.broadcast || !marked {
+			targets = append(targets, inst)
+		}
+	}
+	return targets
+}
+
+// isBroadcastTarget reports whether inst's card should be marked: a
+// current target while broadcasting, otherwise a marked slot.
+func (m Model) isBroadcastTarget(inst *AgentInstance) bool {
+	if m.mode != ModeBroadcast {
+		return inst.broadcast
+	}
+	for _, t := range m.broadcastTargets() {
+		if t == inst {
+			return true
+		}
+	}
+	return false
+}
+
+// toggleBroadcastMark marks or unmarks the selected agent as a target.
+func (m Model) toggleBroadcastMark() Model {
+	if inst := m.agent(); inst != nil {
+		inst.broadcast = !inst.broadcast
+	}
+	return m
+}
+
+// toggleBroadcastAll marks every running agent in the party, or clears
+// all marks when they already are.
+func (m Model) toggleBroadcastAll() Model {
+	p := m.party()
+	if p == nil {
+		return m
+	}
+	all := true
+	for _, inst := range p.Slots {
+		if inst != nil && inst.Status == "running" && !inst.broadcast {
+			all = false
+		}
+	}
+	for _, inst := range p.Slots {
+		if inst != nil {
+			inst.broadcast = !all && inst.Status == "running"
+		}
+	}
+	return m
+}
+
+func (m Model) openBroadcast() Model {
+	if len(m.broadcastTargets()) > 0 {
+		m.pushMode(ModeBroadcast)
+	}
+	return m
+}
+
+// ── Broadcast Mode ────────────────────────────────────────────────
+
+func (m Model) handleBroadcastMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "esc" {
+		m.popMode()
+		return m, nil
+	}
+	targets := m.broadcastTargets()
+	if len(targets) == 0 {
+		m.mode = ModeNormal
+		return m, nil
+	}
+	b := keyToBytes(msg)
+	if b == nil {
+		return m, nil
+	}
+	for _, inst := range targets {
+		inst.proc.Write(b)
+		inst.ContextBytes += int64(len(b))
+	}
+	return m, nil
+}
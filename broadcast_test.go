@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestBroadcastTypesToRunningOrMarkedAgents(t *testing.T) {
+	h := newHarness(t, PartyFile{Name: "chorus", Slots: []PartySlotConfig{
+		{Agent: "Planner"}, {Agent: "Builder"}, {Agent: "Scout"},
+	}})
+	screens := func(m Model, text string) []bool {
+		var has []bool
+		for _, inst := range m.party().Slots[:3] {
+			has = append(has, inst.emulator != nil && strings.Contains(emulatorText(inst.emulator), text))
+		}
+		return has
+	}
+	h.WaitUntil("all agents ready", func(m Model) bool {
+		has := screens(m, fakeAgentReady)
+		return has[0] && has[1] && has[2]
+	})
+	h.Keys("I")
+	if h.Model().mode != ModeBroadcast {
+		t.Fatal("I did not start broadcasting")
+	}
+	h.Type("stop and commit\r")
+	h.Keys("esc")
+	h.WaitUntil("all agents got the broadcast", func(m Model) bool {
+		has := screens(m, "echo: stop and commit")
+		return has[0] && has[1] && has[2]
+	})
+
+	h.Keys("b", "right", "b")
+	if !strings.Contains(h.View(), "⇶") {
+		t.Fatal("marked cards are not badged")
+	}
+	h.Keys("I")
+	h.Type("/compact\r")
+	h.Keys("esc")
+	h.WaitUntil("marked agents got the broadcast", func(m Model) bool {
+		has := screens(m, "echo: /compact")
+		return has[0] && has[1]
+	})
+	h.Settle(200 * time.Millisecond)
+	if screens(h.Model(), "echo: /compact")[2] {
+		t.Error("unmarked Scout received the broadcast")
+	}
+}
+
+// inputRecorder is an AgentProcess that records what is typed to it.
+type inputRecorder struct {
+	AgentProcess
+	typed bytes.Buffer
+}
+
+func (p *inputRecorder) Write(b []byte) (int, error) { return p.typed.Write(b) }
+
+// broadcastParty is a party of running agents backed by inputRecorders,
+// except for an idle third slot.
+func broadcastParty() (Model, []*inputRecorder) {
+	p := &Party{Name: "chorus"}
+	var procs []*inputRecorder
+	for i, name := range []string{"Planner", "Builder", "Scout"} {
+		inst := &AgentInstance{AgentName: name, Status: "idle"}
+		if i < 2 {
+			proc := &inputRecorder{}
+			inst.Status, inst.proc = "running", proc
+			procs = append(procs, proc)
+		}
+		p.Slots[i] = inst
+	}
+	return Model{parties: []*Party{p}}, procs
+}
+
+func TestBroadcastTargets(t *testing.T) {
+	m, _ := broadcastParty()
+	p := m.party()
+	if got := m.broadcastTargets(); len(got) != 2 || got[0] != p.Slots[0] || got[1] != p.Slots[1] {
+		t.Errorf("unmarked party targets %v, want both running agents", got)
+	}
+
+	m.selectedAgent = 1
+	m = m.toggleBroadcastMark()
+	if got := m.broadcastTargets(); len(got) != 1 || got[0] != p.Slots[1] {
+		t.Errorf("targets %v, want only the marked Builder", got)
+	}
+
+	m = m.toggleBroadcastAll()
+	if !p.Slots[0].broadcast || !p.Slots[1].broadcast || p.Slots[2].broadcast {
+		t.Error("B did not mark exactly the running agents")
+	}
+	m = m.toggleBroadcastAll()
+	if p.Slots[0].broadcast || p.Slots[1].broadcast {
+		t.Error("B did not clear marks when every running agent was marked")
+	}
+}
+
+func TestBroadcastModeWritesToEveryTarget(t *testing.T) {
+	m, procs := broadcastParty()
+	m = m.openBroadcast()
+	if m.mode != ModeBroadcast {
+		t.Fatal("broadcast did not open")
+	}
+	for _, msg := range []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune("go")},
+		{Type: tea.KeyEnter},
+		{Type: tea.KeyRunes, Runes: []rune("pasted\nblock"), Paste: true},
+	} {
+		model, _ := m.handleBroadcastMode(msg)
+		m = model.(Model)
+	}
+	for i, proc := range procs {
+		if got := proc.typed.String(); !strings.HasPrefix(got, "go\r") || !strings.Contains(got, "pasted") {
+			t.Errorf("agent %d got %q", i, got)
+		}
+	}
+
+	model, _ := m.handleBroadcastMode(tea.KeyMsg{Type: tea.KeyEscape})
+	if model.(Model).mode != ModeNormal {
+		t.Error("esc did not leave broadcast mode")
+	}
+
+	idle := Model{parties: []*Party{{Name: "empty"}}}
+	if idle.openBroadcast().mode == ModeBroadcast {
+		t.Error("broadcast opened with no running agents")
+	}
+}
+
+func TestAnotherAgentExitingKeepsInsertMode(t *testing.T) {
+	h := newHarness(t, PartyFile{Name: "pair", Slots: []PartySlotConfig{
+		{Agent: "Planner"}, {Agent: "Builder"},
+	}})
+	h.WaitUntil("both agents ready", func(m Model) bool {
+		p := m.party()
+		return p.Slots[0].emulator != nil && p.Slots[1].emulator != nil &&
+			strings.Contains(emulatorText(p.Slots[1].emulator), fakeAgentReady)
+	})
+	h.Keys("right", "i")
+	h.Send(AgentExitedMsg{ID: h.Model().party().Slots[0].ID})
+	h.Settle(100 * time.Millisecond)
+	if m := h.Model(); m.mode != ModeInsert || m.agent() != m.party().Slots[1] {
+		t.Fatalf("Planner exiting moved the user out of Builder's insert mode (mode %v)", m.mode)
+	}
+
+	h.Type("exit\r")
+	h.WaitFor("How did it go?")
+	if m := h.Model(); m.checkoutAgent != m.party().Slots[1] {
+		t.Fatal("Builder's own exit did not check it out first")
+	}
+}
+
+func TestAgentExitingInNormalModeOpensCheckout(t *testing.T) {
+	h := newHarness(t, PartyFile{Name: "solo", Slots: []PartySlotConfig{{Agent: "Builder"}}})
+	h.WaitFor(fakeAgentReady)
+	if h.Model().mode != ModeNormal {
+		t.Fatal("not in normal mode")
+	}
+	h.Model().agent().proc.Write([]byte("exit\r"))
+	h.WaitFor("How did it go?")
+	if m := h.Model(); m.mode != ModeCheckout || m.checkoutAgent != m.agent() {
+		t.Errorf("mode %v after the agent exited, want its checkout", m.mode)
+	}
+}
+
+func TestAgentExitingDuringBroadcastQueuesCheckout(t *testing.T) {
+	h := newHarness(t, PartyFile{Name: "pair", Slots: []PartySlotConfig{
+		{Agent: "Planner"}, {Agent: "Builder"},
+	}})
+	h.WaitUntil("both agents running", func(m Model) bool {
+		p := m.party()
+		return p.Slots[0].proc != nil && p.Slots[1].proc != nil
+	})
+	h.Keys("I")
+	planner := h.Model().party().Slots[0]
+	h.Send(AgentExitedMsg{ID: planner.ID})
+	if m := h.Model(); m.mode != ModeBroadcast {
+		t.Fatalf("Planner exiting closed broadcast (mode %v)", m.mode)
+	}
+	h.Keys("esc")
+	if m := h.Model(); m.mode != ModeCheckout || m.checkoutAgent != planner {
+		t.Errorf("mode %v after leaving broadcast, want Planner's queued checkout", m.mode)
+	}
+}
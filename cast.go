@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ── Asciicast Recording ───────────────────────────────────────────
+//
+// Every agent PTY is recorded as an asciicast v2 file (session.cast) in its
+// session directory, next to effective_prompt.md. The recorder hangs off
+// localProcess, so sessions are captured in full whether the PTY is owned
+// by the TUI or by the forge daemon, including while the TUI is detached.
+//
+// Format: https://docs.asciinema.org/manual/asciicast/v2/
+
+const castFileName = "session.cast"
+
+type castHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// castRecorder appends timed events to an asciicast file. Safe for
+// concurrent use; writes go straight to the file so a crash loses nothing
+// already read from the PTY.
+type castRecorder struct {
+	mu      sync.Mutex
+	f       *os.File
+	start   time.Time
+	partial []byte // trailing bytes of an incomplete UTF-8 sequence
+}
+
+func newCastRecorder(path string, cols, rows int, title string) (*castRecorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	hdr, _ := json.Marshal(castHeader{
+		Version:   2,
+		Width:     cols,
+		Height:    rows,
+		Timestamp: start.Unix(),
+		Title:     title,
+		Env:       map[string]string{"TERM": "xterm-256color", "SHELL": os.Getenv("SHELL")},
+	})
+	if _, err := f.Write(append(hdr, '\n')); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &castRecorder{f: f, start: start}, nil
+}
+
+// event writes one [time, code, data] line. Errors are dropped: a broken
+// recording must never take the agent down with it.
+func (r *castRecorder) event(code, data string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return
+	}
+	line, _ := json.Marshal([]any{time.Since(r.start).Seconds(), code, data})
+	r.f.Write(append(line, '\n'))
+}
+
+// Output records raw PTY output. A multi-byte rune split across reads is
+// held back until the rest arrives; other invalid UTF-8 is replaced by
+// json.Marshal, as asciinema itself does.
+func (r *castRecorder) Output(b []byte) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	data := append(r.partial, b...)
+	cut := len(data)
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+	r.partial = append([]byte(nil), data[cut:]...)
+	r.mu.Unlock()
+	if cut > 0 {
+		r.event("o", string(data[:cut]))
+	}
+}
+
+func (r *castRecorder) Resize(cols, rows int) { r.event("r", fmt.Sprintf("%dx%d", cols, rows)) }
+
+// Write lets a recorder tee a plain (non-PTY) output stream.
+func (r *castRecorder) Write(b []byte) (int, error) {
+	r.Output(b)
+	return len(b), nil
+}
+
+// Marker records a named point in the session (asciicast "m" event).
+func (r *castRecorder) Marker(label string) { r.event("m", label) }
+
+func (r *castRecorder) Close() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f != nil {
+		r.f.Close()
+		r.f = nil
+	}
+}
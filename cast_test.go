@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readCast parses a recording, failing the test on any line that isn't
+// valid asciicast JSON. Unlike readCastEvents, nothing is skipped.
+func readCast(t *testing.T, path string) (castHeader, []castEvent) {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	var hdr castHeader
+	if !sc.Scan() || json.Unmarshal(sc.Bytes(), &hdr) != nil {
+		t.Fatalf("bad header %q", sc.Text())
+	}
+	var events []castEvent
+	for sc.Scan() {
+		var ev []any
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil || len(ev) != 3 {
+			t.Fatalf("bad event %q: %v", sc.Text(), err)
+		}
+		at, ok1 := ev[0].(float64)
+		code, ok2 := ev[1].(string)
+		data, ok3 := ev[2].(string)
+		if !ok1 || !ok2 || !ok3 {
+			t.Fatalf("bad event types %q", sc.Text())
+		}
+		events = append(events, castEvent{Time: at, Code: code, Data: data})
+	}
+	return hdr, events
+}
+
+func TestCastRecorderHeader(t *testing.T) {
+	t.Setenv("SHELL", "/bin/zsh")
+	path := filepath.Join(t.TempDir(), castFileName)
+	before := time.Now().Unix()
+	r, err := newCastRecorder(path, 100, 30, "api-0-Builder")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	hdr, events := readCast(t, path)
+	if hdr.Version != 2 || hdr.Width != 100 || hdr.Height != 30 || hdr.Title != "api-0-Builder" {
+		t.Errorf("header %+v", hdr)
+	}
+	if hdr.Timestamp < before || hdr.Timestamp > time.Now().Unix() {
+		t.Errorf("timestamp %d not the recording start", hdr.Timestamp)
+	}
+	if hdr.Env["TERM"] != "xterm-256color" || hdr.Env["SHELL"] != "/bin/zsh" {
+		t.Errorf("env %v", hdr.Env)
+	}
+	if len(events) != 0 {
+		t.Errorf("%d events in an empty recording", len(events))
+	}
+
+	if _, err := newCastRecorder(filepath.Join(t.TempDir(), "missing", castFileName), 80, 24, ""); err == nil {
+		t.Error("recording into a missing directory succeeded")
+	}
+}
+
+func TestCastRecorderEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), castFileName)
+	r, err := newCastRecorder(path, 80, 24, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Output([]byte("hello\r\n"))
+	time.Sleep(50 * time.Millisecond)
+	r.Resize(120, 40)
+	r.Write([]byte("tee'd"))
+	r.Marker("exit: 0")
+	r.Close()
+	r.Output([]byte("after close"))
+	r.Marker("after close")
+
+	var nilRecorder *castRecorder // agents run without a recording
+	nilRecorder.Output([]byte("x"))
+	nilRecorder.Marker("x")
+	nilRecorder.Close()
+
+	_, events := readCast(t, path)
+	want := []castEvent{{Code: "o", Data: "hello\r\n"}, {Code: "r", Data: "120x40"}, {Code: "o", Data: "tee'd"}, {Code: "m", Data: "exit: 0"}}
+	if len(events) != len(want) {
+		t.Fatalf("events %v, want %v", events, want)
+	}
+	for i, ev := range events {
+		if ev.Code != want[i].Code || ev.Data != want[i].Data {
+			t.Errorf("event %d: %v, want %q %q", i, ev, want[i].Code, want[i].Data)
+		}
+		if i > 0 && ev.Time < events[i-1].Time {
+			t.Errorf("event %d at %v, before the one ahead of it", i, ev.Time)
+		}
+	}
+	if events[0].Time < 0 || events[1].Time-events[0].Time < 0.05 {
+		t.Errorf("times %v and %v don't reflect the 50ms gap", events[0].Time, events[1].Time)
+	}
+}
+
+func TestCastRecorderSplitRunes(t *testing.T) {
+	text := "héllo 世界 🚀!"
+	tests := []struct {
+		name   string
+		chunks []string
+		events []string
+	}{
+		{"whole", []string{text}, []string{text}},
+		{"2-byte rune split", []string{"h\xc3", "\xa9llo"}, []string{"h", "éllo"}},
+		{"3-byte rune split twice", []string{"\xe4", "\xb8", "\x96界"}, []string{"世界"}},
+		{"4-byte rune split", []string{"go \xf0\x9f", "\x9a\x80"}, []string{"go ", "🚀"}},
+		{"invalid bytes pass through", []string{"a\xff", "b"}, []string{"a�", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), castFileName)
+			r, err := newCastRecorder(path, 80, 24, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, c := range tt.chunks {
+				r.Output([]byte(c))
+			}
+			r.Close()
+			_, events := readCast(t, path)
+			var got []string
+			for _, ev := range events {
+				got = append(got, ev.Data)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.events, "|") {
+				t.Errorf("events %q, want %q", got, tt.events)
+			}
+		})
+	}
+
+	// Split at every byte, the recording still reads back as the text
+	for i := 1; i < len(text); i++ {
+		path := filepath.Join(t.TempDir(), castFileName)
+		r, _ := newCastRecorder(path, 80, 24, "")
+		r.Output([]byte(text[:i]))
+		r.Output([]byte(text[i:]))
+		r.Close()
+		_, events := readCast(t, path)
+		var got strings.Builder
+		for _, ev := range events {
+			got.WriteString(ev.Data)
+		}
+		if got.String() != text {
+			t.Errorf("split at %d: recorded %q", i, got.String())
+		}
+	}
+}
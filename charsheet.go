@@ -34,6 +34,7 @@ func (m Model) renderCharSheet(inst *AgentInstance, tw, th int) string {
 
 	equippedSection := m.renderEquippedSection(inst)
 	availableSection := m.renderAvailableSection(inst)
+	passivesSection := m.renderPassivesSection(inst)
 	statsSection := m.renderStatsSection(inst, className, level, xp, nextXP)
 
 	// ── Layout ─────────────────────────────────────────────────
@@ -42,7 +43,7 @@ func (m Model) renderCharSheet(inst *AgentInstance, tw, th int) string {
 	rightColWidth := tw - leftColWidth - 4
 
 	leftCol := lipgloss.NewStyle().Width(leftColWidth).Render(
-		lipgloss.JoinVertical(lipgloss.Left, equippedSection, "", availableSection),
+		lipgloss.JoinVertical(lipgloss.Left, equippedSection, "", availableSection, "", passivesSection),
 	)
 	// Agent bio section (from <name>.md)
 	var bioSection string
@@ -50,8 +51,14 @@ func (m Model) renderCharSheet(inst *AgentInstance, tw, th int) string {
 		bioSection = m.renderBioSection(inst, rightColWidth)
 	}
 
+	// Messages and review request from the forge tools
+	var commsSection string
+	if len(inst.Inbox.Messages) > 0 || inst.ReviewRequest != "" {
+		commsSection = renderCommsSection(inst, rightColWidth) + "\n"
+	}
+
 	rightCol := lipgloss.NewStyle().Width(rightColWidth).Render(
-		lipgloss.JoinVertical(lipgloss.Left, statsSection, "", bioSection),
+		lipgloss.JoinVertical(lipgloss.Left, statsSection, "", commsSection, bioSection),
 	)
 
 	body := lipgloss.JoinHorizontal(lipgloss.Top, leftCol, "  ", rightCol)
@@ -66,16 +73,34 @@ func (m Model) renderCharSheet(inst *AgentInstance, tw, th int) string {
 
 	titleBar := fmt.Sprintf("─── %s ──── %s ─── %s ───", title, classLabel, levelLabel)
 
-	// Token bar
+	// Token budget bar
+	budget := SkillBudget(m.config, inst.ClassName, level)
+	used := LoadoutTokens(m.config, inst.ClassName, inst.Equipped)
 	tokenColor := colorGreen
-	if composed.TotalTokens > 800 {
+	if used > budget*8/10 {
 		tokenColor = colorYellow
 	}
-	if composed.TotalTokens > 950 {
+	if used > budget {
 		tokenColor = colorRed
 	}
+	barWidth := 20
+	filled := 0
+	if budget > 0 {
+		filled = used * barWidth / budget
+	}
+	if filled > barWidth {
+		filled = barWidth
+	}
 	tokenStr := lipgloss.NewStyle().Foreground(tokenColor).
-		Render(fmt.Sprintf("~%d tokens", composed.TotalTokens))
+		Render(fmt.Sprintf("Skills %s/%s tokens %s%s",
+			formatTokens(used), formatTokens(budget),
+			strings.Repeat("█", filled), strings.Repeat("░", barWidth-filled))) +
+		lipgloss.NewStyle().Foreground(colorTextDim).
+			Render(fmt.Sprintf("  prompt ~%s", formatTokens(composed.TotalTokens)))
+	if used > budget {
+		tokenStr += "\n" + lipgloss.NewStyle().Foreground(colorRed).Bold(true).
+			Render(fmt.Sprintf("⚠ Over budget by %s tokens — unequip skills", formatTokens(used-budget)))
+	}
 
 	// Pending banner
 	var pendingBanner string
@@ -95,7 +120,7 @@ func (m Model) renderCharSheet(inst *AgentInstance, tw, th int) string {
 
 	// Footer hints
 	hints := lipgloss.NewStyle().Foreground(colorTextDim).
-		Render("  ↑↓:navigate  tab:section  space:equip  []:scroll  s:start  esc:close")
+		Render("  ↑↓:navigate  tab:section  space:toggle  []:scroll  s:start  esc:close")
 
 	// Compose full sheet
 	content := lipgloss.JoinVertical(lipgloss.Left,
@@ -144,7 +169,7 @@ func (m Model) renderEquippedSection(inst *AgentInstance) string {
 			if isActive && cursor == m.csCursor {
 				prefix = "> "
 			}
-			line := fmt.Sprintf("%s★ %s", prefix, name)
+			line := fmt.Sprintf("%s★ %s%s", prefix, name, skillCost(skill))
 			style := lipgloss.NewStyle().Foreground(colorYellow)
 			lines = append(lines, style.Render(line))
 			cursor++
@@ -162,7 +187,7 @@ func (m Model) renderEquippedSection(inst *AgentInstance) string {
 		if isActive && cursor == m.csCursor {
 			prefix = "> "
 		}
-		line := fmt.Sprintf("%s● %s", prefix, name)
+		line := fmt.Sprintf("%s● %s%s", prefix, name, skillCost(skill))
 		style := lipgloss.NewStyle().Foreground(colorText)
 		lines = append(lines, style.Render(line))
 		cursor++
@@ -197,6 +222,7 @@ func (m Model) renderAvailableSection(inst *AgentInstance) string {
 
 	avail := m.availableSkills(inst)
 	sort.Strings(avail)
+	level := m.agentLevel(inst.AgentName)
 
 	var lines []string
 	for i, sid := range avail {
@@ -209,8 +235,12 @@ func (m Model) renderAvailableSection(inst *AgentInstance) string {
 		if isActive && i == m.csCursor {
 			prefix = "> "
 		}
-		line := fmt.Sprintf("%s%s", prefix, name)
+		line := fmt.Sprintf("%s%s%s", prefix, name, skillCost(skill))
 		style := lipgloss.NewStyle().Foreground(colorTextDim)
+		// Skills that would blow the budget can't be equipped
+		if len(inst.Equipped) < MaxEquipSlots && !CanEquip(m.config, inst.ClassName, level, inst.Equipped, sid) {
+			style = lipgloss.NewStyle().Foreground(colorRed).Faint(true)
+		}
 		lines = append(lines, style.Render(line))
 	}
 
@@ -225,6 +255,55 @@ func (m Model) renderAvailableSection(inst *AgentInstance) string {
 	)
 }
 
+// skillCost formats a skill's token cost for the loadout lists.
+func skillCost(skill *SkillEntry) string {
+	if skill == nil {
+		return ""
+	}
+	return fmt.Sprintf("  (%s)", formatTokens(skill.Tokens))
+}
+
+// ── Passives Section ───────────────────────────────────────────────
+
+func (m Model) renderPassivesSection(inst *AgentInstance) string {
+	isActive := m.csSection == 2
+
+	header := csHeaderStyle.Render("┌─ PASSIVES ──────────────┐")
+
+	active := make(map[string]bool, len(inst.Passives))
+	for _, p := range inst.Passives {
+		active[p] = true
+	}
+
+	var lines []string
+	for i, pid := range AllPassiveIDs(m.config) {
+		prefix := "  "
+		if isActive && i == m.csCursor {
+			prefix = "> "
+		}
+		mark, style := "◇", lipgloss.NewStyle().Foreground(colorTextDim)
+		if active[pid] {
+			mark, style = "◆", lipgloss.NewStyle().Foreground(colorGreen)
+		}
+		lines = append(lines, style.Render(fmt.Sprintf("%s%s %s", prefix, mark, passiveName(m.config, pid))))
+	}
+
+	// Describe the passive under the cursor
+	if isActive {
+		if ids := AllPassiveIDs(m.config); m.csCursor < len(ids) {
+			if p := m.config.Passive(ids[m.csCursor]); p != nil && p.Description != "" {
+				lines = append(lines, lipgloss.NewStyle().Foreground(colorTextDim).Italic(true).Render("    "+p.Description))
+			}
+		}
+	}
+
+	lines = append(lines, csHeaderStyle.Render("└─────────────────────────┘"))
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		append([]string{header}, lines...)...,
+	)
+}
+
 // ── Stats Section ──────────────────────────────────────────────────
 
 func (m Model) renderStatsSection(inst *AgentInstance, className string, level, xp, nextXP int) string {
@@ -267,6 +346,45 @@ func (m Model) renderStatsSection(inst *AgentInstance, className string, level,
 	)
 }
 
+// ── Comms Section ──────────────────────────────────────────────────
+
+// commsShown is how many of the latest messages the sheet lists.
+const commsShown = 4
+
+func renderCommsSection(inst *AgentInstance, maxWidth int) string {
+	header := csHeaderStyle.Render("┌─ COMMS ─────────────────┐")
+
+	var lines []string
+	if inst.ReviewRequest != "" {
+		lines = append(lines, lipgloss.NewStyle().Foreground(colorYellow).
+			Render(truncLine("  ⚑ Review: "+inst.ReviewRequest, maxWidth)))
+	}
+	msgs := inst.Inbox.Messages
+	unreadFrom := len(msgs) - inst.Inbox.Unread
+	start := max(len(msgs)-commsShown, 0)
+	for i := start; i < len(msgs); i++ {
+		msg := msgs[i]
+		mark := " "
+		if i >= unreadFrom {
+			mark = "✉"
+		}
+		text := strings.Join(strings.Fields(msg.Text), " ")
+		lines = append(lines, truncLine(fmt.Sprintf("  %s %s %s: ", mark, msg.Sent.Format("15:04"), msg.Agent), maxWidth)+
+			lipgloss.NewStyle().Foreground(colorTextDim).
+				Render(truncLine(fmt.Sprintf("[%s] %s", msg.Kind, text), max(maxWidth-lipgloss.Width(msg.Agent)-12, 8))))
+	}
+	if start > 0 {
+		lines = append(lines, lipgloss.NewStyle().Foreground(colorTextDim).
+			Render(fmt.Sprintf("  … %d earlier", start)))
+	}
+
+	lines = append(lines, csHeaderStyle.Render("└─────────────────────────┘"))
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		append([]string{header}, lines...)...,
+	)
+}
+
 // ── Profile Section ────────────────────────────────────────────────
 
 func (m Model) renderBioSection(inst *AgentInstance, maxWidth int) string {
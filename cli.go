@@ -0,0 +1,574 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ── Command Line ──────────────────────────────────────────────────
+//
+// Without arguments agent-tui opens the TUI. Subcommands manage forge
+// state from scripts through the same loaders and savers the TUI uses, so
+// a party created here is exactly what the wizard would have written.
+
+// cliCommand is one top-level subcommand, or one action of a group.
+type cliCommand struct {
+	name    string
+	usage   string // arguments, after the command path
+	summary string
+	run     func(args []string) error
+	sub     []cliCommand // actions of a group like "party"; run is unused
+}
+
+var cliCommands = []cliCommand{
+	{name: "party", summary: "manage parties", sub: []cliCommand{
+		{name: "list", summary: "list parties", run: runPartyList},
+		{name: "show", usage: "<name>", summary: "print a party file", run: runPartyShow},
+		{name: "create", usage: "<name> [--project <dir>] [--agents a,b,...]", summary: "create a party; agents not in a slot go to the bench", run: runPartyCreate},
+		{name: "delete", usage: "<name>", summary: "delete a party, its runtime state and its worktrees", run: runPartyDelete},
+		{name: "clone", usage: "<name> <new-name> [--project <dir>]", summary: "copy a party under a new name", run: runPartyClone},
+	}},
+	{name: "agent", summary: "inspect agents", sub: []cliCommand{
+		{name: "list", summary: "list agents", run: runAgentList},
+		{name: "show", usage: "<name>", summary: "show an agent's class, level and loadout", run: runAgentShow},
+	}},
+	{name: "skill", summary: "inspect skills", sub: []cliCommand{
+		{name: "list", summary: "list skills and their token cost", run: runSkillList},
+		{name: "show", usage: "<id>", summary: "print a skill", run: runSkillShow},
+	}},
+	{name: "session", summary: "inspect recorded sessions", sub: []cliCommand{
+		{name: "list", usage: "[--party <name>] [--agent <name>]", summary: "list sessions, newest first", run: runSessionList},
+		{name: "show", usage: "<id>", summary: "show a session and its result", run: runSessionShow},
+	}},
+	{name: "raid", usage: raidUsage, summary: "run a party headlessly", run: runRaid},
+	{name: "serve", summary: "run the forge daemon", run: runServe},
+	{name: "mcp", usage: mcpUsage, summary: "serve the forge MCP tools to an agent on stdio", run: runMCP},
+	{name: "hook", usage: hookUsage, summary: "report an agent's tool use to forge (run by agent hooks)", run: runHook},
+}
+
+// runCLI dispatches args (without the program name) to a subcommand.
+func runCLI(args []string) error {
+	return dispatch("agent-tui", cliCommands, args)
+}
+
+func dispatch(path string, cmds []cliCommand, args []string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printCommands(os.Stdout, path, cmds)
+		return nil
+	}
+	i := slices.IndexFunc(cmds, func(c cliCommand) bool { return c.name == args[0] })
+	if i < 0 {
+		printCommands(os.Stderr, path, cmds)
+		return fmt.Errorf("unknown command %q", strings.TrimPrefix(path+" "+args[0], "agent-tui "))
+	}
+	c := cmds[i]
+	if c.sub != nil {
+		return dispatch(path+" "+c.name, c.sub, args[1:])
+	}
+	return c.run(args[1:])
+}
+
+func printCommands(w io.Writer, path string, cmds []cliCommand) {
+	fmt.Fprintf(w, "usage: %s <command> [args]\n\ncommands:\n", path)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, c := range cmds {
+		fmt.Fprintf(tw, "  %s\t%s\n", c.name, c.summary)
+	}
+	tw.Flush()
+}
+
+// newFlagSet returns a flag set that reports errors instead of exiting
+// and prints usage as "agent-tui <command> <args>".
+func newFlagSet(command, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: agent-tui %s %s\n", command, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseArgs parses flags anywhere in args, so "party create foo --project x"
+// works, and checks the number of positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string, nargs int) ([]string, error) {
+	var pos []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, errHelp
+			}
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		pos = append(pos, args[0])
+		args = args[1:]
+	}
+	if len(pos) != nargs {
+		fs.Usage()
+		return nil, fmt.Errorf("%s: expected %d argument(s), got %d", fs.Name(), nargs, len(pos))
+	}
+	return pos, nil
+}
+
+// errHelp is returned after -h printed usage; main exits 0 on it.
+var errHelp = errors.New("help requested")
+
+// listFlag collects a comma-separated flag, e.g. --agents Planner,Builder.
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ",") }
+
+func (l *listFlag) Set(v string) error {
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			*l = append(*l, s)
+		}
+	}
+	return nil
+}
+
+// ── party ─────────────────────────────────────────────────────────
+
+func runPartyList(args []string) error {
+	fs := newFlagSet("party list", "")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	if _, _, err := loadForgeConfig(); err != nil {
+		return err
+	}
+	names, err := ListPartyFiles()
+	if err != nil {
+		return fmt.Errorf("listing parties: %w", err)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSLOTS\tBENCH\tPROJECT")
+	for _, name := range names {
+		pf, err := LoadParty(name)
+		if err != nil {
+			fmt.Fprintf(tw, "%s\t-\t-\t(unreadable: %v)\n", name, err)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\n", name, len(pf.Slots), len(pf.Bench), pf.Project)
+	}
+	return tw.Flush()
+}
+
+func runPartyShow(args []string) error {
+	fs := newFlagSet("party show", "<name>")
+	pos, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if _, _, err := loadForgeConfig(); err != nil {
+		return err
+	}
+	pf, err := loadPartyArg(pos[0])
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(pf)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+func runPartyCreate(args []string) error {
+	fs := newFlagSet("party create", "<name> [--project <dir>] [--agents a,b,...]")
+	project := fs.String("project", "", "project directory (default: current directory)")
+	var agents listFlag
+	fs.Var(&agents, "agents", "comma-separated agents for the slots, in order (default: the starter party)")
+	pos, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	cfg, _, err := loadForgeConfig()
+	if err != nil {
+		return err
+	}
+	name := pos[0]
+	if err := checkNewPartyName(name); err != nil {
+		return err
+	}
+	dir, err := projectArg(*project)
+	if err != nil {
+		return err
+	}
+
+	pf := DefaultParty(name, dir)
+	if len(agents) > 0 {
+		if pf, err = partyWithAgents(cfg, name, dir, agents); err != nil {
+			return err
+		}
+	}
+	if err := SaveParty(pf); err != nil {
+		return fmt.Errorf("saving party: %w", err)
+	}
+	fmt.Printf("created party %s (%d slots) for %s\n", name, len(pf.Slots), dir)
+	if len(agents) > MaxPartySlots {
+		fmt.Printf("%s benched: a party has %d slots\n", strings.Join(agents[MaxPartySlots:], ", "), MaxPartySlots)
+	}
+	return nil
+}
+
+// partyWithAgents builds a party whose slots hold agents in order. Agents
+// past MaxPartySlots go to the bench first, then every other agent, as in
+// the wizard.
+func partyWithAgents(cfg *ForgeConfig, name, dir string, agents []string) (*PartyFile, error) {
+	pf := &PartyFile{Name: name, Project: dir}
+	var listed []string
+	for _, a := range agents {
+		def := agentByName(cfg, a)
+		if def == nil {
+			return nil, fmt.Errorf("agent %s not found", a)
+		}
+		if slices.Contains(listed, def.Name) {
+			return nil, fmt.Errorf("agent %s listed twice", def.Name)
+		}
+		listed = append(listed, def.Name)
+		if len(pf.Slots) < MaxPartySlots {
+			pf.Slots = append(pf.Slots, PartySlotConfig{Agent: def.Name})
+		} else {
+			pf.Bench = append(pf.Bench, PartySlotConfig{Agent: def.Name})
+		}
+	}
+	for _, a := range cfg.Agents {
+		if !slices.Contains(listed, a.Name) {
+			pf.Bench = append(pf.Bench, PartySlotConfig{Agent: a.Name})
+		}
+	}
+	return pf, nil
+}
+
+func runPartyDelete(args []string) error {
+	fs := newFlagSet("party delete", "<name>")
+	pos, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if _, _, err := loadForgeConfig(); err != nil {
+		return err
+	}
+	name := pos[0]
+	pf, err := loadPartyArg(name)
+	if err != nil {
+		return err
+	}
+	cleanupPartyWorktrees(name, pf.Project)
+	if err := os.Remove(partyPath(name)); err != nil {
+		return err
+	}
+	if err := os.Remove(partyStatePath(name)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing runtime state: %w", err)
+	}
+	fmt.Printf("deleted party %s\n", name)
+	return nil
+}
+
+func runPartyClone(args []string) error {
+	fs := newFlagSet("party clone", "<name> <new-name> [--project <dir>]")
+	project := fs.String("project", "", "project directory for the copy (default: the original's)")
+	pos, err := parseArgs(fs, args, 2)
+	if err != nil {
+		return err
+	}
+	if _, _, err := loadForgeConfig(); err != nil {
+		return err
+	}
+	pf, err := loadPartyArg(pos[0])
+	if err != nil {
+		return err
+	}
+	if err := checkNewPartyName(pos[1]); err != nil {
+		return err
+	}
+	pf.Name = pos[1]
+	if *project != "" {
+		if pf.Project, err = projectArg(*project); err != nil {
+			return err
+		}
+	}
+	// Conversations belong to the original party's agents
+	for i := range pf.Slots {
+		pf.Slots[i].ConversationID = ""
+	}
+	for i := range pf.Bench {
+		pf.Bench[i].ConversationID = ""
+	}
+	if err := SaveParty(pf); err != nil {
+		return fmt.Errorf("saving party: %w", err)
+	}
+	fmt.Printf("cloned party %s as %s\n", pos[0], pf.Name)
+	return nil
+}
+
+// loadPartyArg loads a party named on the command line, with a friendlier
+// error than the underlying file-not-found.
+func loadPartyArg(name string) (*PartyFile, error) {
+	if err := checkPartyName(name); err != nil {
+		return nil, err
+	}
+	pf, err := LoadParty(name)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("party %q not found", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("party %q: %w", name, err)
+	}
+	return pf, nil
+}
+
+// checkPartyName rejects names that would escape partiesDir().
+func checkPartyName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid party name %q", name)
+	}
+	return nil
+}
+
+func checkNewPartyName(name string) error {
+	if err := checkPartyName(name); err != nil {
+		return err
+	}
+	if _, err := os.Stat(partyPath(name)); err == nil {
+		return fmt.Errorf("party %q already exists", name)
+	}
+	return nil
+}
+
+// projectArg resolves a --project value to an absolute directory.
+func projectArg(dir string) (string, error) {
+	if dir == "" {
+		return os.Getwd()
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	if fi, err := os.Stat(abs); err != nil || !fi.IsDir() {
+		return "", fmt.Errorf("project %s is not a directory", dir)
+	}
+	return abs, nil
+}
+
+// ── agent ─────────────────────────────────────────────────────────
+
+func runAgentList(args []string) error {
+	fs := newFlagSet("agent list", "")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	cfg, roster, err := loadForgeConfig()
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tCLASS\tBACKEND\tLEVEL\tXP")
+	for _, a := range cfg.Agents {
+		r := roster.Agents[a.Name]
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\n", a.Name, a.Class,
+			ResolveBackendName(cfg, a.Backend, a.Class), r.Level, r.XP)
+	}
+	return tw.Flush()
+}
+
+func runAgentShow(args []string) error {
+	fs := newFlagSet("agent show", "<name>")
+	pos, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	cfg, roster, err := loadForgeConfig()
+	if err != nil {
+		return err
+	}
+	a := agentByName(cfg, pos[0])
+	if a == nil {
+		return fmt.Errorf("agent %s not found", pos[0])
+	}
+	r := roster.Agents[a.Name]
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 1, ' ', 0)
+	fmt.Fprintf(tw, "Name:\t%s\n", a.Name)
+	fmt.Fprintf(tw, "Class:\t%s\n", a.Class)
+	fmt.Fprintf(tw, "Backend:\t%s\n", ResolveBackendName(cfg, a.Backend, a.Class))
+	fmt.Fprintf(tw, "Level:\t%d (%d XP)\n", r.Level, r.XP)
+	fmt.Fprintf(tw, "Tint:\t#%02x%02x%02x\n", a.Tint[0], a.Tint[1], a.Tint[2])
+	fmt.Fprintf(tw, "Skills:\t%s\n", strings.Join(a.DefaultEquipped, ", "))
+	fmt.Fprintf(tw, "Tokens:\t%s / %s\n",
+		formatTokens(LoadoutTokens(cfg, a.Class, a.DefaultEquipped)),
+		formatTokens(SkillBudget(cfg, a.Class, r.Level)))
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if a.Bio != "" {
+		fmt.Printf("\n%s\n", strings.TrimSpace(a.Bio))
+	}
+	return nil
+}
+
+// agentByName finds an agent definition, ignoring case.
+func agentByName(cfg *ForgeConfig, name string) *AgentConfig {
+	for i := range cfg.Agents {
+		if strings.EqualFold(cfg.Agents[i].Name, name) {
+			return &cfg.Agents[i]
+		}
+	}
+	return nil
+}
+
+// ── skill ─────────────────────────────────────────────────────────
+
+func runSkillList(args []string) error {
+	fs := newFlagSet("skill list", "")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	cfg, _, err := loadForgeConfig()
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTOKENS\tDESCRIPTION")
+	for _, s := range cfg.Skills {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.ID, formatTokens(s.Tokens), s.Description)
+	}
+	return tw.Flush()
+}
+
+func runSkillShow(args []string) error {
+	fs := newFlagSet("skill show", "<id>")
+	pos, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	cfg, _, err := loadForgeConfig()
+	if err != nil {
+		return err
+	}
+	s := SkillByID(cfg, pos[0])
+	if s == nil {
+		return fmt.Errorf("skill %s not found", pos[0])
+	}
+	fmt.Printf("ID:     %s\nName:   %s\nTokens: %s\n\n", s.ID, s.Name, formatTokens(s.Tokens))
+	fmt.Print(s.Content)
+	if !strings.HasSuffix(s.Content, "\n") {
+		fmt.Println()
+	}
+	return nil
+}
+
+// ── session ───────────────────────────────────────────────────────
+
+func runSessionList(args []string) error {
+	fs := newFlagSet("session list", "[--party <name>] [--agent <name>]")
+	party := fs.String("party", "", "only sessions of this party")
+	agent := fs.String("agent", "", "only sessions of this agent")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	if _, _, err := loadForgeConfig(); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tPARTY\tAGENT\tSTARTED\tDURATION\tEXIT\tRATING")
+	for _, rec := range listSessions() {
+		if *party != "" && rec.Meta.Party != *party {
+			continue
+		}
+		if *agent != "" && !strings.EqualFold(rec.Meta.Agent, *agent) {
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			filepath.Base(rec.Dir),
+			orDash(rec.Meta.Party),
+			rec.Meta.Agent,
+			rec.Meta.Started.Format(time.DateTime),
+			formatDuration(rec.Duration),
+			orDash(rec.Exit),
+			orDash(rec.Meta.Rating))
+	}
+	return tw.Flush()
+}
+
+func runSessionShow(args []string) error {
+	fs := newFlagSet("session show", "<id>")
+	pos, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	if _, _, err := loadForgeConfig(); err != nil {
+		return err
+	}
+	rec, err := findSession(pos[0])
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 1, ' ', 0)
+	fmt.Fprintf(tw, "ID:\t%s\n", filepath.Base(rec.Dir))
+	fmt.Fprintf(tw, "Dir:\t%s\n", rec.Dir)
+	fmt.Fprintf(tw, "Party:\t%s\n", orDash(rec.Meta.Party))
+	fmt.Fprintf(tw, "Agent:\t%s\n", rec.Meta.Agent)
+	fmt.Fprintf(tw, "Class:\t%s\n", orDash(rec.Meta.Class))
+	fmt.Fprintf(tw, "Backend:\t%s\n", orDash(rec.Meta.Backend))
+	fmt.Fprintf(tw, "Started:\t%s\n", rec.Meta.Started.Format(time.DateTime))
+	fmt.Fprintf(tw, "Duration:\t%s\n", formatDuration(rec.Duration))
+	fmt.Fprintf(tw, "Exit:\t%s\n", orDash(rec.Exit))
+	fmt.Fprintf(tw, "Rating:\t%s\n", orDash(rec.Meta.Rating))
+	if entries, err := os.ReadDir(rec.Dir); err == nil {
+		var files []string
+		for _, e := range entries {
+			files = append(files, e.Name())
+		}
+		fmt.Fprintf(tw, "Files:\t%s\n", strings.Join(files, ", "))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if data, err := os.ReadFile(filepath.Join(rec.Dir, "result.md")); err == nil {
+		fmt.Printf("\n%s\n", strings.TrimSpace(string(data)))
+	}
+	return nil
+}
+
+// findSession resolves a session directory name, or a unique prefix of one.
+func findSession(id string) (SessionRecord, error) {
+	var matches []SessionRecord
+	for _, rec := range listSessions() {
+		base := filepath.Base(rec.Dir)
+		if base == id {
+			return rec, nil
+		}
+		if strings.HasPrefix(base, id) {
+			matches = append(matches, rec)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return SessionRecord{}, fmt.Errorf("session %q not found", id)
+	case 1:
+		return matches[0], nil
+	}
+	return SessionRecord{}, fmt.Errorf("session %q is ambiguous: %d sessions match", id, len(matches))
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		nargs   int
+		pos     []string
+		project string
+		agents  string
+		err     string
+	}{
+		{"flags after positionals", []string{"api", "--project", "/src", "--agents", "Planner, Builder"}, 1,
+			[]string{"api"}, "/src", "Planner,Builder", ""},
+		{"flags between positionals", []string{"a", "--project=/x", "b"}, 2, []string{"a", "b"}, "/x", "", ""},
+		{"repeated list flag", []string{"--agents", "Scout", "api", "--agents", ",Guard,"}, 1,
+			[]string{"api"}, "", "Scout,Guard", ""},
+		{"too few", []string{"--project", "/src"}, 1, nil, "", "", "expected 1 argument(s), got 0"},
+		{"too many", []string{"a", "b"}, 1, nil, "", "", "expected 1 argument(s), got 2"},
+		{"unknown flag", []string{"a", "--nope"}, 1, nil, "", "", "flag provided but not defined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newFlagSet("party create", "<name>")
+			fs.SetOutput(new(strings.Builder))
+			project := fs.String("project", "", "")
+			var agents listFlag
+			fs.Var(&agents, "agents", "")
+			pos, err := parseArgs(fs, tt.args, tt.nargs)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(pos, tt.pos) || *project != tt.project || agents.String() != tt.agents {
+				t.Errorf("positionals %q, project %q, agents %q", pos, *project, agents.String())
+			}
+		})
+	}
+
+	fs := newFlagSet("party list", "")
+	fs.SetOutput(new(strings.Builder))
+	if _, err := parseArgs(fs, []string{"-h"}, 0); !errors.Is(err, errHelp) {
+		t.Errorf("-h returned %v, want errHelp", err)
+	}
+}
+
+// cliHome gives the CLI a throwaway HOME and returns a project directory.
+func cliHome(t *testing.T) string {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	project := t.TempDir()
+	t.Chdir(project)
+	return project
+}
+
+func partyAgents(slots []PartySlotConfig) string {
+	var names []string
+	for _, s := range slots {
+		names = append(names, s.Agent)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestPartyCreate(t *testing.T) {
+	project := cliHome(t)
+	if err := runCLI([]string{"party", "create", "starter"}); err != nil {
+		t.Fatal(err)
+	}
+	pf, err := LoadParty("starter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pf.Project != project || partyAgents(pf.Slots) != partyAgents(DefaultParty("", "").Slots) {
+		t.Errorf("default party %+v", pf)
+	}
+
+	if err := runCLI([]string{"party", "create", "pair", "--agents", "builder,Reviewer"}); err != nil {
+		t.Fatal(err)
+	}
+	pf, _ = LoadParty("pair")
+	if partyAgents(pf.Slots) != "Builder,Reviewer" || partyAgents(pf.Bench) != "Fixer,Guard,Planner,Scout,Scribe,Tester" {
+		t.Errorf("slots %s, bench %s", partyAgents(pf.Slots), partyAgents(pf.Bench))
+	}
+
+	for _, tt := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"party", "create", "pair"}, `party "pair" already exists`},
+		{[]string{"party", "create", "../escape"}, "invalid party name"},
+		{[]string{"party", "create", "x", "--agents", "Ghost"}, "agent Ghost not found"},
+		{[]string{"party", "create", "x", "--agents", "Scout,scout"}, "agent Scout listed twice"},
+		{[]string{"party", "create", "x", "--project", "/does/not/exist"}, "is not a directory"},
+	} {
+		if err := runCLI(tt.args); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%v: error %v, want %q", tt.args, err, tt.err)
+		}
+	}
+	if _, err := os.Stat(partyPath("x")); !os.IsNotExist(err) {
+		t.Error("a failed create saved the party")
+	}
+}
+
+func TestPartyCreateBenchesAgentsPastTheSlots(t *testing.T) {
+	cliHome(t)
+	if _, _, err := loadForgeConfig(); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Ninth", "Tenth"} {
+		if err := SaveAgentToDir(AgentConfig{Name: name, Class: "developer"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	all := "Tenth,Planner,Builder,Fixer,Scout,Scribe,Guard,Reviewer,Ninth"
+	if err := runCLI([]string{"party", "create", "crowd", "--agents", all}); err != nil {
+		t.Fatal(err)
+	}
+	pf, _ := LoadParty("crowd")
+	if len(pf.Slots) != MaxPartySlots || partyAgents(pf.Slots) != "Tenth,Planner,Builder,Fixer,Scout,Scribe,Guard,Reviewer" {
+		t.Errorf("slots %s", partyAgents(pf.Slots))
+	}
+	if partyAgents(pf.Bench) != "Ninth,Tester" {
+		t.Errorf("bench %s, want the ninth listed agent first", partyAgents(pf.Bench))
+	}
+}
+
+func TestPartyClone(t *testing.T) {
+	cliHome(t)
+	other := t.TempDir()
+	if err := runCLI([]string{"party", "create", "api", "--agents", "Builder"}); err != nil {
+		t.Fatal(err)
+	}
+	pf, _ := LoadParty("api")
+	pf.Slots[0].ConversationID = "conv-1"
+	pf.Bench[0].ConversationID = "conv-2"
+	SaveParty(pf)
+
+	if err := runCLI([]string{"party", "clone", "api", "hotfix", "--project", other}); err != nil {
+		t.Fatal(err)
+	}
+	clone, err := LoadParty("hotfix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clone.Name != "hotfix" || clone.Project != other || partyAgents(clone.Slots) != "Builder" {
+		t.Errorf("clone %+v", clone)
+	}
+	if clone.Slots[0].ConversationID != "" || clone.Bench[0].ConversationID != "" {
+		t.Error("clone kept the original's conversations")
+	}
+	if orig, _ := LoadParty("api"); orig.Slots[0].ConversationID != "conv-1" {
+		t.Error("clone changed the original")
+	}
+
+	if err := runCLI([]string{"party", "clone", "api", "hotfix"}); err == nil {
+		t.Error("clone over an existing party succeeded")
+	}
+	if err := runCLI([]string{"party", "clone", "ghost", "x"}); err == nil || !strings.Contains(err.Error(), `party "ghost" not found`) {
+		t.Errorf("clone of a missing party: %v", err)
+	}
+}
+
+func TestPartyDeleteRemovesWorktrees(t *testing.T) {
+	project := cliHome(t)
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", project}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	git("-c", "user.name=t", "-c", "user.email=t@t", "commit", "-q", "--allow-empty", "-m", "init")
+	if err := runCLI([]string{"party", "create", "api", "--agents", "Builder"}); err != nil {
+		t.Fatal(err)
+	}
+	wt, branch, err := setupWorktree("api", "Builder", project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(partyStatePath("api"), []byte("slots: []\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runCLI([]string{"party", "delete", "api"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{partyPath("api"), partyStatePath("api"), wt, filepath.Join(worktreesDir(), "api")} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s still exists", path)
+		}
+	}
+	if out, _ := exec.Command("git", "-C", project, "branch", "--list", branch).Output(); len(out) != 0 {
+		t.Errorf("branch %s not deleted", branch)
+	}
+
+	if err := runCLI([]string{"party", "delete", "api"}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("deleting a missing party: %v", err)
+	}
+}
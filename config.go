@@ -14,22 +14,31 @@ import (
 
 // ForgeConfig is the top-level ~/.agent-forge/config.yaml structure.
 type ForgeConfig struct {
-	Classes      map[string]*ClassConfig `yaml:"classes"`
-	ToolProfiles map[string][]string     `yaml:"tool_profiles"`
-	Agents       []AgentConfig           `yaml:"-"` // loaded from ~/.claude/agents/
-	Skills       []*SkillEntry           `yaml:"-"` // loaded from ~/.claude/skills/
+	Classes        map[string]*ClassConfig   `yaml:"classes"`
+	ToolProfiles   map[string][]string       `yaml:"tool_profiles"`
+	Backends       map[string]*BackendConfig `yaml:"backends,omitempty"`               // merged over builtinBackends
+	DefaultBackend string                    `yaml:"default_backend,omitempty"`        // empty = claude
+	APIListen      string                    `yaml:"api_listen,omitempty"`             // unix:<path> or loopback host:port
+	Passives       map[string]*PassiveConfig `yaml:"passives,omitempty"`               // merged over builtinPassives
+	TokenBudget    int                       `yaml:"token_budget,omitempty"`           // skill token budget at level 1; 0 = TokenBudgetTotal
+	BudgetPerLevel int                       `yaml:"token_budget_per_level,omitempty"` // 0 = TokenBudgetPerLevel
+	Agents         []AgentConfig             `yaml:"-"`                                // loaded from ~/.claude/agents/
+	Skills         []*SkillEntry             `yaml:"-"`                                // loaded from ~/.claude/skills/
 }
 
 type ClassConfig struct {
 	Description  string   `yaml:"description"`
 	InnateSkills []string `yaml:"innate_skills"`
 	ToolProfile  string   `yaml:"tool_profile"`
+	Backend      string   `yaml:"backend,omitempty"`
+	TokenBudget  int      `yaml:"token_budget,omitempty"` // overrides the forge-wide skill budget
 }
 
 type AgentConfig struct {
 	Name            string      `yaml:"name"`
 	Class           string      `yaml:"class"`
 	Tint            [3]uint8    `yaml:"tint"`
+	Backend         string      `yaml:"backend,omitempty"`
 	Bio             string      `yaml:"-"` // loaded from <name>.md
 	Directives      string      `yaml:"-"` // operational sections for system prompt
 	DefaultEquipped []string    `yaml:"-"` // skill IDs from ## Skills section
@@ -43,6 +52,7 @@ type SkillEntry struct {
 	Name        string // from frontmatter
 	Description string // from frontmatter
 	Content     string // full SKILL.md content (for --append-system-prompt)
+	Tokens      int    // countTokens(Content), counted once at load
 }
 
 // ── Party File (per-party state) ───────────────────────────────────
@@ -55,9 +65,11 @@ type PartyFile struct {
 }
 
 type PartySlotConfig struct {
-	Agent    string   `yaml:"agent"`
-	Equipped []string `yaml:"equipped"` // skill IDs
-	Passives []string `yaml:"passives"`
+	Agent          string   `yaml:"agent"`
+	Equipped       []string `yaml:"equipped"` // skill IDs
+	Passives       []string `yaml:"passives"`
+	After          []string `yaml:"after,omitempty"`           // raid: agent names that must finish first
+	ConversationID string   `yaml:"conversation_id,omitempty"` // last backend conversation, for resume
 }
 
 // ── Roster File (global agent XP/level) ────────────────────────────
@@ -83,21 +95,25 @@ func claudeDir() string {
 	return filepath.Join(home, ".claude")
 }
 
-func agentsDir() string  { return filepath.Join(claudeDir(), "agents") }
-func skillsDir() string  { return filepath.Join(claudeDir(), "skills") }
-func configPath() string { return filepath.Join(forgeDir(), "config.yaml") }
-func rosterPath() string { return filepath.Join(forgeDir(), "roster.yaml") }
-func partiesDir() string { return filepath.Join(forgeDir(), "parties") }
+func agentsDir() string    { return filepath.Join(claudeDir(), "agents") }
+func skillsDir() string    { return filepath.Join(claudeDir(), "skills") }
+func configPath() string   { return filepath.Join(forgeDir(), "config.yaml") }
+func rosterPath() string   { return filepath.Join(forgeDir(), "roster.yaml") }
+func partiesDir() string   { return filepath.Join(forgeDir(), "parties") }
 func sessionsDir() string  { return filepath.Join(forgeDir(), "sessions") }
+func stateDir() string     { return filepath.Join(forgeDir(), "state") }
 func worktreesDir() string { return filepath.Join(forgeDir(), "worktrees") }
 func partyPath(name string) string {
 	return filepath.Join(partiesDir(), name+".yaml")
 }
+func daemonSocketPath() string { return filepath.Join(forgeDir(), "forge.sock") }
+func apiSocketPath() string    { return filepath.Join(forgeDir(), "api.sock") }
+func apiTokenPath() string     { return filepath.Join(forgeDir(), "api.token") }
 
 // ── Load / Save ────────────────────────────────────────────────────
 
 func ensureForgeDir() error {
-	for _, d := range []string{forgeDir(), partiesDir(), sessionsDir(), worktreesDir(), agentsDir()} {
+	for _, d := range []string{forgeDir(), partiesDir(), sessionsDir(), worktreesDir(), stateDir(), agentsDir()} {
 		if err := os.MkdirAll(d, 0755); err != nil {
 			return err
 		}
@@ -168,7 +184,84 @@ func SaveParty(p *PartyFile) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(partyPath(p.Name), data, 0644)
+	return writeFileAtomic(partyPath(p.Name), data)
+}
+
+// writeFileAtomic replaces path with data via a synced temp file and rename,
+// so a crash mid-write leaves either the old file or the new one.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ── Party Runtime State ────────────────────────────────────────────
+
+// PartyState is the runtime state of a party's agents that PartyFile
+// doesn't carry. It is rewritten atomically whenever it changes so a crash
+// doesn't lose queued handoffs or which worktree belongs to which agent.
+// Agents are keyed by name since slot positions change on swap.
+type PartyState struct {
+	Agents map[string]*AgentState `yaml:"agents"`
+}
+
+type AgentState struct {
+	Status          string   `yaml:"status,omitempty"` // last known status
+	Worktree        string   `yaml:"worktree,omitempty"`
+	Branch          string   `yaml:"branch,omitempty"`
+	HandoffContext  string   `yaml:"handoff_context,omitempty"`
+	PendingEquipped []string `yaml:"pending_equipped,omitempty"`
+	PendingPassives []string `yaml:"pending_passives,omitempty"`
+	HasPending      bool     `yaml:"has_pending,omitempty"`
+	Model           string   `yaml:"model,omitempty"`
+}
+
+func partyStatePath(name string) string {
+	return filepath.Join(stateDir(), name+".yaml")
+}
+
+func LoadPartyState(name string) (*PartyState, error) {
+	data, err := os.ReadFile(partyStatePath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &PartyState{Agents: make(map[string]*AgentState)}, nil
+		}
+		return nil, err
+	}
+	var s PartyState
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	if s.Agents == nil {
+		s.Agents = make(map[string]*AgentState)
+	}
+	return &s, nil
+}
+
+func SavePartyState(name string, s *PartyState) error {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(stateDir(), 0755)
+	return writeFileAtomic(partyStatePath(name), data)
 }
 
 func ListPartyFiles() ([]string, error) {
@@ -326,6 +419,7 @@ func LoadSkillsFromDir() ([]*SkillEntry, error) {
 			Name:        name,
 			Description: desc,
 			Content:     content,
+			Tokens:      countTokens(content),
 		})
 	}
 	return skills, nil
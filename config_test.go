@@ -0,0 +1,145 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "party.yaml")
+	for _, data := range []string{"first", "second"} {
+		if err := writeFileAtomic(path, []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := os.ReadFile(path); string(got) != data {
+			t.Errorf("read %q after writing %q", got, data)
+		}
+	}
+	if fi, _ := os.Stat(path); fi.Mode().Perm() != 0644 {
+		t.Errorf("mode %v, want 0644", fi.Mode().Perm())
+	}
+
+	// A failed rename leaves the target alone and no temp file behind
+	blocked := filepath.Join(dir, "blocked")
+	os.MkdirAll(filepath.Join(blocked, "child"), 0755)
+	if err := writeFileAtomic(blocked, []byte("x")); err == nil {
+		t.Error("replacing a non-empty directory succeeded")
+	}
+	if err := writeFileAtomic(filepath.Join(dir, "missing", "f"), []byte("x")); err == nil {
+		t.Error("writing into a missing directory succeeded")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("directory holds %v, want only party.yaml and blocked", names)
+	}
+}
+
+func TestPartyStateRoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := ensureForgeDir(); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := LoadPartyState("new"); err != nil || s.Agents == nil || len(s.Agents) != 0 {
+		t.Fatalf("state of a party never saved: %+v, %v", s, err)
+	}
+
+	want := &PartyState{Agents: map[string]*AgentState{
+		"Builder": {
+			Status:          "running",
+			Worktree:        "/wt/api/Builder",
+			Branch:          "forge/api/Builder",
+			HandoffContext:  "## Handoff from Planner\n\nship it\n",
+			PendingEquipped: []string{"go", "sql"},
+			PendingPassives: []string{"terse"},
+			HasPending:      true,
+			Model:           "opus",
+		},
+		"Scout": {Status: "idle"},
+	}}
+	if err := SavePartyState("api", want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadPartyState("api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loaded %+v, want %+v", got.Agents["Builder"], want.Agents["Builder"])
+	}
+
+	os.WriteFile(partyStatePath("empty"), []byte("agents:\n"), 0644)
+	if s, err := LoadPartyState("empty"); err != nil || s.Agents == nil {
+		t.Errorf("state without agents: %+v, %v", s, err)
+	}
+	os.WriteFile(partyStatePath("bad"), []byte("agents: [\n"), 0644)
+	if _, err := LoadPartyState("bad"); err == nil {
+		t.Error("malformed state loaded")
+	}
+}
+
+func TestRestoreAgentState(t *testing.T) {
+	idle := func() *AgentInstance { return &AgentInstance{Status: "idle", Task: "Awaiting orders..."} }
+
+	inst := idle()
+	restoreAgentState(inst, nil)
+	if !reflect.DeepEqual(inst, idle()) {
+		t.Errorf("nil state changed the instance: %+v", inst)
+	}
+
+	inst = idle()
+	restoreAgentState(inst, &AgentState{Status: "exited", Worktree: "/wt", Branch: "b",
+		HandoffContext: "notes", PendingEquipped: []string{"go"}, HasPending: true, Model: "opus"})
+	if inst.Status != "idle" || inst.Worktree != "/wt" || inst.Branch != "b" || inst.HandoffContext != "notes" ||
+		!reflect.DeepEqual(inst.PendingEquipped, []string{"go"}) || !inst.HasPending || inst.Model != "opus" {
+		t.Errorf("restored %+v", inst)
+	}
+
+	// Running when the state was saved means the TUI died under it
+	inst = idle()
+	restoreAgentState(inst, &AgentState{Status: "running"})
+	if inst.Status != "exited" || inst.Task != "Interrupted" {
+		t.Errorf("agent saved running restored as %s (%s)", inst.Status, inst.Task)
+	}
+}
+
+func TestInitialModelReattachesDaemonAgents(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	oldLauncher := DefaultLauncher
+	t.Cleanup(func() { DefaultLauncher = oldLauncher })
+	serveDaemon(t)
+	if _, _, err := loadForgeConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveParty(&PartyFile{Name: "solo", Project: t.TempDir(),
+		Slots: []PartySlotConfig{{Agent: "Builder"}, {Agent: "Scout"}}}); err != nil {
+		t.Fatal(err)
+	}
+	SavePartyState("solo", &PartyState{Agents: map[string]*AgentState{
+		"Builder": {Status: "running", Worktree: "/wt/Builder", HandoffContext: "notes"},
+		"Scout":   {Status: "running"},
+	}})
+	// Builder outlived the TUI in the daemon; Scout died with it
+	spawnInDaemon(t, "solo-0-Builder", daemonSessionInfo{Backend: "claude"})
+
+	m, err := initialModel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := DefaultLauncher.(DaemonLauncher); !ok || !m.daemon {
+		t.Fatal("initialModel did not switch to the daemon")
+	}
+	builder, scout := m.parties[0].Slots[0], m.parties[0].Slots[1]
+	if builder.Status != "attaching" || builder.Worktree != "/wt/Builder" || builder.HandoffContext != "notes" {
+		t.Errorf("Builder %s, worktree %q, handoff %q", builder.Status, builder.Worktree, builder.HandoffContext)
+	}
+	if scout.Status != "exited" || scout.Task != "Interrupted" {
+		t.Errorf("Scout %s (%s), want exited (Interrupted)", scout.Status, scout.Task)
+	}
+}
@@ -0,0 +1,585 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/vt"
+)
+
+// ── Copy Mode ─────────────────────────────────────────────────────
+//
+// The vt emulator keeps no scrollback, so copy mode ([ or / on the main
+// pane) rebuilds the selected agent's history by replaying its session
+// recording and keeping the lines that scroll off its screen, then
+// browses it as plain text with vim keys: search with / ? n N, select with
+// v or V, and y to yank the selection to the system clipboard via OSC 52. Exited agents are browsed
+// through the transcript captured when they exit (see transcript.go).
+
+// scrollbackLines is how much history copy mode rebuilds.
+const scrollbackLines = 2000
+
+// clipboardOut receives OSC 52 sequences; tests swap it out.
+var clipboardOut io.Writer = os.Stdout
+
+// copyPos is a position in the history: line index and rune column.
+type copyPos struct {
+	Line, Col int
+}
+
+func (p copyPos) before(q copyPos) bool {
+	return p.Line < q.Line || (p.Line == q.Line && p.Col < q.Col)
+}
+
+// copyMode is the state of copy mode on one agent's history.
+type copyMode struct {
+	inst    *AgentInstance
+	session string   // set when browsing an earlier session's transcript
+	lines   [][]rune // history, oldest first
+	top     int      // first line on screen
+	cursor  copyPos
+
+	visual   bool // selecting from anchor to cursor
+	lineWise bool // V: whole lines
+	anchor   copyPos
+
+	searching bool   // typing a query after / or ?
+	backward  bool   // ? searches towards the top
+	input     string // query being typed
+	pattern   string // last confirmed query, for n/N
+
+	status  string // one-line feedback: yank size, pattern not found
+	loading bool   // showing the screen while the recording replays
+	yanked  string // last text sent to the clipboard
+}
+
+// agentScreen is what copy mode shows on inst until its recording is
+// loaded: the transcript of an exited agent, else what is on screen.
+func agentScreen(inst *AgentInstance) []string {
+	if inst.emulator == nil && len(inst.Transcript) > 0 {
+		return inst.Transcript
+	}
+	text := inst.LastOutput
+	if inst.emulator != nil {
+		text = emulatorText(inst.emulator)
+	}
+	return trimScreenLines(strings.Split(text, "\n"))
+}
+
+// scrollbackMsg delivers a running agent's replayed recording to the copy
+// mode that asked for it.
+type scrollbackMsg struct {
+	target *copyMode
+	lines  []string
+}
+
+// loadScrollback replays sessionDir's recording off the Update goroutine.
+func loadScrollback(c *copyMode, sessionDir string) tea.Cmd {
+	return func() tea.Msg {
+		lines, _ := castScrollback(filepath.Join(sessionDir, castFileName))
+		return scrollbackMsg{target: c, lines: lines}
+	}
+}
+
+func (m Model) handleScrollback(msg scrollbackMsg) (tea.Model, tea.Cmd) {
+	c := m.scrollback
+	if c != msg.target {
+		return m, nil // closed or reopened since
+	}
+	c.loading = false
+	if len(msg.lines) == 0 {
+		return m, nil
+	}
+	// The screen shown so far is the tail of the history: keep the cursor,
+	// selection and view on the same text
+	shift := len(msg.lines) - len(c.lines)
+	c.lines = c.lines[:0]
+	for _, l := range msg.lines {
+		c.lines = append(c.lines, []rune(l))
+	}
+	c.moveTo(c.cursor.Line+shift, c.cursor.Col)
+	c.anchor.Line = max(min(c.anchor.Line+shift, len(c.lines)-1), 0)
+	c.top = max(c.top+shift, 0)
+	c.scrollTo(m.copyViewHeight())
+	return m, nil
+}
+
+// castScrollback replays a recording at its recorded size and returns the
+// last scrollbackLines lines of output: each line a line feed scrolled off
+// the top of the screen, then the final screen. Like a terminal, it keeps
+// nothing from the alternate screen. Scroll regions are not tracked: a
+// line feed on the bottom row is taken to scroll the whole screen.
+func castScrollback(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var em *vt.SafeEmulator
+	var hdr castHeader
+	var history []string
+	alt := false
+	err = readCastEvents(f, &hdr, func(ev castEvent) {
+		if em == nil {
+			w, h := hdr.Width, hdr.Height
+			if w <= 0 {
+				w = 80
+			}
+			if h <= 0 {
+				h = 24
+			}
+			em = vt.NewSafeEmulator(w, h)
+			em.SetCallbacks(vt.Callbacks{AltScreen: func(on bool) { alt = on }})
+			// Terminal query replies have nowhere to go
+			go io.Copy(io.Discard, em)
+		}
+		switch ev.Code {
+		case "o":
+			data := ev.Data
+			for {
+				i := strings.IndexAny(data, "\n\v\f")
+				if i < 0 {
+					em.Write([]byte(data))
+					break
+				}
+				em.Write([]byte(data[:i]))
+				// A line feed on the bottom row pushes the top row off
+				if !alt && em.CursorPosition().Y == em.Height()-1 {
+					history = append(history, screenRow(em, 0))
+					if len(history) > 2*scrollbackLines {
+						history = append(history[:0], history[len(history)-scrollbackLines:]...)
+					}
+				}
+				em.Write([]byte(data[i : i+1]))
+				data = data[i+1:]
+			}
+		case "r":
+			var w, h int
+			if _, err := fmt.Sscanf(ev.Data, "%dx%d", &w, &h); err == nil && w > 0 && h > 0 {
+				em.Resize(w, h)
+			}
+		}
+	})
+	if em == nil {
+		return nil, err
+	}
+	defer closeEmulator(em)
+	history = trimScreenLines(append(history, strings.Split(emulatorText(em), "\n")...))
+	if len(history) > scrollbackLines {
+		history = history[len(history)-scrollbackLines:]
+	}
+	return history, err
+}
+
+// screenRow is row y of em's screen as plain text.
+func screenRow(em *vt.SafeEmulator, y int) string {
+	var b strings.Builder
+	for x := 0; x < em.Width(); x++ {
+		if c := em.CellAt(x, y); c == nil {
+			b.WriteByte(' ')
+		} else {
+			b.WriteString(c.Content) // empty after a wide rune
+		}
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+// trimScreenLines drops trailing blanks from each line and blank rows
+// below the last output.
+func trimScreenLines(lines []string) []string {
+	for i, l := range lines {
+		lines[i] = strings.TrimRightFunc(l, unicode.IsSpace)
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// openCopyMode enters copy mode on the selected agent with the cursor on
+// its last line. search starts typing a / query right away. A running
+// agent's screen is shown until its recording has been replayed.
+func (m Model) openCopyMode(search bool) (Model, tea.Cmd) {
+	inst := m.agent()
+	if inst == nil {
+		return m, nil
+	}
+	cm := newCopyMode(inst, agentScreen(inst))
+	cm.cursor = copyPos{Line: len(cm.lines) - 1}
+	cm.scrollTo(m.copyViewHeight())
+	cm.searching = search
+	m.scrollback = cm
+	m.pushMode(ModeCopy)
+	if (inst.emulator == nil && len(inst.Transcript) > 0) || inst.sessionDir == "" {
+		return m, nil
+	}
+	cm.loading = true
+	return m, loadScrollback(cm, inst.sessionDir)
+}
+
+// newCopyMode browses history as inst's, with the cursor at the top.
+func newCopyMode(inst *AgentInstance, history []string) *copyMode {
+	if len(history) == 0 {
+		history = []string{""}
+	}
+	cm := &copyMode{inst: inst, lines: make([][]rune, len(history))}
+	for i, l := range history {
+		cm.lines[i] = []rune(l)
+	}
+	return cm
+}
+
+// copyViewHeight is how many history lines fit above the status line.
+func (m Model) copyViewHeight() int {
+	return max(m.termHeight()-1, 1)
+}
+
+// scrollTo keeps the cursor on screen.
+func (c *copyMode) scrollTo(height int) {
+	if c.cursor.Line < c.top {
+		c.top = c.cursor.Line
+	}
+	if c.cursor.Line >= c.top+height {
+		c.top = c.cursor.Line - height + 1
+	}
+}
+
+// moveTo puts the cursor at line, col, clamped to the history.
+func (c *copyMode) moveTo(line, col int) {
+	line = max(min(line, len(c.lines)-1), 0)
+	col = max(min(col, len(c.lines[line])-1), 0)
+	c.cursor = copyPos{Line: line, Col: col}
+}
+
+// selection returns the selected range in order, inclusive.
+func (c *copyMode) selection() (start, end copyPos) {
+	start, end = c.anchor, c.cursor
+	if end.before(start) {
+		start, end = end, start
+	}
+	if c.lineWise {
+		start.Col = 0
+		end.Col = max(len(c.lines[end.Line])-1, 0)
+	}
+	return start, end
+}
+
+func (c *copyMode) selected(p copyPos) bool {
+	if !c.visual {
+		return false
+	}
+	start, end := c.selection()
+	return !p.before(start) && !end.before(p)
+}
+
+// selectionText is the selected text, lines joined by newlines.
+func (c *copyMode) selectionText() string {
+	start, end := c.selection()
+	var b strings.Builder
+	for i := start.Line; i <= end.Line; i++ {
+		line := c.lines[i]
+		from, to := 0, len(line)
+		if i == start.Line {
+			from = min(start.Col, len(line))
+		}
+		if i == end.Line {
+			to = min(end.Col+1, len(line))
+		}
+		if from < to {
+			b.WriteString(string(line[from:to]))
+		}
+		if i < end.Line {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// matchAt reports whether the search pattern starts at col in line.
+// Lowercase patterns match case-insensitively (vim's smartcase).
+func matchAt(line []rune, col int, pattern []rune, fold bool) bool {
+	if col+len(pattern) > len(line) {
+		return false
+	}
+	for i, r := range pattern {
+		l := line[col+i]
+		if fold {
+			l = unicode.ToLower(l)
+		}
+		if l != r {
+			return false
+		}
+	}
+	return true
+}
+
+func searchPattern(q string) ([]rune, bool) {
+	fold := strings.ToLower(q) == q
+	return []rune(q), fold
+}
+
+// search moves the cursor to the next match of c.pattern, wrapping
+// around the history. It reports whether anything matched.
+func (c *copyMode) search(backward bool) bool {
+	if c.pattern == "" {
+		return false
+	}
+	pat, fold := searchPattern(c.pattern)
+	n := len(c.lines)
+	for step := 0; step <= n; step++ {
+		li := c.cursor.Line + step
+		if backward {
+			li = c.cursor.Line - step
+		}
+		li = ((li % n) + n) % n
+		line := c.lines[li]
+		if backward {
+			from := len(line) - 1
+			if step == 0 {
+				from = c.cursor.Col - 1
+			}
+			for col := from; col >= 0; col-- {
+				if matchAt(line, col, pat, fold) {
+					c.cursor = copyPos{Line: li, Col: col}
+					return true
+				}
+			}
+			continue
+		}
+		from := 0
+		if step == 0 {
+			from = c.cursor.Col + 1
+		}
+		for col := from; col < len(line); col++ {
+			if matchAt(line, col, pat, fold) {
+				c.cursor = copyPos{Line: li, Col: col}
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// osc52 wraps text in the sequence that sets the terminal's clipboard.
+func osc52(text string) string {
+	return "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\x07"
+}
+
+// yankCmd sends text to the system clipboard.
+func yankCmd(text string) tea.Cmd {
+	return func() tea.Msg {
+		io.WriteString(clipboardOut, osc52(text))
+		return nil
+	}
+}
+
+// ── Copy Mode Keys ────────────────────────────────────────────────
+
+func (m Model) handleCopyMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	c := m.scrollback
+	if c == nil {
+		m.popMode()
+		return m, nil
+	}
+	height := m.copyViewHeight()
+	key := msg.String()
+
+	if c.searching {
+		switch key {
+		case "esc":
+			c.searching, c.input = false, ""
+		case "enter":
+			c.searching = false
+			if c.input != "" {
+				c.pattern = c.input
+			}
+			c.input = ""
+			c.status = ""
+			if !c.search(c.backward) {
+				c.status = "Pattern not found: " + c.pattern
+			}
+		case "backspace":
+			if r := []rune(c.input); len(r) > 0 {
+				c.input = string(r[:len(r)-1])
+			}
+		default:
+			switch msg.Type {
+			case tea.KeyRunes:
+				c.input += string(msg.Runes)
+			case tea.KeySpace:
+				c.input += " "
+			}
+		}
+		c.scrollTo(height)
+		return m, nil
+	}
+
+	c.status = ""
+	cur := c.cursor
+	switch key {
+	case "esc", "q":
+		if c.visual && key == "esc" {
+			c.visual = false
+			break
+		}
+		m.scrollback = nil
+		m.popMode()
+		return m, nil
+	case "up", "k":
+		c.moveTo(cur.Line-1, cur.Col)
+	case "down", "j":
+		c.moveTo(cur.Line+1, cur.Col)
+	case "left", "h":
+		c.moveTo(cur.Line, cur.Col-1)
+	case "right", "l":
+		c.moveTo(cur.Line, cur.Col+1)
+	case "0", "home":
+		c.moveTo(cur.Line, 0)
+	case "$", "end":
+		c.moveTo(cur.Line, len(c.lines[cur.Line])-1)
+	case "ctrl+u":
+		c.moveTo(cur.Line-height/2, cur.Col)
+	case "ctrl+d":
+		c.moveTo(cur.Line+height/2, cur.Col)
+	case "ctrl+b", "pgup":
+		c.moveTo(cur.Line-height, cur.Col)
+	case "ctrl+f", "pgdown":
+		c.moveTo(cur.Line+height, cur.Col)
+	case "g":
+		c.moveTo(0, 0)
+	case "G":
+		c.moveTo(len(c.lines)-1, 0)
+	case "/", "?":
+		c.searching, c.backward, c.input = true, key == "?", ""
+	case "n", "N":
+		if !c.search(c.backward != (key == "N")) && c.pattern != "" {
+			c.status = "Pattern not found: " + c.pattern
+		}
+	case "v", "V":
+		lineWise := key == "V"
+		if c.visual && c.lineWise == lineWise {
+			c.visual = false
+			break
+		}
+		if !c.visual {
+			c.anchor = c.cursor
+		}
+		c.visual, c.lineWise = true, lineWise
+	case "y":
+		if !c.visual {
+			// Nothing selected: yank the cursor line
+			c.anchor, c.lineWise = c.cursor, true
+		}
+		c.visual = true
+		text := c.selectionText()
+		start, end := c.selection()
+		c.visual = false
+		c.yanked = text
+		c.status = fmt.Sprintf("Yanked %d line(s) to the clipboard", end.Line-start.Line+1)
+		return m, yankCmd(text)
+	}
+	c.scrollTo(height)
+	return m, nil
+}
+
+// ── Copy Mode View ────────────────────────────────────────────────
+
+func (m Model) renderCopyMode(tw, th int) string {
+	c := m.scrollback
+	height := m.copyViewHeight()
+	pat, fold := searchPattern(c.pattern)
+
+	cursorStyle := lipgloss.NewStyle().Reverse(true)
+	selStyle := lipgloss.NewStyle().Background(colorBgLight).Foreground(colorTextBright)
+	matchStyle := lipgloss.NewStyle().Background(colorYellow).Foreground(colorBgDark)
+
+	var rows []string
+	for i := c.top; i < c.top+height; i++ {
+		if i >= len(c.lines) {
+			rows = append(rows, "")
+			continue
+		}
+		line := c.lines[i]
+		if len(line) > tw {
+			line = line[:tw]
+		}
+		// Mark search matches on the line
+		matched := make([]bool, len(line)+1)
+		if len(pat) > 0 {
+			for col := range line {
+				if matchAt(line, col, pat, fold) {
+					for k := col; k < col+len(pat) && k < len(line); k++ {
+						matched[k] = true
+					}
+				}
+			}
+		}
+		var b strings.Builder
+		cells := max(len(line), 1)
+		if i == c.cursor.Line {
+			cells = max(cells, c.cursor.Col+1)
+		}
+		for col := 0; col < cells && col < tw; col++ {
+			ch := " "
+			if col < len(line) {
+				ch = string(line[col])
+			}
+			p := copyPos{Line: i, Col: col}
+			switch {
+			case p == c.cursor:
+				b.WriteString(cursorStyle.Render(ch))
+			case c.selected(p):
+				b.WriteString(selStyle.Render(ch))
+			case matched[min(col, len(line))]:
+				b.WriteString(matchStyle.Render(ch))
+			default:
+				b.WriteString(ch)
+			}
+		}
+		rows = append(rows, b.String())
+	}
+
+	// Status line: search prompt, feedback, or where we are
+	var status string
+	switch {
+	case c.searching:
+		prompt := "/"
+		if c.backward {
+			prompt = "?"
+		}
+		status = lipgloss.NewStyle().Foreground(colorTextBright).Render(prompt + c.input + "█")
+	case c.status != "":
+		status = lipgloss.NewStyle().Foreground(colorYellow).Render(c.status)
+	default:
+		mode := "COPY"
+		if c.visual && c.lineWise {
+			mode = "VISUAL LINE"
+		} else if c.visual {
+			mode = "VISUAL"
+		}
+		who := c.inst.AgentName
+		if c.session != "" {
+			who += " (" + c.session + ")"
+		}
+		if c.loading {
+			who += " · loading scrollback…"
+		}
+		status = lipgloss.NewStyle().Foreground(colorTextDim).Render(fmt.Sprintf("%s · %s · line %d/%d",
+			mode, who, c.cursor.Line+1, len(c.lines)))
+	}
+	rows = append(rows, status)
+
+	content := lipgloss.NewStyle().Width(tw).Height(th).Render(strings.Join(rows, "\n"))
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorYellow).
+		Render(content)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCopyModeSearchesAndYanks(t *testing.T) {
+	h := newHarness(t, PartyFile{Name: "long", Slots: []PartySlotConfig{{Agent: "Builder"}}})
+	h.WaitFor(fakeAgentReady)
+	h.Keys("i")
+	h.Type("lines 80\r")
+	h.Keys("esc")
+	h.WaitFor("line 80")
+	if strings.Contains(h.View(), fakeAgentReady) {
+		t.Fatal("output did not scroll off the screen")
+	}
+	h.Keys("[")
+	if !h.Model().scrollback.loading {
+		t.Fatal("copy mode did not load the recording")
+	}
+	h.WaitUntil("scrollback loaded", func(m Model) bool { return !m.scrollback.loading })
+	h.Keys("g")
+	h.WaitFor(fakeAgentReady)
+	h.Keys("/")
+	h.Type("line 7\r")
+	h.Keys("V", "j", "y")
+	if got := h.Model().scrollback.yanked; got != "line 7\nline 8" {
+		t.Errorf("yanked %q, want lines 7 and 8", got)
+	}
+	h.Keys("q")
+	if h.Model().mode != ModeNormal {
+		t.Error("q did not leave copy mode")
+	}
+}
+
+func TestCopyModeSearchSmartcaseAndWrap(t *testing.T) {
+	c := newCopyMode(nil, []string{"alpha", "Beta beta", "gamma"})
+	steps := []struct {
+		pattern  string
+		backward bool
+		want     copyPos
+	}{
+		{"beta", false, copyPos{1, 0}}, // lowercase matches any case
+		{"beta", false, copyPos{1, 5}},
+		{"beta", false, copyPos{1, 0}}, // wraps around the history
+		{"Beta", false, copyPos{1, 0}}, // uppercase is exact: the only match
+		{"a", true, copyPos{0, 4}},     // backward wraps to the end of the line above
+	}
+	for _, s := range steps {
+		c.pattern = s.pattern
+		if !c.search(s.backward) || c.cursor != s.want {
+			t.Fatalf("search %q (backward %v) landed on %v, want %v", s.pattern, s.backward, c.cursor, s.want)
+		}
+	}
+	c.pattern = "delta"
+	if c.search(false) {
+		t.Error("search for a missing pattern matched")
+	}
+}
+
+func TestCopyModeSelectionText(t *testing.T) {
+	c := newCopyMode(nil, []string{"alpha", "Beta beta", "gamma"})
+	c.visual = true
+	c.anchor, c.cursor = copyPos{1, 1}, copyPos{0, 2} // selected upwards
+	if got := c.selectionText(); got != "pha\nBe" {
+		t.Errorf("charwise %q", got)
+	}
+	if !c.selected(copyPos{0, 4}) || c.selected(copyPos{1, 2}) {
+		t.Error("selected() disagrees with the selection")
+	}
+	c.lineWise = true
+	if got := c.selectionText(); got != "alpha\nBeta beta" {
+		t.Errorf("linewise %q", got)
+	}
+}
+
+// writeCast writes a recording with the given header size and output
+// events, one per string.
+func writeCast(t *testing.T, width, height int, events ...[]any) string {
+	t.Helper()
+	var b strings.Builder
+	fmt.Fprintf(&b, `{"version": 2, "width": %d, "height": %d}`+"\n", width, height)
+	for _, ev := range events {
+		data, _ := json.Marshal(ev)
+		b.Write(data)
+		b.WriteByte('\n')
+	}
+	path := filepath.Join(t.TempDir(), castFileName)
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func numberedLines(from, to int) string {
+	var out strings.Builder
+	for i := from; i <= to; i++ {
+		fmt.Fprintf(&out, "line %d\r\n", i)
+	}
+	return out.String()
+}
+
+func TestCastScrollbackKeepsScrolledOffLines(t *testing.T) {
+	path := writeCast(t, 20, 3, []any{0.1, "o", numberedLines(1, 10)})
+	lines, err := castScrollback(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 10 || lines[0] != "line 1" || lines[9] != "line 10" {
+		t.Errorf("scrollback %q, want all 10 lines of a 3-row recording", lines)
+	}
+}
+
+func TestCastScrollbackCursorAddressedOutput(t *testing.T) {
+	// A status line drawn at the top of the screen and a prompt at the
+	// bottom overwrite the screen, not the lines already scrolled off
+	path := writeCast(t, 20, 3,
+		[]any{0.1, "o", numberedLines(1, 6)},
+		[]any{0.2, "o", "\x1b[Hstatus\x1b[3;1Hdone"},
+	)
+	lines, err := castScrollback(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"line 1", "line 2", "line 3", "line 4", "status", "line 6", "done"}
+	if !slices.Equal(lines, want) {
+		t.Errorf("scrollback %q, want %q", lines, want)
+	}
+}
+
+func TestCastScrollbackResizeAndAltScreen(t *testing.T) {
+	path := writeCast(t, 20, 3,
+		[]any{0.1, "o", numberedLines(1, 4)},
+		[]any{0.2, "r", "20x5"},
+		// A full-screen app's redraws never reach the history
+		[]any{0.3, "o", "\x1b[?1049h" + strings.Repeat("frame\r\n", 20) + "\x1b[?1049l"},
+		[]any{0.4, "o", numberedLines(5, 9)},
+	)
+	lines, err := castScrollback(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slices.Contains(lines, "frame") {
+		t.Errorf("alternate screen output kept: %q", lines)
+	}
+	for i := 1; i <= 9; i++ {
+		if !slices.Contains(lines, fmt.Sprintf("line %d", i)) {
+			t.Errorf("line %d missing from %q", i, lines)
+		}
+	}
+}
+
+func TestTrimScreenLines(t *testing.T) {
+	got := trimScreenLines([]string{"a  ", "", "b\t", "   ", ""})
+	if !slices.Equal(got, []string{"a", "", "b"}) {
+		t.Errorf("%q", got)
+	}
+}
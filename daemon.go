@@ -0,0 +1,633 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/vt"
+)
+
+// ── Forge Daemon ──────────────────────────────────────────────────
+//
+// `agent-tui serve` runs a background daemon that owns agent PTYs and their
+// emulator state. The TUI talks to it over a Unix socket in ~/.agent-forge/,
+// so agents survive the TUI quitting, SSH disconnects and crashes, and are
+// reattached tmux-style on the next start.
+//
+// Each connection starts with one JSON request line and one JSON response
+// line. spawn and attach then switch the connection to a framed stream:
+// a 1-byte frame type, a 4-byte big-endian length and the payload.
+
+const (
+	frameData   byte = 'd' // PTY output (daemon→TUI) or input (TUI→daemon)
+	frameResize byte = 'r' // cols, rows as two big-endian uint16
+	frameSignal byte = 's' // signal number as big-endian uint32
+	frameExit   byte = 'x' // process exited; payload is the error text
+)
+
+const maxFrameSize = 1 << 20
+
+// clientWriteTimeout bounds every write to an attached TUI, which happens
+// under the session lock: a client that stops reading is dropped instead
+// of stalling output to the others.
+const clientWriteTimeout = 2 * time.Second
+
+type daemonRequest struct {
+	Op      string             `json:"op"` // spawn, attach, list, kill
+	ID      string             `json:"id,omitempty"`
+	Command *AgentCommand      `json:"command,omitempty"`
+	Info    *daemonSessionInfo `json:"info,omitempty"`
+}
+
+type daemonResponse struct {
+	Error    string              `json:"error,omitempty"`
+	Session  *daemonSessionInfo  `json:"session,omitempty"`
+	Sessions []daemonSessionInfo `json:"sessions,omitempty"`
+}
+
+// daemonSessionInfo is the metadata the daemon keeps for a session so a
+// reattaching TUI can restore worktree and backend state.
+type daemonSessionInfo struct {
+	ID       string    `json:"id"`
+	Pid      int       `json:"pid"`
+	Backend  string    `json:"backend"`
+	Worktree string    `json:"worktree,omitempty"`
+	Branch   string    `json:"branch,omitempty"`
+	Started  time.Time `json:"started"`
+	Exited   bool      `json:"exited"`
+
+	SessionDir     string `json:"session_dir,omitempty"`
+	ConversationID string `json:"conversation_id,omitempty"`
+}
+
+func writeFrame(w io.Writer, typ byte, payload []byte) error {
+	hdr := make([]byte, 5, 5+len(payload))
+	hdr[0] = typ
+	binary.BigEndian.PutUint32(hdr[1:], uint32(len(payload)))
+	_, err := w.Write(append(hdr, payload...))
+	return err
+}
+
+func readFrame(r io.Reader) (byte, []byte, error) {
+	var hdr [5]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return 0, nil, err
+	}
+	n := binary.BigEndian.Uint32(hdr[1:])
+	if n > maxFrameSize {
+		return 0, nil, fmt.Errorf("frame too large: %d bytes", n)
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return hdr[0], payload, nil
+}
+
+// ── Server ────────────────────────────────────────────────────────
+
+type forgeDaemon struct {
+	mu       sync.Mutex
+	sessions map[string]*daemonSession
+}
+
+// daemonSession is one agent process owned by the daemon. mu guards the
+// emulator and client set so attach snapshots never interleave with output.
+type daemonSession struct {
+	mu      sync.Mutex
+	info    daemonSessionInfo
+	proc    *localProcess
+	em      *vt.SafeEmulator
+	clients map[net.Conn]bool
+	exitErr string
+
+	// attached is len(clients), kept for answerQueries: it can't take mu,
+	// which pump holds while the emulator writes the replies it reads.
+	attached atomic.Int32
+}
+
+// runServe starts the forge daemon and blocks until SIGINT/SIGTERM.
+// Usage: agent-tui serve
+func runServe(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("usage: agent-tui serve")
+	}
+	if err := ensureForgeDir(); err != nil {
+		return fmt.Errorf("creating forge dir: %w", err)
+	}
+
+	sock := daemonSocketPath()
+	if daemonAvailable() {
+		return fmt.Errorf("daemon already running on %s", sock)
+	}
+	os.Remove(sock) // stale socket from a crashed daemon
+
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", sock, err)
+	}
+	defer os.Remove(sock)
+
+	d := &forgeDaemon{sessions: make(map[string]*daemonSession)}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-sigCh
+		fmt.Fprintf(os.Stderr, "forge daemon: received %s, stopping agents\n", sig)
+		d.stopAll()
+		ln.Close()
+	}()
+
+	fmt.Fprintf(os.Stderr, "forge daemon: listening on %s\n", sock)
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go d.handleConn(conn)
+	}
+}
+
+func (d *forgeDaemon) handleConn(conn net.Conn) {
+	br := bufio.NewReader(conn)
+	line, err := br.ReadBytes('\n')
+	if err != nil {
+		conn.Close()
+		return
+	}
+	var req daemonRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		d.reply(conn, daemonResponse{Error: "bad request: " + err.Error()})
+		conn.Close()
+		return
+	}
+
+	switch req.Op {
+	case "list":
+		d.reply(conn, daemonResponse{Sessions: d.list()})
+		conn.Close()
+	case "kill":
+		s := d.session(req.ID)
+		if s == nil {
+			d.reply(conn, daemonResponse{Error: "no such session"})
+		} else {
+			s.proc.Signal(syscall.SIGTERM)
+			d.reply(conn, daemonResponse{})
+		}
+		conn.Close()
+	case "spawn":
+		s, err := d.spawn(req)
+		if err != nil {
+			d.reply(conn, daemonResponse{Error: err.Error()})
+			conn.Close()
+			return
+		}
+		d.attach(s, conn, br)
+	case "attach":
+		s := d.session(req.ID)
+		if s == nil {
+			d.reply(conn, daemonResponse{Error: "no such session"})
+			conn.Close()
+			return
+		}
+		d.attach(s, conn, br)
+	default:
+		d.reply(conn, daemonResponse{Error: fmt.Sprintf("unknown op %q", req.Op)})
+		conn.Close()
+	}
+}
+
+func (d *forgeDaemon) reply(conn net.Conn, resp daemonResponse) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Write(append(data, '\n'))
+	return err
+}
+
+func (d *forgeDaemon) session(id string) *daemonSession {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.sessions[id]
+}
+
+func (d *forgeDaemon) list() []daemonSessionInfo {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var out []daemonSessionInfo
+	for _, s := range d.sessions {
+		s.mu.Lock()
+		out = append(out, s.info)
+		s.mu.Unlock()
+	}
+	return out
+}
+
+// spawn starts a new agent process. A live session with the same ID is
+// reused so a racing TUI start cannot launch the agent twice; the reply
+// then carries that session's info, not the request's.
+func (d *forgeDaemon) spawn(req daemonRequest) (*daemonSession, error) {
+	if req.Command == nil || req.ID == "" {
+		return nil, fmt.Errorf("spawn requires id and command")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if s := d.sessions[req.ID]; s != nil && !s.info.Exited {
+		return s, nil
+	}
+
+	proc, err := req.Command.start()
+	if err != nil {
+		return nil, err
+	}
+
+	info := daemonSessionInfo{ID: req.ID}
+	if req.Info != nil {
+		info = *req.Info
+		info.ID = req.ID
+	}
+	info.Pid = proc.cmd.Process.Pid
+	info.Started = time.Now()
+
+	s := &daemonSession{
+		info:    info,
+		proc:    proc,
+		em:      vt.NewSafeEmulator(req.Command.Cols, req.Command.Rows),
+		clients: make(map[net.Conn]bool),
+	}
+	d.sessions[req.ID] = s
+	go s.pump()
+	go s.answerQueries()
+	return s, nil
+}
+
+// attach hands the connection a screen snapshot and then streams live
+// output to it while forwarding its input frames to the PTY.
+func (d *forgeDaemon) attach(s *daemonSession, conn net.Conn, br *bufio.Reader) {
+	s.mu.Lock()
+	info := s.info
+	conn.SetWriteDeadline(time.Now().Add(clientWriteTimeout))
+	if err := d.reply(conn, daemonResponse{Session: &info}); err != nil {
+		s.mu.Unlock()
+		conn.Close()
+		return
+	}
+	if err := writeFrame(conn, frameData, s.snapshot()); err != nil {
+		s.mu.Unlock()
+		conn.Close()
+		return
+	}
+	if s.info.Exited {
+		// Deliver the exit to the first TUI that sees it, then forget it.
+		writeFrame(conn, frameExit, []byte(s.exitErr))
+		s.mu.Unlock()
+		conn.Close()
+		d.mu.Lock()
+		if d.sessions[info.ID] == s {
+			delete(d.sessions, info.ID)
+		}
+		d.mu.Unlock()
+		return
+	}
+	s.clients[conn] = true
+	s.attached.Store(int32(len(s.clients)))
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, conn)
+		s.attached.Store(int32(len(s.clients)))
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	for {
+		typ, payload, err := readFrame(br)
+		if err != nil {
+			return // client detached
+		}
+		switch typ {
+		case frameData:
+			s.proc.Write(payload)
+		case frameResize:
+			if len(payload) == 4 {
+				cols := int(binary.BigEndian.Uint16(payload[0:2]))
+				rows := int(binary.BigEndian.Uint16(payload[2:4]))
+				s.proc.Resize(cols, rows)
+				s.mu.Lock()
+				s.em.Resize(cols, rows)
+				s.mu.Unlock()
+			}
+		case frameSignal:
+			if len(payload) == 4 {
+				s.proc.Signal(syscall.Signal(binary.BigEndian.Uint32(payload)))
+			}
+		}
+	}
+}
+
+func (d *forgeDaemon) stopAll() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, s := range d.sessions {
+		s.proc.Signal(syscall.SIGTERM)
+	}
+}
+
+// snapshot renders the current screen as bytes that reproduce it in a
+// fresh emulator. Caller holds s.mu.
+func (s *daemonSession) snapshot() []byte {
+	pos := s.em.CursorPosition()
+	return []byte("\x1b[H\x1b[2J" + s.em.Render() + fmt.Sprintf("\x1b[%d;%dH", pos.Y+1, pos.X+1))
+}
+
+// pump copies PTY output into the daemon's emulator and every attached client.
+func (s *daemonSession) pump() {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := s.proc.Read(buf)
+		if n > 0 {
+			s.mu.Lock()
+			s.em.Write(buf[:n])
+			for c := range s.clients {
+				c.SetWriteDeadline(time.Now().Add(clientWriteTimeout))
+				if writeFrame(c, frameData, buf[:n]) != nil {
+					c.Close()
+					delete(s.clients, c)
+				}
+			}
+			s.attached.Store(int32(len(s.clients)))
+			s.mu.Unlock()
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	waitErr := s.proc.Wait()
+	s.proc.Close()
+
+	s.mu.Lock()
+	s.info.Exited = true
+	if waitErr != nil {
+		s.exitErr = waitErr.Error()
+	}
+	for c := range s.clients {
+		writeFrame(c, frameExit, []byte(s.exitErr))
+		c.Close()
+	}
+	s.clients = make(map[net.Conn]bool)
+	s.attached.Store(0)
+	s.mu.Unlock()
+	closeEmulator(s.em)
+}
+
+// answerQueries forwards the daemon emulator's terminal query responses to
+// the PTY while no TUI is attached. Attached TUIs answer from their own
+// emulator, so forwarding both would duplicate replies.
+func (s *daemonSession) answerQueries() {
+	buf := make([]byte, 1024)
+	for {
+		n, err := s.em.Read(buf)
+		if n > 0 && s.attached.Load() == 0 {
+			s.proc.Write(buf[:n])
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// ── Client ────────────────────────────────────────────────────────
+
+// daemonAvailable reports whether a forge daemon is accepting connections.
+func daemonAvailable() bool {
+	conn, err := net.DialTimeout("unix", daemonSocketPath(), 500*time.Millisecond)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// daemonCall sends one request and returns the response along with the
+// still-open connection and its reader (for streaming ops).
+func daemonCall(req daemonRequest) (net.Conn, *bufio.Reader, *daemonResponse, error) {
+	conn, err := net.DialTimeout("unix", daemonSocketPath(), 2*time.Second)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("connecting to forge daemon: %w", err)
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		conn.Close()
+		return nil, nil, nil, err
+	}
+	if _, err := conn.Write(append(data, '\n')); err != nil {
+		conn.Close()
+		return nil, nil, nil, err
+	}
+	br := bufio.NewReader(conn)
+	line, err := br.ReadBytes('\n')
+	if err != nil {
+		conn.Close()
+		return nil, nil, nil, fmt.Errorf("reading daemon response: %w", err)
+	}
+	var resp daemonResponse
+	if err := json.Unmarshal(line, &resp); err != nil {
+		conn.Close()
+		return nil, nil, nil, err
+	}
+	if resp.Error != "" {
+		conn.Close()
+		return nil, nil, nil, errors.New(resp.Error)
+	}
+	return conn, br, &resp, nil
+}
+
+// listDaemonSessions returns all sessions the daemon currently owns.
+func listDaemonSessions() ([]daemonSessionInfo, error) {
+	conn, _, resp, err := daemonCall(daemonRequest{Op: "list"})
+	if err != nil {
+		return nil, err
+	}
+	conn.Close()
+	return resp.Sessions, nil
+}
+
+// daemonProcess is an AgentProcess backed by a daemon-owned session.
+// Closing it detaches; the agent keeps running in the daemon.
+type daemonProcess struct {
+	conn    net.Conn
+	br      *bufio.Reader
+	wmu     sync.Mutex
+	pending []byte // unread remainder of the last data frame
+}
+
+func (p *daemonProcess) Read(b []byte) (int, error) {
+	for len(p.pending) == 0 {
+		typ, payload, err := readFrame(p.br)
+		if err != nil {
+			return 0, err
+		}
+		switch typ {
+		case frameData:
+			p.pending = payload
+		case frameExit:
+			if len(payload) > 0 {
+				return 0, errors.New(string(payload))
+			}
+			return 0, io.EOF
+		}
+	}
+	n := copy(b, p.pending)
+	p.pending = p.pending[n:]
+	return n, nil
+}
+
+func (p *daemonProcess) send(typ byte, payload []byte) error {
+	p.wmu.Lock()
+	defer p.wmu.Unlock()
+	return writeFrame(p.conn, typ, payload)
+}
+
+func (p *daemonProcess) Write(b []byte) (int, error) {
+	if err := p.send(frameData, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (p *daemonProcess) Resize(cols, rows int) error {
+	payload := make([]byte, 4)
+	binary.BigEndian.PutUint16(payload[0:2], uint16(cols))
+	binary.BigEndian.PutUint16(payload[2:4], uint16(rows))
+	return p.send(frameResize, payload)
+}
+
+func (p *daemonProcess) Signal(sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return fmt.Errorf("unsupported signal %v", sig)
+	}
+	payload := make([]byte, 4)
+	binary.BigEndian.PutUint32(payload, uint32(s))
+	return p.send(frameSignal, payload)
+}
+
+func (p *daemonProcess) Close() error { return p.conn.Close() }
+
+// Wait is a no-op: the daemon reaps the child, not the TUI.
+func (p *daemonProcess) Wait() error { return nil }
+
+// DaemonLauncher starts agents inside the forge daemon instead of as
+// children of the TUI.
+type DaemonLauncher struct{}
+
+func (DaemonLauncher) Launch(cfg *ForgeConfig, lc LaunchConfig) tea.Cmd {
+	return func() tea.Msg {
+		// An agent still running in the daemon is reattached before
+		// anything is prepared, so no worktree setup or on_start hook runs
+		if live, err := listDaemonSessions(); err == nil {
+			for _, s := range live {
+				if s.ID == lc.ID && !s.Exited {
+					return attachAgent(cfg, lc.ID, lc.Cols, lc.Rows)()
+				}
+			}
+		}
+
+		pl, err := prepareLaunch(cfg, lc)
+		if err != nil {
+			return AgentExitedMsg{ID: lc.ID, Err: err}
+		}
+
+		conn, br, resp, err := daemonCall(daemonRequest{
+			Op:      "spawn",
+			ID:      lc.ID,
+			Command: &pl.Command,
+			Info: &daemonSessionInfo{
+				Backend:  pl.Backend.Name(),
+				Worktree: pl.Worktree,
+				Branch:   pl.Branch,
+
+				SessionDir:     pl.SessionDir,
+				ConversationID: pl.ConversationID,
+			},
+		})
+		if err != nil {
+			return AgentExitedMsg{ID: lc.ID, Err: err}
+		}
+
+		// Another TUI spawned the agent since the check above and the
+		// daemon handed back that session: drop the one prepared here and
+		// take on the running one's.
+		backend, handoff := pl.Backend, lc.HandoffContext
+		if sess := resp.Session; sess.SessionDir != pl.SessionDir {
+			handoff = ""
+			os.RemoveAll(pl.SessionDir)
+			if backend, err = cfg.Backend(sess.Backend); err != nil {
+				conn.Close()
+				return AgentExitedMsg{ID: lc.ID, Err: err}
+			}
+		} else {
+			go saveAuditPrompt(pl.SessionDir, lc.ID, pl.Command.Binary, pl.Prompt, pl.Command.Args)
+		}
+
+		return AgentStartedMsg{
+			ID:       lc.ID,
+			Proc:     &daemonProcess{conn: conn, br: br},
+			Emulator: vt.NewSafeEmulator(lc.Cols, lc.Rows),
+			Worktree: resp.Session.Worktree,
+			Branch:   resp.Session.Branch,
+			Backend:  backend,
+
+			SessionDir:     resp.Session.SessionDir,
+			ConversationID: resp.Session.ConversationID,
+			Handoff:        handoff,
+		}
+	}
+}
+
+// attachAgent reconnects to an agent already running in the daemon.
+func attachAgent(cfg *ForgeConfig, id string, cols, rows int) tea.Cmd {
+	return func() tea.Msg {
+		conn, br, resp, err := daemonCall(daemonRequest{Op: "attach", ID: id})
+		if err != nil {
+			return AgentExitedMsg{ID: id, Err: err}
+		}
+		backend, err := cfg.Backend(resp.Session.Backend)
+		if err != nil {
+			conn.Close()
+			return AgentExitedMsg{ID: id, Err: err}
+		}
+		proc := &daemonProcess{conn: conn, br: br}
+		proc.Resize(cols, rows)
+		return AgentStartedMsg{
+			ID:       id,
+			Proc:     proc,
+			Emulator: vt.NewSafeEmulator(cols, rows),
+			Worktree: resp.Session.Worktree,
+			Branch:   resp.Session.Branch,
+			Backend:  backend,
+
+			SessionDir:     resp.Session.SessionDir,
+			ConversationID: resp.Session.ConversationID,
+		}
+	}
+}
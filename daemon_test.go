@@ -0,0 +1,301 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/x/vt"
+)
+
+func TestFrameRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	resize := []byte{0, 120, 0, 40}
+	frames := []struct {
+		typ     byte
+		payload []byte
+	}{
+		{frameData, []byte("hello\r\n")},
+		{frameResize, resize},
+		{frameExit, nil},
+		{frameData, bytes.Repeat([]byte("x"), 70000)},
+	}
+	for _, f := range frames {
+		if err := writeFrame(&buf, f.typ, f.payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := buf.Len(); got != 4*5+7+4+0+70000 {
+		t.Errorf("%d bytes written", got)
+	}
+	for i, want := range frames {
+		typ, payload, err := readFrame(&buf)
+		if err != nil || typ != want.typ || !bytes.Equal(payload, want.payload) {
+			t.Errorf("frame %d: %c %d bytes, %v", i, typ, len(payload), err)
+		}
+	}
+	if _, _, err := readFrame(&buf); err != io.EOF {
+		t.Errorf("after the last frame: %v, want EOF", err)
+	}
+
+	writeFrame(&buf, frameData, []byte("cut short"))
+	buf.Truncate(buf.Len() - 3)
+	if _, _, err := readFrame(&buf); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("truncated frame: %v", err)
+	}
+
+	hdr := []byte{frameData, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(hdr[1:], maxFrameSize+1)
+	if _, _, err := readFrame(bytes.NewReader(hdr)); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("oversized frame: %v", err)
+	}
+}
+
+// daemonConn is a client connected to a forgeDaemon over a socketpair.
+// Like an attached TUI, it feeds output to an emulator that answers the
+// agent's terminal queries.
+type daemonConn struct {
+	t    *testing.T
+	conn net.Conn
+	br   *bufio.Reader
+	em   *vt.SafeEmulator
+	seen string // data frames so far
+}
+
+// dialDaemon connects to d over a socketpair and sends req.
+func dialDaemon(t *testing.T, d *forgeDaemon, req daemonRequest) (*daemonConn, daemonResponse) {
+	t.Helper()
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conns := make([]net.Conn, 2)
+	for i, fd := range fds {
+		f := os.NewFile(uintptr(fd), "socketpair")
+		if conns[i], err = net.FileConn(f); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+	}
+	go d.handleConn(conns[1])
+	c := &daemonConn{t: t, conn: conns[0], br: bufio.NewReader(conns[0]), em: vt.NewSafeEmulator(80, 24)}
+	t.Cleanup(func() {
+		c.conn.Close()
+		closeEmulator(c.em)
+	})
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, err := c.em.Read(buf)
+			if n > 0 {
+				writeFrame(c.conn, frameData, buf[:n])
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	data, _ := json.Marshal(req)
+	if _, err := c.conn.Write(append(data, '\n')); err != nil {
+		t.Fatal(err)
+	}
+	c.conn.SetReadDeadline(time.Now().Add(harnessWait))
+	line, err := c.br.ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("%s: reading response: %v", req.Op, err)
+	}
+	var resp daemonResponse
+	if err := json.Unmarshal(line, &resp); err != nil {
+		t.Fatalf("%s: %v\n%s", req.Op, err, line)
+	}
+	return c, resp
+}
+
+// waitFor reads frames until the output contains text. It returns the
+// exit frame's payload if the session ends first.
+func (c *daemonConn) waitFor(text string) (exit string, exited bool) {
+	c.t.Helper()
+	for !strings.Contains(c.seen, text) {
+		typ, payload, err := readFrame(c.br)
+		if err != nil {
+			c.t.Fatalf("waiting for %q: %v\noutput so far: %q", text, err, c.seen)
+		}
+		switch typ {
+		case frameData:
+			c.seen += string(payload)
+			c.em.Write(payload)
+		case frameExit:
+			return string(payload), true
+		}
+	}
+	return "", false
+}
+
+func (c *daemonConn) send(typ byte, payload []byte) {
+	c.t.Helper()
+	if err := writeFrame(c.conn, typ, payload); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// fakeAgentCommand runs the scripted fake agent REPL.
+func fakeAgentCommand(t *testing.T) *AgentCommand {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &AgentCommand{Binary: exe, Args: []string{"fake-agent"}, Dir: t.TempDir(),
+		Env: append(os.Environ(), "TERM=xterm-256color"), Cols: 80, Rows: 24}
+}
+
+func TestDaemonSpawnAttach(t *testing.T) {
+	d := &forgeDaemon{sessions: make(map[string]*daemonSession)}
+	t.Cleanup(d.stopAll)
+
+	first, resp := dialDaemon(t, d, daemonRequest{Op: "spawn", ID: "p-0-Builder", Command: fakeAgentCommand(t),
+		Info: &daemonSessionInfo{ID: "ignored", Backend: "claude", SessionDir: "/sessions/1"}})
+	s := resp.Session
+	if resp.Error != "" || s == nil || s.ID != "p-0-Builder" || s.Pid == 0 || s.Backend != "claude" || s.SessionDir != "/sessions/1" {
+		t.Fatalf("spawn response %+v", resp)
+	}
+	first.waitFor(fakeAgentReady)
+	first.send(frameData, []byte("hello\r"))
+	first.waitFor("hello")
+
+	// A second TUI attaches to the same session and sees the screen so far
+	second, resp := dialDaemon(t, d, daemonRequest{Op: "attach", ID: "p-0-Builder"})
+	if resp.Session == nil || resp.Session.Pid != s.Pid {
+		t.Fatalf("attach response %+v", resp)
+	}
+	second.waitFor(fakeAgentReady)
+
+	// Spawning a live session reuses it rather than starting another agent
+	third, resp := dialDaemon(t, d, daemonRequest{Op: "spawn", ID: "p-0-Builder", Command: fakeAgentCommand(t),
+		Info: &daemonSessionInfo{Backend: "codex", SessionDir: "/sessions/2"}})
+	if resp.Session == nil || resp.Session.Pid != s.Pid || resp.Session.SessionDir != "/sessions/1" {
+		t.Errorf("second spawn %+v, want the running session", resp.Session)
+	}
+	third.conn.Close()
+
+	// Input from either client reaches the agent; output reaches both
+	second.send(frameData, []byte("from second\r"))
+	first.waitFor("from second")
+
+	if _, resp := dialDaemon(t, d, daemonRequest{Op: "list"}); len(resp.Sessions) != 1 || resp.Sessions[0].Exited {
+		t.Errorf("list %+v", resp.Sessions)
+	}
+	if _, resp := dialDaemon(t, d, daemonRequest{Op: "attach", ID: "ghost"}); resp.Error != "no such session" {
+		t.Errorf("attach to an unknown session: %+v", resp)
+	}
+
+	second.send(frameData, []byte("exit 3\r"))
+	for _, c := range []*daemonConn{first, second} {
+		if exit, exited := c.waitFor("never printed"); !exited || exit != "exit status 3" {
+			t.Errorf("exit frame %q (%v)", exit, exited)
+		}
+	}
+
+	// The exit is kept for the next TUI to attach, then forgotten
+	_, resp = dialDaemon(t, d, daemonRequest{Op: "list"})
+	if len(resp.Sessions) != 1 || !resp.Sessions[0].Exited {
+		t.Fatalf("list after exit %+v", resp.Sessions)
+	}
+	late, _ := dialDaemon(t, d, daemonRequest{Op: "attach", ID: "p-0-Builder"})
+	if exit, exited := late.waitFor("never printed"); !exited || exit != "exit status 3" {
+		t.Errorf("late attach: exit %q (%v)", exit, exited)
+	}
+	if _, resp := dialDaemon(t, d, daemonRequest{Op: "list"}); len(resp.Sessions) != 0 {
+		t.Errorf("exited session still listed after its exit was delivered: %+v", resp.Sessions)
+	}
+}
+
+func TestDaemonRejectsBadRequests(t *testing.T) {
+	d := &forgeDaemon{sessions: make(map[string]*daemonSession)}
+	for _, tt := range []struct {
+		req  daemonRequest
+		want string
+	}{
+		{daemonRequest{Op: "spawn", ID: "a"}, "spawn requires id and command"},
+		{daemonRequest{Op: "kill", ID: "a"}, "no such session"},
+		{daemonRequest{Op: "fork"}, `unknown op "fork"`},
+	} {
+		if _, resp := dialDaemon(t, d, tt.req); resp.Error != tt.want {
+			t.Errorf("%s: error %q, want %q", tt.req.Op, resp.Error, tt.want)
+		}
+	}
+}
+
+// serveDaemon runs a forge daemon on daemonSocketPath() until the test
+// ends. HOME must already be a throwaway directory.
+func serveDaemon(t *testing.T) {
+	t.Helper()
+	if err := ensureForgeDir(); err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("unix", daemonSocketPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &forgeDaemon{sessions: make(map[string]*daemonSession)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go d.handleConn(conn)
+		}
+	}()
+	t.Cleanup(func() {
+		ln.Close()
+		d.stopAll()
+	})
+}
+
+// spawnInDaemon starts the fake agent in the daemon as id.
+func spawnInDaemon(t *testing.T, id string, info daemonSessionInfo) {
+	t.Helper()
+	conn, _, _, err := daemonCall(daemonRequest{Op: "spawn", ID: id, Command: fakeAgentCommand(t), Info: &info})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+}
+
+func TestDaemonLauncherReattachesBeforePreparing(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	serveDaemon(t)
+	spawnInDaemon(t, "p-0-Builder", daemonSessionInfo{Backend: "claude", SessionDir: "/sessions/1", Worktree: "/wt/builder"})
+
+	// A backend that does not exist fails prepareLaunch, so only a
+	// reattach can succeed
+	msg := DaemonLauncher{}.Launch(&ForgeConfig{}, LaunchConfig{ID: "p-0-Builder", Backend: "no-such-backend", Cols: 80, Rows: 24})()
+	started, ok := msg.(AgentStartedMsg)
+	if !ok {
+		t.Fatalf("launch returned %#v, want a reattach", msg)
+	}
+	defer started.Proc.Close()
+	if started.Backend.Name() != "claude" || started.SessionDir != "/sessions/1" || started.Worktree != "/wt/builder" {
+		t.Errorf("reattached with backend %s, session %s, worktree %s",
+			started.Backend.Name(), started.SessionDir, started.Worktree)
+	}
+	if entries, _ := os.ReadDir(sessionsDir()); len(entries) != 0 {
+		t.Errorf("reattach prepared a session directory: %v", entries)
+	}
+
+	msg = DaemonLauncher{}.Launch(&ForgeConfig{}, LaunchConfig{ID: "p-1-Scout", Backend: "no-such-backend"})()
+	if exited, ok := msg.(AgentExitedMsg); !ok || exited.Err == nil {
+		t.Errorf("launch of a new agent returned %#v, want prepareLaunch's error", msg)
+	}
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// ── UI Harness ────────────────────────────────────────────────────
+//
+// Harness drives a Model the way tea.Program does, minus the terminal:
+// messages go through Update, returned commands run on goroutines and
+// their results are fed back in. View() is ANSI-stripped for assertions.
+// Paired with ScriptedLauncher it runs real PTY flows end to end.
+
+// harnessWait bounds every wait; generous for -race builds.
+const harnessWait = 10 * time.Second
+
+// Harness is a headless driver for Model.
+type Harness struct {
+	t     *testing.T
+	model tea.Model
+	msgs  chan tea.Msg
+	quit  bool
+}
+
+// newHarness builds a Model against ScriptedLauncher in a throwaway HOME,
+// so tests never touch the real forge or agent directories. parties are
+// saved before the model loads, with a non-git project directory. Agents
+// are stopped when the test ends.
+func newHarness(t *testing.T, parties ...PartyFile) *Harness {
+	t.Helper()
+	sandbox := t.TempDir()
+	t.Setenv("HOME", sandbox)
+	project := filepath.Join(sandbox, "project")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(project)
+
+	oldLauncher, oldClipboard := DefaultLauncher, clipboardOut
+	DefaultLauncher, clipboardOut = ScriptedLauncher{}, io.Discard
+	t.Cleanup(func() { DefaultLauncher, clipboardOut = oldLauncher, oldClipboard })
+
+	if err := ensureForgeDir(); err != nil {
+		t.Fatal(err)
+	}
+	for _, pf := range parties {
+		pf.Project = project
+		if err := SaveParty(&pf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m, err := initialModel()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := &Harness{t: t, model: m, msgs: make(chan tea.Msg, 64)}
+	h.run(m.Init())
+	h.Send(tea.WindowSizeMsg{Width: 160, Height: 50})
+	t.Cleanup(func() {
+		h.Model().stopAllAgents()
+		h.Settle(100 * time.Millisecond)
+	})
+	return h
+}
+
+func (h *Harness) run(cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+	go func() { h.msgs <- cmd() }()
+}
+
+// Send delivers one message to Update and schedules the returned command.
+func (h *Harness) Send(msg tea.Msg) {
+	switch msg := msg.(type) {
+	case nil:
+		return
+	case tea.BatchMsg:
+		for _, cmd := range msg {
+			h.run(cmd)
+		}
+		return
+	case tea.QuitMsg:
+		h.quit = true
+		return
+	}
+	var cmd tea.Cmd
+	h.model, cmd = h.model.Update(msg)
+	h.run(cmd)
+}
+
+// Keys sends named keys ("enter", "esc", "tab", "up", "ctrl+c", " ") or
+// single characters.
+func (h *Harness) Keys(keys ...string) {
+	for _, k := range keys {
+		h.Send(harnessKey(k))
+	}
+}
+
+// Type sends text one rune at a time; '\r' and '\n' become enter.
+func (h *Harness) Type(text string) {
+	for _, r := range text {
+		if r == '\r' || r == '\n' {
+			h.Send(tea.KeyMsg{Type: tea.KeyEnter})
+			continue
+		}
+		h.Send(harnessKey(string(r)))
+	}
+}
+
+var harnessKeyTypes = map[string]tea.KeyType{
+	"enter":     tea.KeyEnter,
+	"esc":       tea.KeyEscape,
+	"tab":       tea.KeyTab,
+	"shift+tab": tea.KeyShiftTab,
+	"backspace": tea.KeyBackspace,
+	"up":        tea.KeyUp,
+	"down":      tea.KeyDown,
+	"left":      tea.KeyLeft,
+	"right":     tea.KeyRight,
+	" ":         tea.KeySpace,
+	"ctrl+c":    tea.KeyCtrlC,
+}
+
+func harnessKey(k string) tea.KeyMsg {
+	if t, ok := harnessKeyTypes[k]; ok {
+		return tea.KeyMsg{Type: t}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+}
+
+// Model returns the current model state.
+func (h *Harness) Model() Model { return h.model.(Model) }
+
+// View returns the rendered screen with escape sequences stripped.
+func (h *Harness) View() string { return ansi.Strip(h.model.View()) }
+
+// Quit reports whether the model has returned tea.Quit.
+func (h *Harness) Quit() bool { return h.quit }
+
+// Settle processes pending messages for d.
+func (h *Harness) Settle(d time.Duration) {
+	timeout := time.After(d)
+	for {
+		select {
+		case msg := <-h.msgs:
+			h.Send(msg)
+		case <-timeout:
+			return
+		}
+	}
+}
+
+// WaitUntil processes messages until cond holds, failing the test if it
+// doesn't within harnessWait.
+func (h *Harness) WaitUntil(what string, cond func(Model) bool) {
+	h.t.Helper()
+	deadline := time.After(harnessWait)
+	for !cond(h.Model()) {
+		select {
+		case msg := <-h.msgs:
+			h.Send(msg)
+		case <-deadline:
+			h.t.Fatalf("timed out waiting for %s; screen:\n%s", what, h.View())
+		}
+	}
+}
+
+// WaitFor processes messages until the screen contains text.
+func (h *Harness) WaitFor(text string) {
+	h.t.Helper()
+	h.WaitUntil(fmt.Sprintf("%q on screen", text), func(Model) bool {
+		return strings.Contains(h.View(), text)
+	})
+}
+
+// ── Core Flows ────────────────────────────────────────────────────
+
+func TestWizardCreatesPartyAndStartsIt(t *testing.T) {
+	h := newHarness(t)
+	if h.Model().wizard == nil {
+		t.Fatal("wizard not shown with no parties")
+	}
+	h.Type("smoke\r")
+	h.Keys("enter")      // use the current directory
+	h.Keys(" ", "enter") // pick the first agent
+	h.Keys("enter")      // finalize
+	h.WaitFor(fakeAgentReady)
+	if _, err := os.Stat(partyPath("smoke")); err != nil {
+		t.Errorf("party file not saved: %v", err)
+	}
+}
+
+func TestInsertModeForwardsInput(t *testing.T) {
+	h := newHarness(t, PartyFile{Name: "solo", Slots: []PartySlotConfig{{Agent: "Builder"}}})
+	h.WaitFor(fakeAgentReady)
+	h.Keys("i")
+	h.Type("hello forge\r")
+	h.WaitFor("echo: hello forge")
+	h.Keys("esc")
+	if h.Model().mode != ModeNormal {
+		t.Error("esc did not leave insert mode")
+	}
+}
+
+func TestCheckoutRatesAndHandsOff(t *testing.T) {
+	h := newHarness(t, PartyFile{Name: "duo", Slots: []PartySlotConfig{
+		{Agent: "Planner"}, {Agent: "Builder"},
+	}})
+	h.WaitUntil("both agents running", func(m Model) bool {
+		p := m.party()
+		return p.Slots[0].Status == "running" && p.Slots[1].Status == "running"
+	})
+	h.WaitFor(fakeAgentReady)
+	h.Keys("i")
+	h.Type("exit\r")
+	h.WaitFor("How did it go?")
+	h.Keys("2") // normal
+	h.WaitFor("Handoff from Planner")
+	h.Keys("enter")
+
+	m := h.Model()
+	if m.mode != ModeNormal {
+		t.Fatal("checkout did not finish")
+	}
+	if ctx := m.party().Slots[1].HandoffContext; !strings.Contains(ctx, fakeAgentReady) {
+		t.Errorf("handoff context missing Planner's output: %q", ctx)
+	}
+	if e := m.roster.Agents["Planner"]; e == nil || e.XP != 20 {
+		t.Error("Planner XP not awarded")
+	}
+}
+
+func TestHandoffKeptUntilLaunchSucceeds(t *testing.T) {
+	h := newHarness(t, PartyFile{Name: "duo",
+		Slots: []PartySlotConfig{{Agent: "Builder"}},
+		Bench: []PartySlotConfig{{Agent: "Scout"}},
+	})
+	h.WaitFor(fakeAgentReady)
+	scout := h.Model().party().Bench[0]
+	scout.HandoffContext = "## Handoff from Builder\n"
+
+	DefaultLauncher = ScriptedLauncher{Binary: filepath.Join(t.TempDir(), "missing")}
+	h.run(h.Model().startInstance(scout, false))
+	h.WaitUntil("failed launch", func(Model) bool { return scout.Status == "exited" })
+	if scout.HandoffContext == "" {
+		t.Fatal("a failed launch consumed the handoff")
+	}
+	if st, _ := LoadPartyState("duo"); st.Agents["Scout"].HandoffContext == "" {
+		t.Error("handoff not saved after the failed launch")
+	}
+
+	DefaultLauncher = ScriptedLauncher{}
+	h.run(h.Model().startInstance(scout, false))
+	h.WaitUntil("Scout running", func(Model) bool { return scout.Status == "running" })
+	if scout.HandoffContext != "" {
+		t.Errorf("handoff still queued after it was delivered: %q", scout.HandoffContext)
+	}
+}
+
+func TestSwapMovesBenchAgentIntoSlot(t *testing.T) {
+	h := newHarness(t, PartyFile{Name: "swap",
+		Slots: []PartySlotConfig{{Agent: "Builder"}},
+		Bench: []PartySlotConfig{{Agent: "Scout"}},
+	})
+	h.WaitFor(fakeAgentReady)
+	h.Keys(" ")
+	if h.Model().mode != ModeSwap {
+		t.Fatal("space did not open swap mode")
+	}
+	h.Keys("enter")
+	p := h.Model().party()
+	if p.Slots[0].AgentName != "Scout" || p.Bench[0].AgentName != "Builder" {
+		t.Error("swap did not exchange slot and bench")
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+// ── Headless Output ───────────────────────────────────────────────
+//
+// In print mode a backend's stdout is either plain text (the answer) or,
+// with headless_format: stream-json, one JSON event per line as claude
+// emits with --output-format stream-json. headlessCapture consumes either
+// and exposes the final result and token usage as the agent runs.
+
+// Headless output formats for BackendConfig.HeadlessFormat.
+const (
+	HeadlessFormatText       = "text"
+	HeadlessFormatStreamJSON = "stream-json"
+)
+
+// tokenUsage is what an agent consumed. Estimated is set when the backend
+// doesn't report usage and the counts come from countTokens instead.
+type tokenUsage struct {
+	Input      int     `json:"input"`
+	Output     int     `json:"output"`
+	CacheRead  int     `json:"cache_read,omitempty"`
+	CacheWrite int     `json:"cache_write,omitempty"`
+	CostUSD    float64 `json:"cost_usd,omitempty"`
+	Estimated  bool    `json:"estimated,omitempty"`
+}
+
+// Total is every token processed, cached or not.
+func (u tokenUsage) Total() int { return u.Input + u.Output + u.CacheRead + u.CacheWrite }
+
+// streamUsage is the usage object in claude's stream-json events.
+type streamUsage struct {
+	InputTokens         int `json:"input_tokens"`
+	OutputTokens        int `json:"output_tokens"`
+	CacheReadTokens     int `json:"cache_read_input_tokens"`
+	CacheCreationTokens int `json:"cache_creation_input_tokens"`
+}
+
+func (u streamUsage) tokenUsage() tokenUsage {
+	return tokenUsage{
+		Input:      u.InputTokens,
+		Output:     u.OutputTokens,
+		CacheRead:  u.CacheReadTokens,
+		CacheWrite: u.CacheCreationTokens,
+	}
+}
+
+type streamEvent struct {
+	Type    string `json:"type"`
+	Message struct {
+		ID      string `json:"id"`
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		Usage *streamUsage `json:"usage"`
+	} `json:"message"`
+	Result       string       `json:"result"`
+	IsError      bool         `json:"is_error"`
+	Usage        *streamUsage `json:"usage"`
+	TotalCostUSD float64      `json:"total_cost_usd"`
+}
+
+// headlessCapture is an io.Writer for a print-mode agent's stdout. Safe
+// for concurrent use: Usage may be polled while the agent runs.
+type headlessCapture struct {
+	format string
+	onText func(text string) // called with human-readable output as it arrives; may be nil
+
+	mu       sync.Mutex
+	line     []byte
+	text     strings.Builder         // readable transcript (assistant text in stream-json)
+	result   string                  // final answer from the result event
+	turns    map[string]*streamUsage // per assistant message, as usage is updated in place
+	final    *tokenUsage             // from the result event, once seen
+	resultOK bool
+	isError  bool
+}
+
+func newHeadlessCapture(format string, onText func(string)) *headlessCapture {
+	return &headlessCapture{format: format, onText: onText, turns: make(map[string]*streamUsage)}
+}
+
+func (c *headlessCapture) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	if c.format != HeadlessFormatStreamJSON {
+		c.text.Write(p)
+		c.mu.Unlock()
+		if c.onText != nil {
+			c.onText(string(p))
+		}
+		return len(p), nil
+	}
+
+	var texts []string
+	c.line = append(c.line, p...)
+	for {
+		i := bytes.IndexByte(c.line, '\n')
+		if i < 0 {
+			break
+		}
+		if t := c.handleLine(c.line[:i]); t != "" {
+			texts = append(texts, t)
+		}
+		c.line = c.line[i+1:]
+	}
+	c.mu.Unlock()
+	if c.onText != nil {
+		for _, t := range texts {
+			c.onText(t)
+		}
+	}
+	return len(p), nil
+}
+
+// Flush processes a trailing event left without a newline when the
+// process exited.
+func (c *headlessCapture) Flush() {
+	c.mu.Lock()
+	var text string
+	if len(c.line) > 0 {
+		text = c.handleLine(c.line)
+		c.line = nil
+	}
+	c.mu.Unlock()
+	if text != "" && c.onText != nil {
+		c.onText(text)
+	}
+}
+
+// handleLine parses one stream-json event and returns any readable text.
+// Lines that aren't JSON are passed through as text.
+func (c *headlessCapture) handleLine(line []byte) string {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return ""
+	}
+	var ev streamEvent
+	if err := json.Unmarshal(line, &ev); err != nil {
+		c.text.Write(line)
+		c.text.WriteByte('\n')
+		return string(line) + "\n"
+	}
+	switch ev.Type {
+	case "assistant":
+		if ev.Message.Usage != nil {
+			c.turns[ev.Message.ID] = ev.Message.Usage
+		}
+		var b strings.Builder
+		for _, part := range ev.Message.Content {
+			if part.Type == "text" && part.Text != "" {
+				b.WriteString(part.Text)
+				b.WriteByte('\n')
+			}
+		}
+		c.text.WriteString(b.String())
+		return b.String()
+	case "result":
+		c.result = ev.Result
+		c.resultOK = true
+		c.isError = ev.IsError
+		if ev.Usage != nil {
+			u := ev.Usage.tokenUsage()
+			u.CostUSD = ev.TotalCostUSD
+			c.final = &u
+		}
+	}
+	return ""
+}
+
+// Result is the agent's final answer: the result event in stream-json,
+// otherwise everything it printed.
+func (c *headlessCapture) Result() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.resultOK {
+		return strings.TrimSpace(c.result)
+	}
+	return strings.TrimSpace(c.text.String())
+}
+
+// Text is the readable transcript so far.
+func (c *headlessCapture) Text() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.text.String()
+}
+
+// ReportedError is set when a stream-json result event flagged an error.
+func (c *headlessCapture) ReportedError() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.isError
+}
+
+// Usage returns the tokens consumed so far. Without backend-reported usage
+// it estimates from the prompt and the output.
+func (c *headlessCapture) Usage(prompt string) tokenUsage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.final != nil {
+		return *c.final
+	}
+	if len(c.turns) > 0 {
+		var u tokenUsage
+		for _, t := range c.turns {
+			tu := t.tokenUsage()
+			u.Input += tu.Input
+			u.Output += tu.Output
+			u.CacheRead += tu.CacheRead
+			u.CacheWrite += tu.CacheWrite
+		}
+		return u
+	}
+	return tokenUsage{
+		Input:     countTokens(prompt),
+		Output:    countTokens(c.text.String()),
+		Estimated: true,
+	}
+}
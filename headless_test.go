@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// streamJSON is a claude stream-json run: usage of the first message is
+// updated in place, and the result event carries the final totals.
+const streamJSON = `{"type":"system","subtype":"init","session_id":"s1"}
+{"type":"assistant","message":{"id":"m1","content":[{"type":"text","text":"Reading the code."}],"usage":{"input_tokens":100,"output_tokens":5}}}
+{"type":"assistant","message":{"id":"m1","content":[{"type":"tool_use","name":"Read"}],"usage":{"input_tokens":100,"output_tokens":20,"cache_read_input_tokens":50}}}
+{"type":"user","message":{"content":[{"type":"tool_result"}]}}
+{"type":"assistant","message":{"id":"m2","content":[{"type":"text","text":"Fixed it."}],"usage":{"input_tokens":300,"output_tokens":10}}}
+`
+
+const streamResult = `{"type":"result","subtype":"success","is_error":false,"result":"Fixed the off-by-one.\n","total_cost_usd":0.25,"usage":{"input_tokens":400,"output_tokens":30,"cache_read_input_tokens":50,"cache_creation_input_tokens":7}}`
+
+func TestHeadlessCaptureStreamJSON(t *testing.T) {
+	var live []string
+	c := newHeadlessCapture(HeadlessFormatStreamJSON, func(s string) { live = append(live, s) })
+
+	// Split mid-event, as pipe reads do
+	c.Write([]byte(streamJSON[:90]))
+	c.Write([]byte(streamJSON[90:]))
+
+	if got := c.Text(); got != "Reading the code.\nFixed it.\n" {
+		t.Errorf("text %q", got)
+	}
+	if strings.Join(live, "") != c.Text() {
+		t.Errorf("onText got %q", live)
+	}
+	u := c.Usage("prompt")
+	want := tokenUsage{Input: 400, Output: 30, CacheRead: 50}
+	if u != want {
+		t.Errorf("usage before result %+v, want the sum of the latest per-message usage %+v", u, want)
+	}
+
+	// The result event arrives without a trailing newline
+	c.Write([]byte(streamResult))
+	if c.Result() != "Reading the code.\nFixed it." {
+		t.Errorf("result %q before the event was flushed", c.Result())
+	}
+	c.Flush()
+	if got := c.Result(); got != "Fixed the off-by-one." {
+		t.Errorf("result %q", got)
+	}
+	u = c.Usage("prompt")
+	want = tokenUsage{Input: 400, Output: 30, CacheRead: 50, CacheWrite: 7, CostUSD: 0.25}
+	if u != want {
+		t.Errorf("final usage %+v, want %+v", u, want)
+	}
+	if c.ReportedError() {
+		t.Error("successful result reported as an error")
+	}
+}
+
+func TestHeadlessCaptureStreamJSONErrorAndNoise(t *testing.T) {
+	c := newHeadlessCapture(HeadlessFormatStreamJSON, nil)
+	c.Write([]byte("warning: not json\n" + `{"type":"result","is_error":true,"result":"API error"}` + "\n"))
+	if !c.ReportedError() {
+		t.Error("is_error not reported")
+	}
+	if c.Text() != "warning: not json\n" {
+		t.Errorf("non-JSON line not passed through: %q", c.Text())
+	}
+	if c.Result() != "API error" {
+		t.Errorf("result %q", c.Result())
+	}
+}
+
+func TestHeadlessCaptureText(t *testing.T) {
+	c := newHeadlessCapture(HeadlessFormatText, nil)
+	c.Write([]byte("All done.\n\n"))
+	if c.Result() != "All done." {
+		t.Errorf("result %q", c.Result())
+	}
+	u := c.Usage("hello world")
+	if !u.Estimated || u.Input != countTokens("hello world") || u.Output != countTokens("All done.\n\n") {
+		t.Errorf("usage %+v, want estimated from prompt and output", u)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -75,6 +76,12 @@ func initialModel() (Model, error) {
 		selectedAgent: 0,
 	}
 
+	// Run agents inside the forge daemon when one is serving
+	if daemonAvailable() {
+		DefaultLauncher = DaemonLauncher{}
+		m.daemon = true
+	}
+
 	for _, name := range partyNames {
 		pf, err := LoadParty(name)
 		if err != nil {
@@ -86,6 +93,18 @@ func initialModel() (Model, error) {
 
 	m.rebuildAgentIndex()
 
+	// Mark agents still running in the daemon for reattach on first resize
+	if m.daemon {
+		if sessions, err := listDaemonSessions(); err == nil {
+			for _, s := range sessions {
+				if inst := m.agentByID(s.ID); inst != nil {
+					inst.Status = "attaching"
+					inst.Task = "Reattaching..."
+				}
+			}
+		}
+	}
+
 	if len(m.parties) == 1 {
 		m.activeParty = 0
 		m.autoStartPending = true
@@ -106,9 +125,12 @@ func initialModel() (Model, error) {
 }
 
 func main() {
-	// Check for subcommands
-	if len(os.Args) > 1 && os.Args[1] == "raid" {
-		if err := runRaid(os.Args[2:]); err != nil {
+	// Any argument selects a subcommand; none opens the TUI
+	if len(os.Args) > 1 {
+		if err := runCLI(os.Args[1:]); err != nil {
+			if errors.Is(err, errHelp) {
+				return
+			}
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
@@ -127,6 +149,13 @@ func main() {
 		tea.WithMouseCellMotion(),
 	)
 
+	// Control API is best-effort: another instance may already hold the socket
+	if stop, err := startAPI(model.config.APIListen, tuiTarget{p}); err == nil {
+		defer stop()
+	} else {
+		go p.Send(apiWarningMsg(apiUnavailable(err)))
+	}
+
 	if _, err := p.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
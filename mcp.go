@@ -0,0 +1,461 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ── Forge MCP Server ──────────────────────────────────────────────
+//
+// Every agent launched on a backend with mcp_config_flag gets a "forge"
+// MCP server: `agent-tui mcp --agent <id>`, spoken to over stdio. Its
+// tools let agents message each other, hand off work, set their task line,
+// list the party and ask for a review. Each call is forwarded to the
+// control API of the TUI or raid that launched the agent, which updates
+// the agent's AgentInstance (or raid agent) so the change shows up in the
+// UI and over the API.
+
+const (
+	mcpServerName      = "forge"
+	mcpConfigFileName  = "mcp.json"
+	mcpProtocolVersion = "2024-11-05" // answered when the client asks for a version we don't know
+)
+
+// mcpProtocolVersions are the MCP revisions this server speaks. Nothing it
+// uses differs between them.
+var mcpProtocolVersions = []string{"2025-06-18", "2025-03-26", mcpProtocolVersion}
+
+// controlAddr is the address the control API is serving on, "" if it
+// isn't. Agents are only given the forge server when it is set.
+var controlAddr string
+
+// forgeMCPArgs configures a launch of agentID on b with the forge MCP
+// server: it returns the args that load its config from sessionDir and
+// tools with the server's tools allowed. The config file is only written
+// if write is set, so a dry run can describe it. Backends without
+// mcp_config_flag, or an empty addr, get no server.
+func forgeMCPArgs(b *BackendConfig, tools []string, addr, agentID, sessionDir string, write bool) ([]string, []string, error) {
+	if b.MCPConfigFlag == "" || addr == "" {
+		return nil, tools, nil
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, tools, nil
+	}
+	path := filepath.Join(sessionDir, mcpConfigFileName)
+	if write {
+		cfg := map[string]any{
+			"mcpServers": map[string]any{
+				mcpServerName: map[string]any{
+					"command": exe,
+					"args":    []string{"mcp", "--agent", agentID, "--api", addr},
+				},
+			},
+		}
+		data, _ := json.MarshalIndent(cfg, "", "  ")
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return nil, nil, fmt.Errorf("writing MCP config: %w", err)
+		}
+	}
+	if b.MCPTools != "" {
+		tools = append(append([]string{}, tools...), b.MCPTools)
+	}
+	return appendFlag(nil, b.MCPConfigFlag, path), tools, nil
+}
+
+// ── mcp Subcommand ────────────────────────────────────────────────
+
+const mcpUsage = "--agent <id> [--api <addr>]"
+
+// runMCP serves the forge tools for one agent on stdin/stdout.
+func runMCP(args []string) error {
+	var agentID, addr string
+	fs := newFlagSet("mcp", mcpUsage)
+	fs.StringVar(&agentID, "agent", "", "agent ID the tools act for (required)")
+	fs.StringVar(&addr, "api", "", "control API address (default: the forge socket)")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	if agentID == "" {
+		fs.Usage()
+		return fmt.Errorf("mcp: --agent is required")
+	}
+	s := &mcpServer{agent: agentID, api: newAPIClient(addr)}
+	return s.serve(os.Stdin, os.Stdout)
+}
+
+// ── JSON-RPC ──────────────────────────────────────────────────────
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"` // absent for notifications
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// JSON-RPC error codes.
+const (
+	rpcParseError     = -32700
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+)
+
+type mcpServer struct {
+	agent string
+	api   *apiClient
+}
+
+// serve reads newline-delimited JSON-RPC messages from r until EOF.
+func (s *mcpServer) serve(r io.Reader, w io.Writer) error {
+	in := bufio.NewReader(r)
+	enc := json.NewEncoder(w)
+	for {
+		line, err := in.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var req rpcRequest
+			if jerr := json.Unmarshal(line, &req); jerr != nil {
+				enc.Encode(rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"),
+					Error: &rpcError{rpcParseError, jerr.Error()}})
+			} else if req.ID != nil {
+				result, rerr := s.handle(req)
+				enc.Encode(rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result, Error: rerr})
+			}
+			// Notifications (initialized, cancelled) need no answer
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *mcpServer) handle(req rpcRequest) (any, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		json.Unmarshal(req.Params, &params)
+		version := mcpProtocolVersion
+		for _, v := range mcpProtocolVersions {
+			if v == params.ProtocolVersion {
+				version = v
+			}
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]string{"name": mcpServerName, "version": "1.0.0"},
+			"instructions":    mcpInstructions,
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		tools := make([]map[string]any, len(forgeTools))
+		for i, t := range forgeTools {
+			tools[i] = map[string]any{
+				"name":        t.name,
+				"description": t.description,
+				"inputSchema": t.schema(),
+			}
+		}
+		return map[string]any{"tools": tools}, nil
+	case "tools/call":
+		var params struct {
+			Name      string         `json:"name"`
+			Arguments map[string]any `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{rpcInvalidParams, err.Error()}
+		}
+		tool := findForgeTool(params.Name)
+		if tool == nil {
+			return nil, &rpcError{rpcInvalidParams, "unknown tool " + params.Name}
+		}
+		text, err := s.call(tool, params.Arguments)
+		if err != nil {
+			return toolResult(err.Error(), true), nil
+		}
+		return toolResult(text, false), nil
+	}
+	return nil, &rpcError{rpcMethodNotFound, "method not found: " + req.Method}
+}
+
+// call checks required arguments and runs the tool.
+func (s *mcpServer) call(t *forgeTool, raw map[string]any) (string, error) {
+	args := make(map[string]string)
+	for _, p := range t.params {
+		v, _ := raw[p.name].(string)
+		v = strings.TrimSpace(v)
+		if v == "" && p.required {
+			return "", fmt.Errorf("%s is required", p.name)
+		}
+		args[p.name] = v
+	}
+	return t.run(s, args)
+}
+
+func toolResult(text string, isError bool) map[string]any {
+	return map[string]any{
+		"content": []map[string]string{{"type": "text", "text": text}},
+		"isError": isError,
+	}
+}
+
+// ── Tools ─────────────────────────────────────────────────────────
+
+const mcpInstructions = `You are one agent in a party working on the same project. Use the forge tools to coordinate: keep your task line current with forge_set_task, check forge_read_messages when you start and between steps, message teammates with forge_post_message, pass finished work on with forge_handoff, and ask for a review with forge_request_review.`
+
+type forgeTool struct {
+	name        string
+	description string
+	params      []toolParam
+	run         func(s *mcpServer, args map[string]string) (string, error)
+}
+
+type toolParam struct {
+	name        string
+	description string
+	required    bool
+}
+
+// schema is the tool's JSON Schema input; every parameter is a string.
+func (t *forgeTool) schema() map[string]any {
+	props := make(map[string]any)
+	required := []string{}
+	for _, p := range t.params {
+		props[p.name] = map[string]string{"type": "string", "description": p.description}
+		if p.required {
+			required = append(required, p.name)
+		}
+	}
+	return map[string]any{"type": "object", "properties": props, "required": required}
+}
+
+func findForgeTool(name string) *forgeTool {
+	for i := range forgeTools {
+		if forgeTools[i].name == name {
+			return &forgeTools[i]
+		}
+	}
+	return nil
+}
+
+var forgeTools = []forgeTool{
+	{
+		name:        "forge_post_message",
+		description: "Send a message to a teammate, or to the whole party.",
+		params: []toolParam{
+			{"to", `teammate name, or "all" for every agent in the party`, true},
+			{"text", "the message", true},
+		},
+		run: func(s *mcpServer, args map[string]string) (string, error) {
+			err := s.api.post(s.agentPath("message"), map[string]string{"to": args["to"], "text": args["text"]}, nil)
+			return "Message sent to " + args["to"] + ".", err
+		},
+	},
+	{
+		name:        "forge_read_messages",
+		description: "Read messages, handoffs and review requests from teammates that you haven't read yet.",
+		run: func(s *mcpServer, args map[string]string) (string, error) {
+			var msgs []forgeMessage
+			if err := s.api.post(s.agentPath("inbox/read"), nil, &msgs); err != nil {
+				return "", err
+			}
+			if len(msgs) == 0 {
+				return "No new messages.", nil
+			}
+			var b strings.Builder
+			for _, msg := range msgs {
+				fmt.Fprintf(&b, "[%s] %s from %s:\n%s\n\n", msg.Sent.Format(time.Kitchen), msg.Kind, msg.Agent, msg.Text)
+			}
+			return strings.TrimSpace(b.String()), nil
+		},
+	},
+	{
+		name:        "forge_handoff",
+		description: "Hand your work off to a teammate. The summary is added to their prompt when they next start and sent to them as a message.",
+		params: []toolParam{
+			{"to", "teammate name", true},
+			{"summary", "what you did, what is left and anything they need to know", true},
+		},
+		run: func(s *mcpServer, args map[string]string) (string, error) {
+			err := s.api.post(s.agentPath("handoff"), map[string]string{"to": args["to"], "summary": args["summary"]}, nil)
+			return "Handed off to " + args["to"] + ".", err
+		},
+	},
+	{
+		name:        "forge_set_task",
+		description: "Set the one-line task shown for you in the party UI.",
+		params: []toolParam{
+			{"text", "what you are working on now", true},
+		},
+		run: func(s *mcpServer, args map[string]string) (string, error) {
+			err := s.api.post(s.agentPath("task"), map[string]string{"text": args["text"]}, nil)
+			return "Task set.", err
+		},
+	},
+	{
+		name:        "forge_list_party",
+		description: "List the agents in your party with their class, status and current task.",
+		run: func(s *mcpServer, args map[string]string) (string, error) {
+			var parties []apiParty
+			if err := s.api.get("/parties", &parties); err != nil {
+				return "", err
+			}
+			for _, p := range parties {
+				members := append(append([]*apiAgent{}, p.Slots...), p.Bench...)
+				for _, a := range members {
+					if a != nil && a.ID == s.agent {
+						return formatParty(p, s.agent), nil
+					}
+				}
+			}
+			return "", fmt.Errorf("agent %s is not in any party", s.agent)
+		},
+	},
+	{
+		name:        "forge_request_review",
+		description: "Ask a teammate to review your work. Without a reviewer, the party's code reviewer is asked.",
+		params: []toolParam{
+			{"summary", "what to review: branch, files and what changed", true},
+			{"reviewer", "teammate name (optional)", false},
+		},
+		run: func(s *mcpServer, args map[string]string) (string, error) {
+			var reply struct {
+				Reviewer string `json:"reviewer"`
+			}
+			if err := s.api.post(s.agentPath("review"), map[string]string{"reviewer": args["reviewer"], "summary": args["summary"]}, &reply); err != nil {
+				return "", err
+			}
+			if reply.Reviewer == "" {
+				return "No reviewer in the party; the request is flagged in the UI.", nil
+			}
+			return "Review requested from " + reply.Reviewer + ".", nil
+		},
+	},
+}
+
+func (s *mcpServer) agentPath(action string) string {
+	return "/agents/" + url.PathEscape(s.agent) + "/" + action
+}
+
+// formatParty lists p's agents for forge_list_party, marking self.
+func formatParty(p apiParty, self string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Party %s (project %s)\n", p.Name, p.Project)
+	line := func(a *apiAgent, where string) {
+		you := ""
+		if a.ID == self {
+			you = " (you)"
+		}
+		fmt.Fprintf(&b, "- %s%s [%s, %s]: %s — %s\n", a.Agent, you, a.Class, where, a.Status, orDash(a.Task))
+	}
+	for _, a := range p.Slots {
+		if a != nil {
+			line(a, "slot")
+		}
+	}
+	for _, a := range p.Bench {
+		line(a, "bench")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// ── API Client ────────────────────────────────────────────────────
+
+// apiClient calls the control API at an address as accepted by listenAPI.
+type apiClient struct {
+	hc    *http.Client
+	base  string
+	token string // bearer token for TCP addresses
+}
+
+func newAPIClient(addr string) *apiClient {
+	if addr == "" {
+		addr = "unix:" + apiSocketPath()
+	}
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		dial := func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		}
+		return &apiClient{
+			hc:   &http.Client{Transport: &http.Transport{DialContext: dial}, Timeout: 10 * time.Second},
+			base: "http://forge",
+		}
+	}
+	token := os.Getenv(apiTokenEnv)
+	if token == "" {
+		data, _ := os.ReadFile(apiTokenPath())
+		token = strings.TrimSpace(string(data))
+	}
+	return &apiClient{hc: &http.Client{Timeout: 10 * time.Second}, base: "http://" + addr, token: token}
+}
+
+func (c *apiClient) get(path string, out any) error {
+	return c.do(http.MethodGet, path, nil, out)
+}
+
+func (c *apiClient) post(path string, in, out any) error {
+	return c.do(http.MethodPost, path, in, out)
+}
+
+// do sends in as JSON and decodes the reply into out. Non-2xx replies
+// become errors carrying the API's message.
+func (c *apiClient) do(method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.base+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return fmt.Errorf("forge is not reachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%s", strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
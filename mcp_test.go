@@ -0,0 +1,366 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// mcpConn drives mcpServer.serve over a pair of pipes, one JSON-RPC
+// message per line, as an MCP client does over the server's stdio.
+type mcpConn struct {
+	t    *testing.T
+	in   *io.PipeWriter
+	out  *bufio.Reader
+	done chan error
+}
+
+// startMCP serves agent's forge tools against target's control API.
+func startMCP(t *testing.T, target ControlTarget, agent string) *mcpConn {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(apiTokenEnv, "s3cret")
+	api := httptest.NewServer(guardAPI(apiHandler(target), "s3cret"))
+	t.Cleanup(api.Close)
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	c := &mcpConn{t: t, in: inW, out: bufio.NewReader(outR), done: make(chan error, 1)}
+	s := &mcpServer{agent: agent, api: newAPIClient(strings.TrimPrefix(api.URL, "http://"))}
+	go func() {
+		err := s.serve(inR, outW)
+		outW.Close()
+		c.done <- err
+	}()
+	t.Cleanup(func() { inW.Close() })
+	return c
+}
+
+// send writes one raw line to the server.
+func (c *mcpConn) send(line string) {
+	c.t.Helper()
+	if _, err := io.WriteString(c.in, line+"\n"); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// reply reads the server's next response.
+func (c *mcpConn) reply() rpcReply {
+	c.t.Helper()
+	line, err := c.out.ReadBytes('\n')
+	if err != nil {
+		c.t.Fatalf("reading response: %v", err)
+	}
+	var r rpcReply
+	if err := json.Unmarshal(line, &r); err != nil {
+		c.t.Fatalf("response is not JSON: %v\n%s", err, line)
+	}
+	if r.JSONRPC != "2.0" {
+		c.t.Errorf("jsonrpc %q in %s", r.JSONRPC, line)
+	}
+	return r
+}
+
+// call sends a request and returns its response.
+func (c *mcpConn) call(id, method, params string) rpcReply {
+	c.t.Helper()
+	req := `{"jsonrpc":"2.0","id":` + id + `,"method":"` + method + `"`
+	if params != "" {
+		req += `,"params":` + params
+	}
+	c.send(req + "}")
+	r := c.reply()
+	if string(r.ID) != id {
+		c.t.Errorf("%s answered with id %s, want %s", method, r.ID, id)
+	}
+	return r
+}
+
+// tool calls a forge tool and returns its text and whether it failed.
+func (c *mcpConn) tool(name string, args map[string]string) (string, bool) {
+	c.t.Helper()
+	params, _ := json.Marshal(map[string]any{"name": name, "arguments": args})
+	r := c.call("7", "tools/call", string(params))
+	if r.Error != nil {
+		c.t.Fatalf("%s: %+v", name, r.Error)
+	}
+	var res struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		IsError bool `json:"isError"`
+	}
+	if err := json.Unmarshal(r.Result, &res); err != nil || len(res.Content) != 1 || res.Content[0].Type != "text" {
+		c.t.Fatalf("%s result %s", name, r.Result)
+	}
+	return res.Content[0].Text, res.IsError
+}
+
+// rpcReply is rpcResponse as a client decodes it.
+type rpcReply struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *rpcError       `json:"error"`
+}
+
+func TestMCPServeFraming(t *testing.T) {
+	c := startMCP(t, &raidTarget{}, "a")
+
+	c.send("")
+	c.send(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	if r := c.call(`"first"`, "ping", ""); r.Error != nil || string(r.Result) != "{}" {
+		t.Errorf("ping after a blank line and a notification: %+v %s", r.Error, r.Result)
+	}
+
+	c.send(`{"jsonrpc":"2.0","id":2,"method":`)
+	r := c.reply()
+	if r.Error == nil || r.Error.Code != rpcParseError || string(r.ID) != "null" {
+		t.Errorf("truncated request: id %s, error %+v", r.ID, r.Error)
+	}
+
+	// A final request without a newline is still answered before EOF
+	io.WriteString(c.in, `{"jsonrpc":"2.0","id":3,"method":"ping"}`)
+	c.in.Close()
+	if r := c.reply(); string(r.ID) != "3" {
+		t.Errorf("unterminated request answered with id %s", r.ID)
+	}
+	if err := <-c.done; err != nil {
+		t.Errorf("serve returned %v at EOF", err)
+	}
+}
+
+func TestMCPInitialize(t *testing.T) {
+	c := startMCP(t, &raidTarget{}, "a")
+	for _, tt := range []struct{ asked, want string }{
+		{"2025-06-18", "2025-06-18"},
+		{"2025-03-26", "2025-03-26"},
+		{"1999-01-01", mcpProtocolVersion},
+	} {
+		r := c.call("1", "initialize", `{"protocolVersion":"`+tt.asked+`","capabilities":{}}`)
+		var res struct {
+			ProtocolVersion string                `json:"protocolVersion"`
+			Capabilities    map[string]any        `json:"capabilities"`
+			ServerInfo      struct{ Name string } `json:"serverInfo"`
+			Instructions    string                `json:"instructions"`
+		}
+		if err := json.Unmarshal(r.Result, &res); err != nil {
+			t.Fatal(err)
+		}
+		if res.ProtocolVersion != tt.want {
+			t.Errorf("asked for %s, got %s, want %s", tt.asked, res.ProtocolVersion, tt.want)
+		}
+		if res.ServerInfo.Name != mcpServerName || res.Capabilities["tools"] == nil || res.Instructions == "" {
+			t.Errorf("initialize result %s", r.Result)
+		}
+	}
+}
+
+func TestMCPToolsList(t *testing.T) {
+	c := startMCP(t, &raidTarget{}, "a")
+	r := c.call("1", "tools/list", "")
+	var res struct {
+		Tools []struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+			InputSchema struct {
+				Type       string                       `json:"type"`
+				Properties map[string]map[string]string `json:"properties"`
+				Required   []string                     `json:"required"`
+			} `json:"inputSchema"`
+		} `json:"tools"`
+	}
+	if err := json.Unmarshal(r.Result, &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Tools) != len(forgeTools) {
+		t.Fatalf("%d tools, want %d", len(res.Tools), len(forgeTools))
+	}
+	for i, tool := range res.Tools {
+		if tool.Name != forgeTools[i].name || tool.Description == "" || tool.InputSchema.Type != "object" {
+			t.Errorf("tool %d: %+v", i, tool)
+		}
+		if tool.InputSchema.Required == nil {
+			t.Errorf("%s: required must be [] rather than null", tool.Name)
+		}
+	}
+	review := res.Tools[5].InputSchema
+	if strings.Join(review.Required, ",") != "summary" || review.Properties["reviewer"]["type"] != "string" {
+		t.Errorf("forge_request_review schema %+v", review)
+	}
+}
+
+func TestMCPErrors(t *testing.T) {
+	c := startMCP(t, &raidTarget{}, "a")
+	tests := []struct {
+		name, method, params string
+		code                 int
+	}{
+		{"unknown method", "resources/list", "", rpcMethodNotFound},
+		{"params not an object", "tools/call", `["forge_set_task"]`, rpcInvalidParams},
+		{"arguments not an object", "tools/call", `{"name":"forge_set_task","arguments":"x"}`, rpcInvalidParams},
+		{"unknown tool", "tools/call", `{"name":"forge_launch_missiles"}`, rpcInvalidParams},
+	}
+	for _, tt := range tests {
+		if r := c.call("1", tt.method, tt.params); r.Error == nil || r.Error.Code != tt.code {
+			t.Errorf("%s: error %+v, want code %d", tt.name, r.Error, tt.code)
+		}
+	}
+
+	// Tool failures are results flagged isError, not protocol errors
+	if text, isErr := c.tool("forge_set_task", map[string]string{"text": "  "}); !isErr || text != "text is required" {
+		t.Errorf("blank required argument: %q, isError %v", text, isErr)
+	}
+	if text, isErr := c.tool("forge_set_task", map[string]string{"text": "x"}); !isErr || !strings.Contains(text, "agent not found") {
+		t.Errorf("API error: %q, isError %v", text, isErr)
+	}
+}
+
+// tuiParty runs a TUI with Builder and Reviewer in one party and returns
+// its control target and the agents' IDs.
+func tuiParty(t *testing.T) (ControlTarget, string, string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	if err := ensureForgeDir(); err != nil {
+		t.Fatal(err)
+	}
+	project := t.TempDir()
+	if err := SaveParty(&PartyFile{Name: "duo", Project: project, Slots: []PartySlotConfig{
+		{Agent: "Builder"}, {Agent: "Reviewer"},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(project)
+	m, err := initialModel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	builder, reviewer := m.parties[0].Slots[0].ID, m.parties[0].Slots[1].ID
+
+	p := tea.NewProgram(m, tea.WithInput(nil), tea.WithOutput(io.Discard),
+		tea.WithoutRenderer(), tea.WithoutSignalHandler())
+	done := make(chan struct{})
+	go func() {
+		p.Run()
+		close(done)
+	}()
+	t.Cleanup(func() {
+		p.Quit()
+		<-done
+	})
+	return tuiTarget{p}, builder, reviewer
+}
+
+// raidDuo is a raid target with running Builder and Reviewer agents.
+func raidDuo() (ControlTarget, string, string) {
+	t := &raidTarget{party: "duo", project: "/src/app"}
+	t.add(&raidAgent{info: apiAgent{ID: "duo-builder", Agent: "Builder", Class: "developer", Status: "running"}})
+	t.add(&raidAgent{info: apiAgent{ID: "duo-reviewer", Agent: "Reviewer", Class: "code reviewer", Status: "running"}})
+	return t, "duo-builder", "duo-reviewer"
+}
+
+func TestMCPToolsReachTarget(t *testing.T) {
+	targets := map[string]func(t *testing.T) (ControlTarget, string, string){
+		"tui":  tuiParty,
+		"raid": func(*testing.T) (ControlTarget, string, string) { return raidDuo() },
+	}
+	for name, setup := range targets {
+		t.Run(name, func(t *testing.T) {
+			target, builder, reviewer := setup(t)
+			c := startMCP(t, target, builder)
+
+			if _, isErr := c.tool("forge_set_task", map[string]string{"text": "fixing the parser"}); isErr {
+				t.Fatal("forge_set_task failed")
+			}
+			if a, err := target.Agent(builder); err != nil || a.Task != "fixing the parser" {
+				t.Errorf("task not set on the target: %+v, %v", a, err)
+			}
+
+			text, _ := c.tool("forge_list_party", nil)
+			if !strings.HasPrefix(text, "Party duo") || !strings.Contains(text, "- Builder (you) [developer, slot]") ||
+				!strings.Contains(text, "fixing the parser") || !strings.Contains(text, "- Reviewer [code reviewer, slot]") {
+				t.Errorf("party listing:\n%s", text)
+			}
+
+			if text, _ := c.tool("forge_post_message", map[string]string{"to": "reviewer", "text": "parser is next"}); text != "Message sent to reviewer." {
+				t.Errorf("post: %q", text)
+			}
+			if text, _ := c.tool("forge_request_review", map[string]string{"summary": "branch fix-parser"}); text != "Review requested from "+reviewer+"." {
+				t.Errorf("review: %q", text)
+			}
+			if text, isErr := c.tool("forge_post_message", map[string]string{"to": "Ghost", "text": "hi"}); !isErr || !strings.Contains(text, "Ghost") {
+				t.Errorf("message to a stranger: %q, isError %v", text, isErr)
+			}
+
+			inbox, err := target.ReadInbox(reviewer)
+			if err != nil || len(inbox) != 2 {
+				t.Fatalf("reviewer inbox %+v, %v", inbox, err)
+			}
+			if inbox[0].Kind != MessageNote || inbox[0].Agent != "Builder" || inbox[1].Kind != MessageReview {
+				t.Errorf("reviewer inbox %+v", inbox)
+			}
+
+			// The reviewer's own server reads from the same target
+			rc := startMCP(t, target, reviewer)
+			c.tool("forge_post_message", map[string]string{"to": "all", "text": "done"})
+			text, _ = rc.tool("forge_read_messages", nil)
+			if !strings.Contains(text, "message from Builder:\ndone") {
+				t.Errorf("read messages:\n%s", text)
+			}
+			if text, _ := rc.tool("forge_read_messages", nil); text != "No new messages." {
+				t.Errorf("second read: %q", text)
+			}
+		})
+	}
+}
+
+func TestForgeMCPArgs(t *testing.T) {
+	dir := t.TempDir()
+	claude, err := (&ForgeConfig{}).Backend("claude")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools := []string{"Read", "Edit"}
+
+	if args, got, _ := forgeMCPArgs(claude, tools, "", "a", dir, true); args != nil || len(got) != 2 {
+		t.Errorf("without an API address: args %v, tools %v", args, got)
+	}
+	if args, _, _ := forgeMCPArgs(&BackendConfig{}, tools, "127.0.0.1:1", "a", dir, true); args != nil {
+		t.Errorf("backend without mcp_config_flag got %v", args)
+	}
+
+	path := filepath.Join(dir, mcpConfigFileName)
+	args, got, err := forgeMCPArgs(claude, tools, "unix:/tmp/forge.sock", "duo-builder", dir, false)
+	if err != nil || len(args) == 0 || args[len(args)-1] != path {
+		t.Errorf("args %v, %v", args, err)
+	}
+	if len(got) != 3 || got[2] != claude.MCPTools || len(tools) != 2 {
+		t.Errorf("tools %v (caller's slice %v)", got, tools)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("config written without write")
+	}
+
+	forgeMCPArgs(claude, tools, "unix:/tmp/forge.sock", "duo-builder", dir, true)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg struct {
+		MCPServers map[string]struct {
+			Args []string `json:"args"`
+		} `json:"mcpServers"`
+	}
+	json.Unmarshal(data, &cfg)
+	if got := strings.Join(cfg.MCPServers[mcpServerName].Args, " "); got != "mcp --agent duo-builder --api unix:/tmp/forge.sock" {
+		t.Errorf("server args %q", got)
+	}
+}
@@ -8,27 +8,27 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"syscall"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/x/vt"
-	"github.com/creack/pty"
 )
 
 // ── Pull Request Types ────────────────────────────────────────────
 
 type PullRequest struct {
-	Number    int             `json:"number"`
-	Title     string          `json:"title"`
-	State     string          `json:"state"`
-	Branch    string          `json:"headRefName"`
-	Author    string          `json:"author"`
-	IsDraft   bool            `json:"isDraft"`
-	Checks    PRChecksStatus  `json:"statusCheckRollup"`
-	ReviewDec string          `json:"reviewDecision"`
-	UpdatedAt string          `json:"updatedAt"`
+	Number    int            `json:"number"`
+	Title     string         `json:"title"`
+	State     string         `json:"state"`
+	Branch    string         `json:"headRefName"`
+	Author    string         `json:"author"`
+	IsDraft   bool           `json:"isDraft"`
+	Checks    PRChecksStatus `json:"statusCheckRollup"`
+	ReviewDec string         `json:"reviewDecision"`
+	UpdatedAt string         `json:"updatedAt"`
 }
 
 type PRChecksStatus struct {
@@ -122,6 +122,11 @@ const (
 	ModeCharSheet
 	ModeCheckout
 	ModeCommandPalette
+	ModeSessions
+	ModeApprovals
+	ModeCopy
+	ModeSearch
+	ModeBroadcast
 )
 
 const MaxPartySlots = 8
@@ -130,10 +135,10 @@ const MaxPartySlots = 8
 
 // AgentInstance is a live agent in a party slot or bench.
 type AgentInstance struct {
-	ID        string
-	AgentName string
-	ClassName string
-	Tint      color.RGBA
+	ID         string
+	AgentName  string
+	ClassName  string
+	Tint       color.RGBA
 	kittyB64   string
 	avatarImg  image.Image // per-agent avatar for half-block rendering
 	Bio        string
@@ -142,22 +147,45 @@ type AgentInstance struct {
 	// Skill loadout
 	Equipped []string
 	Passives []string
-	Model    string // model override
+	After    []string       // raid: agents that must finish first (see raid.go)
+	Model    string         // model override
+	Backend  string         // agent CLI backend name (see backend.go)
+	backend  *BackendConfig // resolved backend of the running process
+
+	// Backend conversation, persisted in the party YAML for resume
+	ConversationID string
 
 	// Git worktree isolation
 	Worktree string // path to git worktree (empty if not isolated)
 	Branch   string // git branch for this worktree
 
 	// Handoff
-	LastOutput     string // final terminal output snapshot for handoff
-	HandoffContext string // injected context from another agent's handoff
+	LastOutput     string   // final lines of output, plus on_exit hook output, for handoff
+	Transcript     []string // plain-text output of the last run, kept after exit (see transcript.go)
+	HandoffContext string   // injected context from another agent's handoff
+	hookReport     string   // on_exit hook output of the last run
+	exitStatus     string   // how the last run ended
+
+	// Coordination through the forge MCP tools (see mcp.go)
+	Inbox         agentInbox // messages from teammates
+	ReviewRequest string     // summary of the agent's open review request
+	reviewer      string     // ID of the agent asked to review; its next message answers
+
+	// Recent tool uses reported by agent hooks (see activity.go)
+	Activity activityFeed
+
+	// Permission prompt waiting on screen (see approvals.go)
+	Approval             *approvalRequest
+	approvalCheckPending bool // a screen scan is scheduled
+
+	broadcast bool // marked as a broadcast target (see broadcast.go)
 
 	// PTY state
-	Status       string // "idle", "running", "exited"
-	Task         string
-	cmd          *exec.Cmd
-	ptyFile      *os.File
-	emulator     *vt.SafeEmulator
+	Status        string // "idle", "running", "exited"
+	Task          string
+	proc          AgentProcess // local PTY or daemon-owned session
+	sessionDir    string       // audit/recording directory of the current run
+	emulator      *vt.SafeEmulator
 	ContextBytes  int64     // total PTY bytes for HP bar
 	ContextTokens int       // parsed real token count (0 = use byte estimate)
 	ContextMax    int       // parsed max context tokens (0 = use default)
@@ -214,7 +242,7 @@ type Model struct {
 	swapIndex     int
 
 	// Character sheet state
-	csSection int // 0=equipped, 1=available
+	csSection int // 0=equipped, 1=available, 2=passives
 	csCursor  int // cursor within current section
 	bioScroll int // scroll offset for profile/bio section
 
@@ -222,14 +250,29 @@ type Model struct {
 	checkoutAgent  *AgentInstance
 	checkoutStep   int // 0=XP, 1=scroll naming, 2=handoff, 3=worktree
 	handoffTarget  int // index into party slots for handoff target
+	handoffContent HandoffContent
 	scrollNameBuf  string // text input for scroll name
 
+	// Agents that exited while another mode was open, checked out in turn
+	// once the user is back in normal mode
+	pendingCheckouts []*AgentInstance
+
 	// Wizard (nil when not active)
 	wizard *WizardState
 
 	// Delete confirmation
 	deleteConfirm bool
 
+	// Resume-or-fresh prompt for an agent with a saved conversation
+	resumeAsk *AgentInstance
+
+	// Activity feed panel for the selected agent
+	showActivity bool
+
+	// Split panes (see split.go)
+	splitLayout SplitLayout
+	zoomed      bool // focused pane fills the main pane
+
 	// Git panel (files or PRs)
 	showGitPanel   bool
 	gitPanelMode   int // 0=files, 1=PRs
@@ -242,6 +285,26 @@ type Model struct {
 	cmdPaletteInput  string
 	cmdPaletteCursor int
 
+	// Sessions browser
+	sessions          []SessionRecord
+	sessionsLoading   bool
+	sessionCursor     int
+	sessionShowPrompt bool
+	sessionPrompt     string         // effective_prompt.md of the selected session
+	replay            *sessionReplay // non-nil while replaying
+
+	// Approvals panel
+	approvalCursor int
+
+	// Copy mode over the selected agent's scrollback (nil when inactive)
+	scrollback *copyMode
+
+	// Search across every agent's output (nil when inactive)
+	agentSearch *agentSearch
+
+	// Why the control API isn't serving, shown in the status bar
+	apiWarning string
+
 	// Layout cache (recomputed on resize/party change)
 	layout LayoutCache
 
@@ -251,6 +314,9 @@ type Model struct {
 	// Agent index for O(1) lookup by ID
 	agentIndex map[string]*AgentInstance
 
+	// Agents run in the forge daemon; quitting detaches instead of stopping
+	daemon bool
+
 	width  int
 	height int
 	ready  bool
@@ -364,12 +430,16 @@ func (m Model) partyForAgent(inst *AgentInstance) *Party {
 
 const leftPanelWidth = 20
 const gitPanelWidth = 30
+const activityPanelWidth = 36
 
 func (m Model) mainPaneWidth() int {
 	w := m.width - leftPanelWidth - 1 // panel content + border right
 	if m.showGitPanel {
 		w -= gitPanelWidth + 1
 	}
+	if m.showActivity {
+		w -= activityPanelWidth + 1
+	}
 	if w < 20 {
 		w = 20
 	}
@@ -470,7 +540,7 @@ func (m Model) cardLayout() (cardWidth, avatarCols, avatarRows, cardHeight, part
 		if maxPartyH < 10 {
 			maxPartyH = 10
 		}
-		maxAvatarRows := maxPartyH/rows - 6
+		maxAvatarRows := maxPartyH/rows - 8
 		if maxAvatarRows < 3 {
 			maxAvatarRows = 3
 		}
@@ -479,7 +549,7 @@ func (m Model) cardLayout() (cardWidth, avatarCols, avatarRows, cardHeight, part
 		}
 	}
 
-	cardHeight = avatarRows + 4 // name + class + status + hp bar
+	cardHeight = avatarRows + 6           // name + class + status + task + hp bar + passives
 	partyHeight = (cardHeight+2)*rows + 1 // +1 for project dir footer
 	return
 }
@@ -506,64 +576,125 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 	case forceResizeMsg:
 		return m, nil
+	case replayTickMsg:
+		return m.handleReplayTick(msg)
+	case sessionsMsg:
+		return m.handleSessions(msg)
+	case passiveHooksDoneMsg:
+		return m.handlePassiveHooksDone(msg)
+	case apiRequestMsg:
+		return m.handleAPIRequest(msg)
+	case approvalCheckMsg:
+		return m.handleApprovalCheck(msg)
+	case apiWarningMsg:
+		m.apiWarning = string(msg)
+		return m, nil
+	case transcriptMsg:
+		return m.handleTranscript(msg)
+	case scrollbackMsg:
+		return m.handleScrollback(msg)
+	case searchCorpusMsg:
+		return m.handleSearchCorpus(msg)
 	case tea.MouseMsg:
 		if m.wizard != nil {
 			return m, nil // no mouse in wizard
 		}
-		return m.handleMouse(msg)
+		return withFittedPanes(m.handleMouse(msg))
 	case tea.KeyMsg:
-		if m.deleteConfirm {
-			return m.handleDeleteConfirm(msg)
-		}
-		if m.wizard != nil {
-			return m.handleWizardKeys(msg)
-		}
-		switch m.mode {
-		case ModeInsert:
-			return m.handleInsertMode(msg)
-		case ModeSwap:
-			return m.handleSwapMode(msg)
-		case ModeCharSheet:
-			return m.handleCharSheetMode(msg)
-		case ModeCheckout:
-			return m.handleCheckoutMode(msg)
-		case ModeCommandPalette:
-			return m.handleCommandPalette(msg)
-		default:
-			return m.handleNormalMode(msg)
-		}
+		return withFittedPanes(m.handleKey(msg))
 	}
 	return m, nil
 }
 
+// handleKey routes a key to the handler for the current prompt or mode.
+func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	next, cmd := m.dispatchKey(msg)
+	// Closing a mode may uncover checkouts queued while it was open
+	if nm, ok := next.(Model); ok {
+		next = nm.openQueuedCheckout()
+	}
+	return next, cmd
+}
+
+func (m Model) dispatchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.deleteConfirm {
+		return m.handleDeleteConfirm(msg)
+	}
+	if m.resumeAsk != nil {
+		return m.handleResumeAsk(msg)
+	}
+	if m.wizard != nil {
+		return m.handleWizardKeys(msg)
+	}
+	switch m.mode {
+	case ModeInsert:
+		return m.handleInsertMode(msg)
+	case ModeSwap:
+		return m.handleSwapMode(msg)
+	case ModeCharSheet:
+		return m.handleCharSheetMode(msg)
+	case ModeCheckout:
+		return m.handleCheckoutMode(msg)
+	case ModeCommandPalette:
+		return m.handleCommandPalette(msg)
+	case ModeSessions:
+		return m.handleSessionsMode(msg)
+	case ModeApprovals:
+		return m.handleApprovalsMode(msg)
+	case ModeCopy:
+		return m.handleCopyMode(msg)
+	case ModeSearch:
+		return m.handleSearchMode(msg)
+	case ModeBroadcast:
+		return m.handleBroadcastMode(msg)
+	default:
+		return m.handleNormalMode(msg)
+	}
+}
+
 // ── Resize ─────────────────────────────────────────────────────────
 
 func (m Model) handleResize(msg tea.WindowSizeMsg) (tea.Model, tea.Cmd) {
+	wasReady := m.ready
 	m.width = msg.Width
 	m.height = msg.Height
 	m.ready = true
 	m.recomputeLayout()
 
-	tw := m.layout.TermWidth
-	th := m.layout.TermHeight
-
 	// Only resize active party agents
-	p := m.party()
-	if p != nil {
-		for _, inst := range p.Slots {
-			if inst != nil && inst.Status == "running" && inst.emulator != nil && inst.ptyFile != nil {
-				pty.Setsize(inst.ptyFile, &pty.Winsize{Rows: uint16(th), Cols: uint16(tw)})
-				inst.emulator.Resize(tw, th)
-			}
-		}
+	m.resizeActivePartyAgents()
+
+	var cmds []tea.Cmd
+	if !wasReady {
+		cmds = m.reattachAgents()
 	}
 
 	if m.autoStartPending {
 		m.autoStartPending = false
-		return m.autoStartPartyAgents()
+		newM, cmd := m.autoStartPartyAgents()
+		return newM, tea.Batch(append(cmds, cmd)...)
 	}
 
-	return m, nil
+	return m, tea.Batch(cmds...)
+}
+
+// reattachAgents reconnects every agent marked as still running in the daemon.
+func (m Model) reattachAgents() []tea.Cmd {
+	var cmds []tea.Cmd
+	for _, p := range m.parties {
+		for _, inst := range p.Slots {
+			if inst != nil && inst.Status == "attaching" {
+				w, h := m.agentTermSize(inst)
+				cmds = append(cmds, attachAgent(m.config, inst.ID, w, h))
+			}
+		}
+		for _, inst := range p.Bench {
+			if inst != nil && inst.Status == "attaching" {
+				cmds = append(cmds, attachAgent(m.config, inst.ID, m.termWidth(), m.termHeight()))
+			}
+		}
+	}
+	return cmds
 }
 
 // ── Avatar Ready ──────────────────────────────────────────────────
@@ -607,18 +738,29 @@ func (m Model) handleAgentStarted(msg AgentStartedMsg) (tea.Model, tea.Cmd) {
 	if inst == nil {
 		return m, nil
 	}
-	inst.cmd = msg.Cmd
-	inst.ptyFile = msg.PtyFile
+	inst.proc = msg.Proc
 	inst.emulator = msg.Emulator
 	inst.Worktree = msg.Worktree
 	inst.Branch = msg.Branch
+	inst.backend = msg.Backend
+	inst.sessionDir = msg.SessionDir
+	// The queued handoff went out in this launch's prompt
+	if msg.Handoff != "" && msg.Handoff == inst.HandoffContext {
+		inst.HandoffContext = ""
+	}
+	if msg.ConversationID != "" && msg.ConversationID != inst.ConversationID {
+		inst.ConversationID = msg.ConversationID
+		m.saveParty(m.partyForAgent(inst))
+	}
 	inst.Status = "running"
-	inst.Task = "Running claude..."
+	inst.Task = fmt.Sprintf("Running %s...", msg.Backend.Binary)
 	inst.ContextBytes = 0
-	go forwardResponses(inst)
+	go forwardResponses(inst.emulator, inst.proc)
+	m.savePartyState(m.partyForAgent(inst))
+	w, h := m.agentTermSize(inst)
 	return m, tea.Batch(
 		readAgentPTY(inst),
-		delayedResize(inst, m.termWidth(), m.termHeight()),
+		delayedResize(inst, w, h),
 	)
 }
 
@@ -634,11 +776,39 @@ func (m Model) handleAgentOutput(msg AgentOutputMsg) (tea.Model, tea.Cmd) {
 			parseContextFromTerminal(inst)
 		}
 
-		return m, readAgentPTY(inst)
+		return m, tea.Batch(readAgentPTY(inst), scheduleApprovalCheck(inst))
 	}
 	return m, nil
 }
 
+// openCheckout starts the rate / scroll / handoff / worktree steps for an
+// exited agent.
+func (m Model) openCheckout(inst *AgentInstance) Model {
+	m.pendingCheckouts = slices.DeleteFunc(m.pendingCheckouts, func(a *AgentInstance) bool { return a == inst })
+	m.checkoutAgent = inst
+	m.checkoutStep = 0
+	m.handoffTarget = -1
+	m.mode = ModeCheckout
+	return m
+}
+
+// openQueuedCheckout checks out the next queued agent once nothing else
+// holds the screen. Agents restarted or removed since they exited are
+// dropped from the queue.
+func (m Model) openQueuedCheckout() Model {
+	if m.mode != ModeNormal || m.wizard != nil || m.deleteConfirm || m.resumeAsk != nil {
+		return m
+	}
+	for len(m.pendingCheckouts) > 0 {
+		inst := m.pendingCheckouts[0]
+		m.pendingCheckouts = m.pendingCheckouts[1:]
+		if inst.Status == "exited" && m.partyForAgent(inst) != nil {
+			return m.openCheckout(inst)
+		}
+	}
+	return m
+}
+
 func (m Model) handleAgentExited(msg AgentExitedMsg) (tea.Model, tea.Cmd) {
 	inst := m.agentByID(msg.ID)
 	if inst == nil {
@@ -646,13 +816,31 @@ func (m Model) handleAgentExited(msg AgentExitedMsg) (tea.Model, tea.Cmd) {
 	}
 	inst.Status = "exited"
 	inst.Task = "Process exited"
-
-	// If we're in insert mode viewing this agent, switch back
-	if m.mode == ModeInsert {
-		a := m.agent()
-		if a != nil && a.ID == inst.ID {
-			m.mode = ModeNormal
+	inst.Approval = nil
+	status := "0"
+	if msg.Err != nil {
+		status = msg.Err.Error()
+	}
+	inst.exitStatus = status
+
+	// on_exit hooks belong to the passives this session ran with
+	var hooks tea.Cmd
+	if inst.proc != nil && hasExitHooks(m.config, inst.Passives) {
+		dir, party := inst.Worktree, ""
+		if p := m.partyForAgent(inst); p != nil {
+			party = p.Name
+			if dir == "" {
+				dir = p.Project
+			}
 		}
+		inst.Task = "Running passives..."
+		hooks = runExitHooksCmd(m.config, inst.Passives, dir, passiveHookEnv{
+			AgentID:    inst.ID,
+			Agent:      inst.AgentName,
+			Party:      party,
+			SessionDir: inst.sessionDir,
+			ExitStatus: status,
+		})
 	}
 
 	// Apply pending skill changes
@@ -664,43 +852,137 @@ func (m Model) handleAgentExited(msg AgentExitedMsg) (tea.Model, tea.Cmd) {
 		inst.PendingPassives = nil
 	}
 
-	// Capture final output for handoff before closing emulator
+	m.savePartyState(m.partyForAgent(inst))
+
+	// Capture final output for handoff before closing emulator, and the
+	// full transcript from the recording
+	var transcript tea.Cmd
 	if inst.emulator != nil {
-		inst.LastOutput = strings.ReplaceAll(inst.emulator.Render(), "\r\n", "\n")
-		// Trim to last ~2000 chars to keep handoff context reasonable
-		if len(inst.LastOutput) > 2000 {
-			inst.LastOutput = inst.LastOutput[len(inst.LastOutput)-2000:]
-		}
+		inst.LastOutput = snapshotOutput(inst.emulator)
+		transcript = captureTranscript(inst.ID, inst.sessionDir, screenLines(inst.emulator))
 	}
 
-	// Show checkout modal
-	m.checkoutAgent = inst
-	m.checkoutStep = 0
-	m.handoffTarget = -1
-	m.mode = ModeCheckout
+	// Check out right away from normal mode or when the user was typing to
+	// this agent. Any other mode (broadcast, copy, search, another agent's
+	// insert...) is left alone and the checkout waits until it closes.
+	switch {
+	case m.mode == ModeInsert && m.agent() == inst:
+		m.popMode()
+		m = m.openCheckout(inst)
+	default:
+		m.pendingCheckouts = append(m.pendingCheckouts, inst)
+		m = m.openQueuedCheckout()
+	}
 
 	// Cleanup PTY resources
-	ptf := inst.ptyFile
-	cmd := inst.cmd
+	proc := inst.proc
 	em := inst.emulator
-	inst.ptyFile = nil
-	inst.cmd = nil
+	inst.proc = nil
 	inst.emulator = nil
 	go func() {
 		if em != nil {
-			em.Close()
-		}
-		if ptf != nil {
-			ptf.Close()
+			closeEmulator(em)
 		}
-		if cmd != nil {
-			cmd.Wait()
+		if proc != nil {
+			proc.Close()
+			proc.Wait()
 		}
 	}()
 
+	return m, tea.Batch(hooks, transcript)
+}
+
+// handlePassiveHooksDone records on_exit hook output on the agent so a
+// later handoff carries it (e.g. the test results).
+func (m Model) handlePassiveHooksDone(msg passiveHooksDoneMsg) (tea.Model, tea.Cmd) {
+	inst := m.agentByID(msg.ID)
+	if inst == nil || len(msg.Results) == 0 {
+		return m, nil
+	}
+	inst.hookReport = formatHookResults(m.config, msg.Results)
+	inst.LastOutput += inst.hookReport
+	if err := hookFailure(m.config, "on_exit", msg.Results); err != nil {
+		inst.Task = err.Error()
+	} else if inst.Status == "exited" {
+		inst.Task = "Process exited"
+	}
+	return m, nil
+}
+
+// requestStart starts an agent, first asking whether to resume its last
+// conversation when there is one and the backend can resume it.
+func (m Model) requestStart(inst *AgentInstance) (Model, tea.Cmd) {
+	if inst != nil && (inst.Status == "idle" || inst.Status == "exited") && m.canResume(inst) {
+		m.resumeAsk = inst
+		return m, nil
+	}
+	return m, m.startInstance(inst, false)
+}
+
+// canResume reports whether inst has a conversation its backend can resume.
+func (m Model) canResume(inst *AgentInstance) bool {
+	if inst.ConversationID == "" {
+		return false
+	}
+	backend, err := m.config.Backend(inst.Backend)
+	return err == nil && backend.SupportsResume()
+}
+
+func (m Model) handleResumeAsk(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	inst := m.resumeAsk
+	switch msg.String() {
+	case "r", "enter":
+		m.resumeAsk = nil
+		return m, m.startInstance(inst, true)
+	case "f":
+		m.resumeAsk = nil
+		return m, m.startInstance(inst, false)
+	case "esc", "q":
+		m.resumeAsk = nil
+	}
 	return m, nil
 }
 
+// startInstance launches an idle or exited agent in its party's project,
+// continuing its last conversation when resume is set.
+// Returns nil when the agent can't be started right now.
+func (m Model) startInstance(inst *AgentInstance, resume bool) tea.Cmd {
+	if inst == nil || (inst.Status != "idle" && inst.Status != "exited") {
+		return nil
+	}
+	tw, th := m.agentTermSize(inst)
+	if tw <= 0 || th <= 0 {
+		return nil
+	}
+	inst.Status = "idle"
+	inst.Task = "Starting..."
+	inst.ReviewRequest, inst.reviewer = "", "" // a new run asks afresh
+	inst.Activity = nil
+	inst.Transcript, inst.hookReport, inst.exitStatus = nil, "", ""
+	inst.Approval = nil
+	projectDir := "."
+	partyName := ""
+	if p := m.partyForAgent(inst); p != nil {
+		if p.Project != "" {
+			projectDir = p.Project
+		}
+		partyName = p.Name
+	}
+	return startAgent(inst, tw, th, m.config, projectDir, partyName, resume)
+}
+
+// stopInstance asks a running agent to terminate.
+func (m Model) stopInstance(inst *AgentInstance) {
+	if inst == nil || inst.Status != "running" {
+		return
+	}
+	inst.Status = "exited"
+	inst.Task = "Stopping..."
+	if inst.proc != nil {
+		inst.proc.Signal(syscall.SIGTERM)
+	}
+}
+
 // ── Mouse ──────────────────────────────────────────────────────────
 
 func (m Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
@@ -712,9 +994,9 @@ func (m Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 	th := m.termHeight()
 
 	// Row regions (0-indexed from bubbletea)
-	headerBottom := 0                   // header is row 0
-	termTop := 1                        // terminal starts at row 1
-	termBottom := termTop + th + 1      // terminal border bottom
+	headerBottom := 0              // header is row 0
+	termTop := 1                   // terminal starts at row 1
+	termBottom := termTop + th + 1 // terminal border bottom
 	// Click on left panel (party select)
 	if msg.X < panelRight && msg.Y > headerBottom {
 		m.focus = FocusLeftPanel
@@ -775,7 +1057,10 @@ func (m Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 func (m Model) handleNormalMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "q", "ctrl+c":
-		m.stopAllAgents()
+		// Daemon-owned agents keep running; the next start reattaches
+		if !m.daemon {
+			m.stopAllAgents()
+		}
 		return m, tea.Quit
 
 	case ":":
@@ -784,6 +1069,12 @@ func (m Model) handleNormalMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.cmdPaletteCursor = 0
 		return m, nil
 
+	case "p":
+		return m.openApprovals(), nil
+
+	case "f":
+		return m.openAgentSearch()
+
 	case "tab":
 		// Cycle focus zones
 		switch m.focus {
@@ -840,6 +1131,8 @@ func (m Model) handleLeftPanelKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.createNewParty()
 	case "d":
 		return m.deleteParty()
+	case "s":
+		return m.openSessions()
 	case "1", "2", "3", "4", "5", "6", "7", "8", "9":
 		idx := int(msg.String()[0] - '1')
 		if idx < len(m.parties) {
@@ -854,17 +1147,21 @@ func (m Model) handleLeftPanelKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// resizeActivePartyAgents sizes each running agent in the active party to
+// its pane (see agentTermSize), skipping those already that size.
 func (m *Model) resizeActivePartyAgents() {
 	p := m.party()
 	if p == nil {
 		return
 	}
-	tw := m.layout.TermWidth
-	th := m.layout.TermHeight
 	for _, inst := range p.Slots {
-		if inst != nil && inst.Status == "running" && inst.emulator != nil && inst.ptyFile != nil {
-			pty.Setsize(inst.ptyFile, &pty.Winsize{Rows: uint16(th), Cols: uint16(tw)})
-			inst.emulator.Resize(tw, th)
+		if inst == nil || inst.Status != "running" || inst.emulator == nil || inst.proc == nil {
+			continue
+		}
+		w, h := m.agentTermSize(inst)
+		if inst.emulator.Width() != w || inst.emulator.Height() != h {
+			inst.proc.Resize(w, h)
+			inst.emulator.Resize(w, h)
 		}
 	}
 }
@@ -898,34 +1195,19 @@ func (m Model) handleMainPaneKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if inst != nil && inst.Status == "running" {
 			m.pushMode(ModeInsert)
 		}
+	case "I":
+		return m.openBroadcast(), nil
+	case "b":
+		return m.toggleBroadcastMark(), nil
+	case "B":
+		return m.toggleBroadcastAll(), nil
 	case "s":
-		inst := m.agent()
-		if inst != nil && (inst.Status == "idle" || inst.Status == "exited") {
-			tw := m.termWidth()
-			th := m.termHeight()
-			if tw > 0 && th > 0 {
-				inst.Status = "idle"
-				inst.Task = "Starting..."
-				p := m.party()
-				projectDir := "."
-				partyName := ""
-				if p != nil {
-					if p.Project != "" {
-						projectDir = p.Project
-					}
-					partyName = p.Name
-				}
-				return m, startAgent(inst, tw, th, m.config, projectDir, partyName)
-			}
-		}
+		return m.requestStart(m.agent())
 	case "x":
-		inst := m.agent()
-		if inst != nil && inst.Status == "running" {
-			inst.Status = "exited"
-			inst.Task = "Stopping..."
-			if inst.cmd != nil && inst.cmd.Process != nil {
-				inst.cmd.Process.Signal(syscall.SIGTERM)
-			}
+		m.stopInstance(m.agent())
+	case "c":
+		if inst := m.agent(); inst != nil && inst.Status == "exited" {
+			return m.openCheckout(inst), nil
 		}
 	case " ":
 		p := m.party()
@@ -935,6 +1217,14 @@ func (m Model) handleMainPaneKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 	case "g":
 		return m.toggleGitPanel()
+	case "a":
+		return m.toggleActivityPanel(), nil
+	case "v":
+		return m.setSplitLayout((m.splitLayout + 1) % splitLayoutCount), nil
+	case "z":
+		return m.toggleZoom(), nil
+	case "[", "/":
+		return m.openCopyMode(msg.String() == "/")
 	}
 	return m, nil
 }
@@ -958,27 +1248,25 @@ func (m Model) handlePartyBarKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.bioScroll = 0
 		}
 	case "s":
-		inst := m.agent()
-		if inst != nil && (inst.Status == "idle" || inst.Status == "exited") {
-			tw := m.termWidth()
-			th := m.termHeight()
-			if tw > 0 && th > 0 {
-				inst.Status = "idle"
-				inst.Task = "Starting..."
-				p := m.party()
-				projectDir := "."
-				partyName := ""
-				if p != nil {
-					if p.Project != "" {
-						projectDir = p.Project
-					}
-					partyName = p.Name
-				}
-				return m, startAgent(inst, tw, th, m.config, projectDir, partyName)
-			}
+		return m.requestStart(m.agent())
+	case "c":
+		if inst := m.agent(); inst != nil && inst.Status == "exited" {
+			return m.openCheckout(inst), nil
 		}
 	case "g":
 		return m.toggleGitPanel()
+	case "a":
+		return m.toggleActivityPanel(), nil
+	case "v":
+		return m.setSplitLayout((m.splitLayout + 1) % splitLayoutCount), nil
+	case "z":
+		return m.toggleZoom(), nil
+	case "I":
+		return m.openBroadcast(), nil
+	case "b":
+		return m.toggleBroadcastMark(), nil
+	case "B":
+		return m.toggleBroadcastAll(), nil
 	}
 	return m, nil
 }
@@ -991,13 +1279,13 @@ func (m Model) handleInsertMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 	inst := m.agent()
-	if inst == nil || inst.ptyFile == nil {
+	if inst == nil || inst.proc == nil {
 		m.mode = ModeNormal
 		return m, nil
 	}
 	b := keyToBytes(msg)
 	if b != nil {
-		inst.ptyFile.Write(b)
+		inst.proc.Write(b)
 		inst.ContextBytes += int64(len(b))
 	}
 	return m, nil
@@ -1032,15 +1320,10 @@ func (m Model) handleSwapMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			swapped := p.Bench[m.swapIndex]
 			p.Slots[m.selectedAgent] = swapped
 			p.Bench[m.swapIndex] = old
-			if swapped.Status == "running" && swapped.emulator != nil && swapped.ptyFile != nil {
-				tw := m.termWidth()
-				th := m.termHeight()
-				pty.Setsize(swapped.ptyFile, &pty.Winsize{Rows: uint16(th), Cols: uint16(tw)})
-				swapped.emulator.Resize(tw, th)
-			}
 		}
 		m.mode = ModeNormal
 		m.recomputeLayout()
+		m.resizeActivePartyAgents()
 	}
 	return m, nil
 }
@@ -1060,10 +1343,10 @@ func (m Model) handleCharSheetMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Save skill changes to party file
 		m.saveCurrentParty()
 	case "tab":
-		m.csSection = (m.csSection + 1) % 2
+		m.csSection = (m.csSection + 1) % 3
 		m.csCursor = 0
 	case "shift+tab":
-		m.csSection = (m.csSection + 1) % 2
+		m.csSection = (m.csSection + 2) % 3
 		m.csCursor = 0
 	case "up", "k":
 		if m.csCursor > 0 {
@@ -1092,29 +1375,12 @@ func (m Model) handleCharSheetMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "s":
 		// Start agent from char sheet
 		if inst.Status == "idle" || inst.Status == "exited" {
-			tw := m.termWidth()
-			th := m.termHeight()
-			if tw > 0 && th > 0 {
-				inst.Status = "idle"
-				inst.Task = "Starting..."
-				m.mode = ModeNormal
-				p := m.party()
-				projectDir := "."
-				partyName := ""
-				if p != nil {
-					if p.Project != "" {
-						projectDir = p.Project
-					}
-					partyName = p.Name
-				}
-				return m, startAgent(inst, tw, th, m.config, projectDir, partyName)
-			}
+			m.mode = ModeNormal
+			return m.requestStart(inst)
 		}
 	case "x":
-		if inst.Status == "running" && inst.cmd != nil && inst.cmd.Process != nil {
-			inst.Status = "exited"
-			inst.Task = "Stopping..."
-			inst.cmd.Process.Signal(syscall.SIGTERM)
+		if inst.proc != nil {
+			m.stopInstance(inst)
 		}
 	case "[":
 		if m.bioScroll > 0 {
@@ -1137,6 +1403,8 @@ func (m Model) charSheetSectionLen(inst *AgentInstance) int {
 		return innateCount + MaxEquipSlots
 	case 1: // available
 		return len(m.availableSkills(inst))
+	case 2: // passives
+		return len(AllPassiveIDs(m.config))
 	}
 	return 0
 }
@@ -1156,11 +1424,24 @@ func (m Model) charSheetToggle(inst *AgentInstance) {
 	case 1: // available section — equip at cursor
 		avail := m.availableSkills(inst)
 		if m.csCursor < len(avail) {
-			inst.Equipped = ToggleEquip(m.config, inst.ClassName, inst.Equipped, avail[m.csCursor])
+			inst.Equipped = ToggleEquip(m.config, inst.ClassName, m.agentLevel(inst.AgentName), inst.Equipped, avail[m.csCursor])
+		}
+	case 2: // passives — toggle at cursor
+		ids := AllPassiveIDs(m.config)
+		if m.csCursor < len(ids) {
+			inst.Passives = TogglePassive(m.config, inst.Passives, ids[m.csCursor])
 		}
 	}
 }
 
+// agentLevel returns an agent's roster level (1 if unranked).
+func (m Model) agentLevel(name string) int {
+	if entry := m.roster.Agents[name]; entry != nil && entry.Level > 0 {
+		return entry.Level
+	}
+	return 1
+}
+
 // availableSkills returns skills NOT innate and NOT equipped.
 func (m Model) availableSkills(inst *AgentInstance) []string {
 	classCfg := m.config.Classes[inst.ClassName]
@@ -1205,6 +1486,9 @@ func (m Model) handleCheckoutMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// xpRatings names the checkout ratings for session records.
+var xpRatings = map[int]string{50: "great", 20: "normal", 5: "rough"}
+
 func (m Model) handleCheckoutXP(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	var xpGain int
 	switch msg.String() {
@@ -1220,6 +1504,10 @@ func (m Model) handleCheckoutXP(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	if rating := xpRatings[xpGain]; rating != "" {
+		rateSession(m.checkoutAgent.sessionDir, rating)
+	}
+
 	if xpGain > 0 {
 		name := m.checkoutAgent.AgentName
 		entry := m.roster.Agents[name]
@@ -1247,6 +1535,7 @@ func (m Model) advanceCheckout(fromStep int) (tea.Model, tea.Cmd) {
 	if fromStep <= 2 && m.checkoutAgent.LastOutput != "" {
 		m.checkoutStep = 2
 		m.handoffTarget = 0
+		m.handoffContent = HandoffTail
 		return m, nil
 	}
 	if fromStep <= 3 && m.checkoutAgent.Worktree != "" {
@@ -1324,17 +1613,16 @@ func (m Model) handleCheckoutHandoff(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.handoffTarget < len(targets)-1 {
 			m.handoffTarget++
 		}
+	case "tab", "right", "l":
+		m.handoffContent = (m.handoffContent + 1) % handoffContentCount
+	case "shift+tab", "left", "h":
+		m.handoffContent = (m.handoffContent + handoffContentCount - 1) % handoffContentCount
 	case "enter":
 		// Perform handoff to selected target
 		if m.handoffTarget >= 0 && m.handoffTarget < len(targets) {
 			target := targets[m.handoffTarget]
-			handoffCtx := fmt.Sprintf(
-				"\n\n## Handoff from %s (%s)\nThe following is the final output from %s's session. Use it as context:\n\n```\n%s\n```",
-				m.checkoutAgent.AgentName, m.checkoutAgent.ClassName,
-				m.checkoutAgent.AgentName,
-				m.checkoutAgent.LastOutput,
-			)
-			target.HandoffContext = handoffCtx
+			target.HandoffContext = buildHandoffContext(m.checkoutAgent, m.handoffContent)
+			m.savePartyState(p)
 		}
 		return m.advanceCheckout(3)
 	case "esc":
@@ -1343,6 +1631,29 @@ func (m Model) handleCheckoutHandoff(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// snapshotOutput is the last handoffTailLines of the emulator screen as
+// plain text, for handoff context.
+func snapshotOutput(em *vt.SafeEmulator) string {
+	return tailLines(strings.Join(screenLines(em), "\n"), handoffTailLines)
+}
+
+// buildHandoffContext formats what c selects of an agent's session as
+// prompt context for the next agent.
+func buildHandoffContext(from *AgentInstance, c HandoffContent) string {
+	return handoffSection(from.AgentName, from.ClassName, handoffOutput(from, c))
+}
+
+// handoffSection formats one agent's output as a prompt section. Shared by
+// manual checkout handoffs and raid pipelines.
+func handoffSection(agentName, className, output string) string {
+	return fmt.Sprintf(
+		"\n\n## Handoff from %s (%s)\nThe following is the final output from %s's session. Use it as context:\n\n```\n%s\n```",
+		agentName, className,
+		agentName,
+		output,
+	)
+}
+
 func (m Model) handleCheckoutWorktree(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	p := m.partyForAgent(m.checkoutAgent)
 	projectDir := "."
@@ -1364,6 +1675,7 @@ func (m Model) handleCheckoutWorktree(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	default:
 		return m, nil
 	}
+	m.savePartyState(p)
 
 	m.checkoutAgent = nil
 	m.checkoutStep = 0
@@ -1408,13 +1720,14 @@ func (m Model) doDeleteParty() (Model, tea.Cmd) {
 		return m, nil
 	}
 	for _, inst := range p.Slots {
-		if inst != nil && inst.Status == "running" && inst.cmd != nil && inst.cmd.Process != nil {
-			inst.cmd.Process.Signal(syscall.SIGTERM)
+		if inst != nil && inst.Status == "running" && inst.proc != nil {
+			inst.proc.Signal(syscall.SIGTERM)
 		}
 	}
 	// Clean up git worktrees for this party
 	go cleanupPartyWorktrees(p.Name, p.Project)
 	os.Remove(partyPath(p.Name))
+	os.Remove(partyStatePath(p.Name))
 	m.parties = append(m.parties[:m.activeParty], m.parties[m.activeParty+1:]...)
 	if m.activeParty >= len(m.parties) {
 		m.activeParty = len(m.parties) - 1
@@ -1447,21 +1760,79 @@ func (m Model) buildParty(pf *PartyFile) *Party {
 		agentMap[m.config.Agents[i].Name] = &m.config.Agents[i]
 	}
 
+	// Runtime state saved before the last exit (or crash)
+	state, err := LoadPartyState(pf.Name)
+	if err != nil {
+		state = &PartyState{}
+	}
+
 	// Build slots
 	for i := 0; i < MaxPartySlots && i < len(pf.Slots); i++ {
 		slot := pf.Slots[i]
 		party.Slots[i] = m.buildInstance(agentMap, slot, pf.Name, i)
+		restoreAgentState(party.Slots[i], state.Agents[slot.Agent])
 	}
 
 	// Build bench
 	for i, slot := range pf.Bench {
 		inst := m.buildInstance(agentMap, slot, pf.Name, 4+i)
+		restoreAgentState(inst, state.Agents[slot.Agent])
 		party.Bench = append(party.Bench, inst)
 	}
 
 	return party
 }
 
+// restoreAgentState applies saved runtime state to a freshly built
+// instance. An agent last seen running was cut off by a crash (or is still
+// alive in the daemon, which initialModel detects afterwards).
+func restoreAgentState(inst *AgentInstance, st *AgentState) {
+	if st == nil {
+		return
+	}
+	inst.Worktree = st.Worktree
+	inst.Branch = st.Branch
+	inst.HandoffContext = st.HandoffContext
+	inst.PendingEquipped = st.PendingEquipped
+	inst.PendingPassives = st.PendingPassives
+	inst.HasPending = st.HasPending
+	inst.Model = st.Model
+	if st.Status == "running" {
+		inst.Status = "exited"
+		inst.Task = "Interrupted"
+	}
+}
+
+// savePartyState persists the runtime state of every agent in p.
+func (m Model) savePartyState(p *Party) {
+	if p == nil {
+		return
+	}
+	state := &PartyState{Agents: make(map[string]*AgentState)}
+	add := func(inst *AgentInstance) {
+		if inst == nil {
+			return
+		}
+		state.Agents[inst.AgentName] = &AgentState{
+			Status:          inst.Status,
+			Worktree:        inst.Worktree,
+			Branch:          inst.Branch,
+			HandoffContext:  inst.HandoffContext,
+			PendingEquipped: inst.PendingEquipped,
+			PendingPassives: inst.PendingPassives,
+			HasPending:      inst.HasPending,
+			Model:           inst.Model,
+		}
+	}
+	for _, inst := range p.Slots {
+		add(inst)
+	}
+	for _, inst := range p.Bench {
+		add(inst)
+	}
+	SavePartyState(p.Name, state)
+}
+
 func (m Model) buildInstance(agentMap map[string]*AgentConfig, slot PartySlotConfig, partyName string, idx int) *AgentInstance {
 	def := agentMap[slot.Agent]
 	if def == nil {
@@ -1469,9 +1840,11 @@ func (m Model) buildInstance(agentMap map[string]*AgentConfig, slot PartySlotCon
 			ID:        fmt.Sprintf("%s-%d", partyName, idx),
 			AgentName: slot.Agent,
 			ClassName: "coder",
+			Backend:   ResolveBackendName(m.config, "", "coder"),
 			Status:    "idle",
 			Task:      "Awaiting orders...",
 			Tint:      color.RGBA{128, 128, 128, 255},
+			After:     slot.After,
 		}
 	}
 
@@ -1505,13 +1878,20 @@ func (m Model) buildInstance(agentMap map[string]*AgentConfig, slot PartySlotCon
 		Directives: def.Directives,
 		Equipped:   equipped,
 		Passives:   slot.Passives,
+		After:      slot.After,
+		Backend:    ResolveBackendName(m.config, def.Backend, def.Class),
 		Status:     "idle",
 		Task:       "Awaiting orders...",
+
+		ConversationID: slot.ConversationID,
 	}
 }
 
 func (m Model) saveCurrentParty() {
-	p := m.party()
+	m.saveParty(m.party())
+}
+
+func (m Model) saveParty(p *Party) {
 	if p == nil {
 		return
 	}
@@ -1522,22 +1902,36 @@ func (m Model) saveCurrentParty() {
 	for _, inst := range p.Slots {
 		if inst != nil {
 			pf.Slots = append(pf.Slots, PartySlotConfig{
-				Agent:    inst.AgentName,
-				Equipped: inst.Equipped,
-				Passives: inst.Passives,
+				Agent:          inst.AgentName,
+				Equipped:       inst.Equipped,
+				Passives:       inst.Passives,
+				After:          inst.After,
+				ConversationID: inst.ConversationID,
 			})
 		}
 	}
 	for _, inst := range p.Bench {
 		if inst != nil {
 			pf.Bench = append(pf.Bench, PartySlotConfig{
-				Agent:    inst.AgentName,
-				Equipped: inst.Equipped,
-				Passives: inst.Passives,
+				Agent:          inst.AgentName,
+				Equipped:       inst.Equipped,
+				Passives:       inst.Passives,
+				After:          inst.After,
+				ConversationID: inst.ConversationID,
 			})
 		}
 	}
 	SaveParty(pf)
+	m.savePartyState(p)
+}
+
+// ── Activity Panel ─────────────────────────────────────────────────
+
+func (m Model) toggleActivityPanel() Model {
+	m.showActivity = !m.showActivity
+	m.recomputeLayout()
+	m.resizeActivePartyAgents()
+	return m
 }
 
 // ── Git Panel ──────────────────────────────────────────────────────
@@ -1650,16 +2044,17 @@ func loadGitTree(projectDir string) []string {
 func (m Model) stopAllAgents() {
 	for _, p := range m.parties {
 		for _, inst := range p.Slots {
-			if inst != nil && inst.Status == "running" && inst.cmd != nil && inst.cmd.Process != nil {
-				inst.cmd.Process.Signal(syscall.SIGTERM)
+			if inst != nil && inst.Status == "running" && inst.proc != nil {
+				inst.proc.Signal(syscall.SIGTERM)
 				inst.Status = "exited"
 			}
 		}
 		for _, inst := range p.Bench {
-			if inst != nil && inst.Status == "running" && inst.cmd != nil && inst.cmd.Process != nil {
-				inst.cmd.Process.Signal(syscall.SIGTERM)
+			if inst != nil && inst.Status == "running" && inst.proc != nil {
+				inst.proc.Signal(syscall.SIGTERM)
 				inst.Status = "exited"
 			}
 		}
+		m.savePartyState(p)
 	}
 }
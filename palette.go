@@ -3,7 +3,6 @@ package main
 import (
 	"fmt"
 	"strings"
-	"syscall"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -38,26 +37,9 @@ func (m Model) paletteActions() []PaletteAction {
 					Label: fmt.Sprintf("Start %s", name),
 					Action: func(m *Model) tea.Cmd {
 						m.selectedAgent = idx
-						inst := m.agent()
-						if inst == nil {
-							return nil
-						}
-						tw := m.termWidth()
-						th := m.termHeight()
-						if tw <= 0 || th <= 0 {
-							return nil
-						}
-						inst.Status = "idle"
-						inst.Task = "Starting..."
-						projectDir := "."
-						partyName := ""
-						if p := m.party(); p != nil {
-							if p.Project != "" {
-								projectDir = p.Project
-							}
-							partyName = p.Name
-						}
-						return startAgent(inst, tw, th, m.config, projectDir, partyName)
+						newM, cmd := m.requestStart(m.agent())
+						*m = newM
+						return cmd
 					},
 				})
 			}
@@ -75,12 +57,7 @@ func (m Model) paletteActions() []PaletteAction {
 					Label: fmt.Sprintf("Stop %s", name),
 					Action: func(m *Model) tea.Cmd {
 						m.selectedAgent = idx
-						inst := m.agent()
-						if inst != nil && inst.cmd != nil && inst.cmd.Process != nil {
-							inst.Status = "exited"
-							inst.Task = "Stopping..."
-							inst.cmd.Process.Signal(syscall.SIGTERM)
-						}
+						m.stopInstance(m.agent())
 						return nil
 					},
 				})
@@ -125,6 +102,88 @@ func (m Model) paletteActions() []PaletteAction {
 		},
 	})
 
+	actions = append(actions, PaletteAction{
+		Label: "Toggle activity panel",
+		Action: func(m *Model) tea.Cmd {
+			*m = m.toggleActivityPanel()
+			return nil
+		},
+	})
+
+	for l := SplitSingle; l < splitLayoutCount; l++ {
+		layout := l
+		actions = append(actions, PaletteAction{
+			Label: fmt.Sprintf("Layout: %s", layout),
+			Action: func(m *Model) tea.Cmd {
+				*m = m.setSplitLayout(layout)
+				return nil
+			},
+		})
+	}
+
+	if m.agent() != nil {
+		actions = append(actions, PaletteAction{
+			Label: "Scrollback / copy mode",
+			Action: func(m *Model) tea.Cmd {
+				var cmd tea.Cmd
+				*m, cmd = m.openCopyMode(false)
+				return cmd
+			},
+		})
+	}
+
+	actions = append(actions, PaletteAction{
+		Label: "Toggle zoom",
+		Action: func(m *Model) tea.Cmd {
+			*m = m.toggleZoom()
+			return nil
+		},
+	})
+
+	if len(m.broadcastTargets()) > 0 {
+		actions = append(actions, PaletteAction{
+			Label: "Broadcast input to agents",
+			Action: func(m *Model) tea.Cmd {
+				*m = m.openBroadcast()
+				return nil
+			},
+		})
+	}
+
+	actions = append(actions, PaletteAction{
+		Label: "Mark/clear all for broadcast",
+		Action: func(m *Model) tea.Cmd {
+			*m = m.toggleBroadcastAll()
+			return nil
+		},
+	})
+
+	actions = append(actions, PaletteAction{
+		Label: "Search all agents' output",
+		Action: func(m *Model) tea.Cmd {
+			newM, cmd := m.openAgentSearch()
+			*m = newM
+			return cmd
+		},
+	})
+
+	actions = append(actions, PaletteAction{
+		Label: "Review approvals",
+		Action: func(m *Model) tea.Cmd {
+			*m = m.openApprovals()
+			return nil
+		},
+	})
+
+	actions = append(actions, PaletteAction{
+		Label: "Browse sessions",
+		Action: func(m *Model) tea.Cmd {
+			var cmd tea.Cmd
+			*m, cmd = m.openSessions()
+			return cmd
+		},
+	})
+
 	actions = append(actions, PaletteAction{
 		Label: "New party",
 		Action: func(m *Model) tea.Cmd {
@@ -162,11 +221,9 @@ func (m Model) handleCommandPalette(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "enter":
 		actions := m.filteredPaletteActions()
 		if m.cmdPaletteCursor >= 0 && m.cmdPaletteCursor < len(actions) {
-			cmd := actions[m.cmdPaletteCursor].Action(&m)
-			if m.mode == ModeCommandPalette {
-				m.popMode()
-			}
-			return m, cmd
+			// Close first so modes the action pushes return past the palette
+			m.popMode()
+			return m, actions[m.cmdPaletteCursor].Action(&m)
 		}
 		m.popMode()
 		return m, nil
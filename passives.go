@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ── Passive Abilities ─────────────────────────────────────────────
+//
+// A passive is an always-on trait toggled per party slot. It can add text
+// to the system prompt and run shell hooks around the agent's lifecycle:
+// on_start runs in the agent's working directory before the CLI launches
+// (a failure aborts the launch), on_exit runs after the agent exits.
+// Hooks get FORGE_AGENT, FORGE_AGENT_ID, FORGE_PARTY and FORGE_SESSION_DIR
+// in their environment, plus FORGE_EXIT_STATUS for on_exit. Their output is
+// appended to passives.log in the session directory.
+
+// PassiveConfig is a single passive definition from config.yaml.
+type PassiveConfig struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	Prompt      string `yaml:"prompt,omitempty"`   // appended to the system prompt
+	OnStart     string `yaml:"on_start,omitempty"` // sh -c before launch
+	OnExit      string `yaml:"on_exit,omitempty"`  // sh -c after exit
+}
+
+// builtinPassives are always available. Entries in config.yaml with the
+// same ID override them.
+var builtinPassives = map[string]PassiveConfig{
+	"test-on-exit": {
+		Name:        "Test on exit",
+		Description: "Runs the project's test suite when the agent exits",
+		OnExit: `if [ -f go.mod ]; then go test ./...
+elif [ -f package.json ]; then npm test --silent
+elif [ -f Cargo.toml ]; then cargo test -q
+elif [ -f Makefile ]; then make test
+else echo "no test suite detected"; fi`,
+	},
+	"commit-wip": {
+		Name:        "Commit WIP",
+		Description: "Commits uncommitted work in the worktree on exit",
+		Prompt:      "Commit your work in small, coherent steps as you go. Anything left uncommitted when you exit is committed as WIP automatically.",
+		OnExit:      `git add -A && { git diff --cached --quiet || git commit -q -m "WIP: $FORGE_AGENT"; }`,
+	},
+	"summarize": {
+		Name:        "Summarize before exit",
+		Description: "Ends every session with a handoff summary",
+		Prompt:      "Before you finish, write a short summary of what you changed, what is left to do and anything the next agent must know. Keep it under 15 lines.",
+	},
+}
+
+// passiveHookTimeout bounds a single hook so a hung test run can't pile up.
+const passiveHookTimeout = 10 * time.Minute
+
+// Passive resolves a passive by ID, config.yaml first. Nil if unknown.
+func (cfg *ForgeConfig) Passive(id string) *PassiveConfig {
+	if p, ok := cfg.Passives[id]; ok && p != nil {
+		return p
+	}
+	if p, ok := builtinPassives[id]; ok {
+		return &p
+	}
+	return nil
+}
+
+// AllPassiveIDs returns every known passive ID, sorted.
+func AllPassiveIDs(cfg *ForgeConfig) []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for id := range builtinPassives {
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	for id, p := range cfg.Passives {
+		if p != nil && !seen[id] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// passiveName is the display name for a passive ID.
+func passiveName(cfg *ForgeConfig, id string) string {
+	if p := cfg.Passive(id); p != nil && p.Name != "" {
+		return p.Name
+	}
+	return id
+}
+
+// TogglePassive adds or removes a passive, ignoring unknown IDs.
+func TogglePassive(cfg *ForgeConfig, passives []string, id string) []string {
+	for i, p := range passives {
+		if p == id {
+			return append(passives[:i:i], passives[i+1:]...)
+		}
+	}
+	if cfg.Passive(id) == nil {
+		return passives
+	}
+	return append(passives, id)
+}
+
+// ── Hooks ─────────────────────────────────────────────────────────
+
+// passiveHookEnv identifies the agent a hook runs for.
+type passiveHookEnv struct {
+	AgentID    string
+	Agent      string
+	Party      string
+	SessionDir string
+	ExitStatus string // on_exit only
+}
+
+type passiveHookResult struct {
+	Passive string
+	Output  string
+	Err     error
+}
+
+// runPassiveHooks runs the given hook ("on_start" or "on_exit") of each
+// passive in dir, in order. For on_start it stops at the first failure.
+func runPassiveHooks(cfg *ForgeConfig, passives []string, hook, dir string, env passiveHookEnv) []passiveHookResult {
+	var results []passiveHookResult
+	for _, id := range passives {
+		p := cfg.Passive(id)
+		if p == nil {
+			continue
+		}
+		script := p.OnStart
+		if hook == "on_exit" {
+			script = p.OnExit
+		}
+		if script == "" {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), passiveHookTimeout)
+		cmd := exec.CommandContext(ctx, "sh", "-c", script)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"FORGE_AGENT="+env.Agent,
+			"FORGE_AGENT_ID="+env.AgentID,
+			"FORGE_PARTY="+env.Party,
+			"FORGE_SESSION_DIR="+env.SessionDir,
+			"FORGE_EXIT_STATUS="+env.ExitStatus,
+		)
+		out, err := cmd.CombinedOutput()
+		cancel()
+
+		r := passiveHookResult{Passive: id, Output: strings.TrimRight(string(out), "\n"), Err: err}
+		results = append(results, r)
+		logPassiveHook(env.SessionDir, hook, r)
+		if err != nil && hook == "on_start" {
+			break
+		}
+	}
+	return results
+}
+
+// hookFailure returns the first failed hook as an error, or nil.
+func hookFailure(cfg *ForgeConfig, hook string, results []passiveHookResult) error {
+	for _, r := range results {
+		if r.Err != nil {
+			return fmt.Errorf("passive %s %s: %v", passiveName(cfg, r.Passive), hook, r.Err)
+		}
+	}
+	return nil
+}
+
+func logPassiveHook(sessionDir, hook string, r passiveHookResult) {
+	if sessionDir == "" {
+		return
+	}
+	f, err := os.OpenFile(filepath.Join(sessionDir, "passives.log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	status := "ok"
+	if r.Err != nil {
+		status = r.Err.Error()
+	}
+	fmt.Fprintf(f, "── %s %s (%s) %s ──\n%s\n\n", r.Passive, hook, status, time.Now().Format(time.RFC3339), r.Output)
+}
+
+// passiveHooksDoneMsg carries the on_exit hook results for an agent.
+type passiveHooksDoneMsg struct {
+	ID      string
+	Results []passiveHookResult
+}
+
+// runExitHooksCmd runs on_exit hooks off the UI goroutine.
+func runExitHooksCmd(cfg *ForgeConfig, passives []string, dir string, env passiveHookEnv) tea.Cmd {
+	return func() tea.Msg {
+		return passiveHooksDoneMsg{ID: env.AgentID, Results: runPassiveHooks(cfg, passives, "on_exit", dir, env)}
+	}
+}
+
+// hasExitHooks reports whether any of the passives defines on_exit.
+func hasExitHooks(cfg *ForgeConfig, passives []string) bool {
+	for _, id := range passives {
+		if p := cfg.Passive(id); p != nil && p.OnExit != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// formatHookResults renders hook output for the handoff context, keeping
+// the tail of long outputs.
+func formatHookResults(cfg *ForgeConfig, results []passiveHookResult) string {
+	var b strings.Builder
+	for _, r := range results {
+		status := "ok"
+		if r.Err != nil {
+			status = "failed: " + r.Err.Error()
+		}
+		out := r.Output
+		if len(out) > 1000 {
+			out = "..." + out[len(out)-1000:]
+		}
+		fmt.Fprintf(&b, "\n\n[%s — %s]\n%s", passiveName(cfg, r.Passive), status, out)
+	}
+	return b.String()
+}
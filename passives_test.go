@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPassivesAddPromptTextAndRunExitHooks(t *testing.T) {
+	h := newHarness(t, PartyFile{Name: "passive", Slots: []PartySlotConfig{
+		{Agent: "Builder", Passives: []string{"summarize", "test-on-exit"}},
+	}})
+	h.WaitFor(fakeAgentReady)
+	h.WaitFor("◆ Summarize")
+	m := h.Model()
+	composed := ComposePrompt(m.config, "developer", nil, m.party().Slots[0].Passives, "")
+	if !strings.Contains(composed.Prompt, "## Passive: Summarize before exit") {
+		t.Error("prompt missing passive text")
+	}
+	h.Keys("i")
+	h.Type("exit\r")
+	h.WaitUntil("on_exit hook output", func(m Model) bool {
+		return strings.Contains(m.party().Slots[0].LastOutput, "no test suite detected")
+	})
+}
+
+func TestTogglePassive(t *testing.T) {
+	cfg := &ForgeConfig{}
+	got := TogglePassive(cfg, nil, "summarize")
+	got = TogglePassive(cfg, got, "no-such-passive")
+	got = TogglePassive(cfg, got, "commit-wip")
+	if !slices.Equal(got, []string{"summarize", "commit-wip"}) {
+		t.Fatalf("passives %q", got)
+	}
+	if got = TogglePassive(cfg, got, "summarize"); !slices.Equal(got, []string{"commit-wip"}) {
+		t.Errorf("after removing summarize: %q", got)
+	}
+}
+
+func TestRunPassiveHooks(t *testing.T) {
+	dir, session := t.TempDir(), t.TempDir()
+	cfg := &ForgeConfig{Passives: map[string]*PassiveConfig{
+		"greet": {Name: "Greet", OnStart: "echo hello $FORGE_AGENT", OnExit: "echo bye $FORGE_EXIT_STATUS"},
+		"fail":  {Name: "Fail", OnStart: "echo broken; exit 3"},
+		"later": {Name: "Later", OnStart: "touch ran"},
+	}}
+	env := passiveHookEnv{Agent: "Builder", SessionDir: session, ExitStatus: "0"}
+
+	results := runPassiveHooks(cfg, []string{"greet", "fail", "later"}, "on_start", dir, env)
+	if len(results) != 2 || results[0].Output != "hello Builder" || results[1].Err == nil {
+		t.Fatalf("on_start results %+v", results)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ran")); err == nil {
+		t.Error("on_start kept going after a failure")
+	}
+	if err := hookFailure(cfg, "on_start", results); err == nil || !strings.Contains(err.Error(), "passive Fail on_start") {
+		t.Errorf("hookFailure %v", err)
+	}
+
+	results = runPassiveHooks(cfg, []string{"greet", "fail"}, "on_exit", dir, env)
+	if len(results) != 1 || results[0].Output != "bye 0" {
+		t.Errorf("on_exit results %+v", results)
+	}
+	if log, _ := os.ReadFile(filepath.Join(session, "passives.log")); !strings.Contains(string(log), "hello Builder") {
+		t.Errorf("passives.log %q", log)
+	}
+}
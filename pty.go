@@ -2,16 +2,17 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/charmbracelet/x/vt"
 	"github.com/creack/pty"
 )
@@ -27,11 +28,13 @@ type LaunchConfig struct {
 	ID             string
 	AgentName      string
 	ClassName      string
+	Backend        string // backend name; empty = forge default
 	Equipped       []string
 	Passives       []string
 	Model          string
 	Directives     string
 	HandoffContext string
+	ConversationID string // conversation to resume; empty = fresh start
 	ProjectDir     string
 	PartyName      string
 	Cols           int
@@ -50,18 +53,126 @@ func (PtyLauncher) Launch(cfg *ForgeConfig, lc LaunchConfig) tea.Cmd {
 	return startAgentProcess(cfg, lc)
 }
 
-// DefaultLauncher is the production launcher.
+// DefaultLauncher is the production launcher. main swaps in DaemonLauncher
+// when a forge daemon is serving.
 var DefaultLauncher AgentLauncher = PtyLauncher{}
 
+// ── Agent Process ──────────────────────────────────────────────────
+
+// AgentProcess is the live process behind a running AgentInstance. Reads
+// return raw terminal output and writes deliver input to the agent's PTY,
+// whether the PTY is owned locally or by the forge daemon.
+type AgentProcess interface {
+	io.ReadWriteCloser
+	Resize(cols, rows int) error
+	Signal(sig os.Signal) error
+	Wait() error
+}
+
+// localProcess is a PTY child owned directly by this process.
+type localProcess struct {
+	cmd  *exec.Cmd
+	ptmx *os.File
+	rec  *castRecorder // nil when recording is off or failed to open
+
+	// Resize works on ptmx's raw descriptor, which Close invalidates
+	mu     sync.Mutex
+	closed bool
+}
+
+func (p *localProcess) Write(b []byte) (int, error) { return p.ptmx.Write(b) }
+
+func (p *localProcess) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return p.ptmx.Close()
+}
+
+func (p *localProcess) Read(b []byte) (int, error) {
+	n, err := p.ptmx.Read(b)
+	if n > 0 {
+		p.rec.Output(b[:n])
+	}
+	return n, err
+}
+
+// Wait reaps the child and finalizes the recording with its exit status.
+func (p *localProcess) Wait() error {
+	err := p.cmd.Wait()
+	status := "0"
+	if err != nil {
+		status = err.Error()
+	}
+	p.rec.Marker("exit: " + status)
+	p.rec.Close()
+	return err
+}
+
+func (p *localProcess) Resize(cols, rows int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return os.ErrClosed
+	}
+	p.rec.Resize(cols, rows)
+	return pty.Setsize(p.ptmx, &pty.Winsize{Rows: uint16(rows), Cols: uint16(cols)})
+}
+
+func (p *localProcess) Signal(sig os.Signal) error {
+	if p.cmd.Process == nil {
+		return os.ErrProcessDone
+	}
+	return p.cmd.Process.Signal(sig)
+}
+
+// AgentCommand is a fully resolved agent invocation, ready to run under a
+// PTY. It is serializable so the daemon can start it on the TUI's behalf.
+type AgentCommand struct {
+	Binary string   `json:"binary"`
+	Args   []string `json:"args"`
+	Dir    string   `json:"dir"`
+	Env    []string `json:"env"`
+	Cols   int      `json:"cols"`
+	Rows   int      `json:"rows"`
+
+	CastPath  string `json:"cast_path,omitempty"` // asciicast recording; empty = off
+	CastTitle string `json:"cast_title,omitempty"`
+}
+
+// start launches the command under a new PTY.
+func (ac AgentCommand) start() (*localProcess, error) {
+	cmd := exec.Command(ac.Binary, ac.Args...)
+	cmd.Dir = ac.Dir
+	cmd.Env = ac.Env
+
+	ptmx, err := pty.StartWithSize(cmd, &pty.Winsize{
+		Rows: uint16(ac.Rows),
+		Cols: uint16(ac.Cols),
+	})
+	if err != nil {
+		return nil, err
+	}
+	p := &localProcess{cmd: cmd, ptmx: ptmx}
+	if ac.CastPath != "" {
+		p.rec, _ = newCastRecorder(ac.CastPath, ac.Cols, ac.Rows, ac.CastTitle)
+	}
+	return p, nil
+}
+
 // ── Messages ───────────────────────────────────────────────────────
 
 type AgentStartedMsg struct {
 	ID       string
-	Cmd      *exec.Cmd
-	PtyFile  *os.File
+	Proc     AgentProcess
 	Emulator *vt.SafeEmulator
 	Worktree string // path to git worktree (empty if not isolated)
 	Branch   string // git branch for this worktree
+	Backend  *BackendConfig
+
+	SessionDir     string // audit/recording directory; empty if unknown
+	ConversationID string // backend conversation ID; empty if untracked
+	Handoff        string // handoff context sent in the prompt; empty on reattach
 }
 
 type AgentOutputMsg struct {
@@ -79,18 +190,24 @@ type forceResizeMsg struct{ ID string }
 // ── Agent Launch ───────────────────────────────────────────────────
 
 // startAgent builds a LaunchConfig from an AgentInstance and delegates to the launcher.
-func startAgent(inst *AgentInstance, cols, rows int, cfg *ForgeConfig, projectDir, partyName string) tea.Cmd {
-	handoff := inst.HandoffContext
-	inst.HandoffContext = "" // consume handoff
+// When resume is set, the agent's last conversation is continued. The
+// handoff context stays queued until the launch succeeds.
+func startAgent(inst *AgentInstance, cols, rows int, cfg *ForgeConfig, projectDir, partyName string, resume bool) tea.Cmd {
+	conversationID := ""
+	if resume {
+		conversationID = inst.ConversationID
+	}
 	return DefaultLauncher.Launch(cfg, LaunchConfig{
 		ID:             inst.ID,
 		AgentName:      inst.AgentName,
 		ClassName:      inst.ClassName,
+		Backend:        inst.Backend,
 		Equipped:       inst.Equipped,
 		Passives:       inst.Passives,
 		Model:          inst.Model,
 		Directives:     inst.Directives,
-		HandoffContext: handoff,
+		HandoffContext: inst.HandoffContext,
+		ConversationID: conversationID,
 		ProjectDir:     projectDir,
 		PartyName:      partyName,
 		Cols:           cols,
@@ -98,78 +215,155 @@ func startAgent(inst *AgentInstance, cols, rows int, cfg *ForgeConfig, projectDi
 	})
 }
 
-// startAgentProcess is the production implementation that launches a real PTY process.
-func startAgentProcess(cfg *ForgeConfig, lc LaunchConfig) tea.Cmd {
-	return func() tea.Msg {
-		em := vt.NewSafeEmulator(lc.Cols, lc.Rows)
+// preparedLaunch is everything resolved for a launch before a process exists.
+type preparedLaunch struct {
+	Command    AgentCommand
+	Backend    *BackendConfig
+	Worktree   string
+	Branch     string
+	SessionDir string
+	Prompt     string // composed prompt without handoff, for the audit file
 
-		// Compose the system prompt from equipped skills
-		composed := ComposePrompt(cfg, lc.ClassName, lc.Equipped, lc.Passives, lc.Directives)
+	ConversationID string // backend conversation ID; empty if untracked
+}
 
-		// Build command args
-		prompt := composed.Prompt
-		if lc.HandoffContext != "" {
-			prompt += lc.HandoffContext
-		}
-		args := []string{}
-		if prompt != "" {
-			args = append(args, "--append-system-prompt", prompt)
-		}
+// prepareLaunch composes the prompt, builds backend args and sets up the
+// worktree. Shared by the local and daemon launchers.
+func prepareLaunch(cfg *ForgeConfig, lc LaunchConfig) (*preparedLaunch, error) {
+	backend, err := cfg.Backend(lc.Backend)
+	if err != nil {
+		return nil, err
+	}
 
-		// Tool restrictions
-		tools := BuildAllowedTools(cfg, lc.ClassName)
-		if len(tools) > 0 {
-			args = append(args, "--allowedTools", strings.Join(tools, ","))
-		}
+	// Compose the system prompt from equipped skills
+	composed := ComposePrompt(cfg, lc.ClassName, lc.Equipped, lc.Passives, lc.Directives)
 
-		// Model override (if set)
-		if lc.Model != "" {
-			args = append(args, "--model", lc.Model)
-		}
+	prompt := composed.Prompt
+	if lc.HandoffContext != "" {
+		prompt += lc.HandoffContext
+	}
+
+	sessionDir := newSessionDir(lc.ID)
+	saveSessionMeta(sessionDir, sessionMeta{
+		AgentID: lc.ID,
+		Agent:   lc.AgentName,
+		Class:   lc.ClassName,
+		Party:   lc.PartyName,
+		Backend: backend.Name(),
+		Started: time.Now(),
+	})
 
-		// Setup git worktree isolation (falls back to projectDir if not a git repo)
-		workDir := lc.ProjectDir
-		var worktree, branch string
-		if wt, br, err := setupWorktree(lc.PartyName, lc.AgentName, lc.ProjectDir); err == nil {
-			workDir = wt
-			worktree = wt
-			branch = br
+	// Build command args for the agent's backend
+	tools := BuildAllowedTools(cfg, lc.ClassName)
+	mcpArgs, tools, err := forgeMCPArgs(backend, tools, controlAddr, lc.ID, sessionDir, true)
+	if err != nil {
+		return nil, err
+	}
+	args, err := backend.BuildArgs(prompt, tools, lc.Model, sessionDir)
+	if err != nil {
+		return nil, err
+	}
+	hookArgs, err := forgeHookArgs(backend, controlAddr, lc.ID, sessionDir, true)
+	if err != nil {
+		return nil, err
+	}
+	args = append(append(args, mcpArgs...), hookArgs...)
+
+	// Pin or resume the backend conversation so a restart can continue it
+	var conversationID string
+	if backend.SupportsResume() {
+		conversationID = lc.ConversationID
+		resume := conversationID != ""
+		if !resume {
+			conversationID = newConversationID()
 		}
+		args = append(args, backend.ConversationArgs(conversationID, resume)...)
+	}
+
+	// Setup git worktree isolation (falls back to projectDir if not a git repo)
+	workDir := lc.ProjectDir
+	var worktree, branch string
+	if wt, br, err := setupWorktree(lc.PartyName, lc.AgentName, lc.ProjectDir); err == nil {
+		workDir = wt
+		worktree = wt
+		branch = br
+	}
+
+	results := runPassiveHooks(cfg, lc.Passives, "on_start", workDir, passiveHookEnv{
+		AgentID:    lc.ID,
+		Agent:      lc.AgentName,
+		Party:      lc.PartyName,
+		SessionDir: sessionDir,
+	})
+	if err := hookFailure(cfg, "on_start", results); err != nil {
+		return nil, err
+	}
 
-		cmd := exec.Command("claude", args...)
-		cmd.Dir = workDir
-		cmd.Env = append(os.Environ(), "TERM=xterm-256color")
+	return &preparedLaunch{
+		Command: AgentCommand{
+			Binary: backend.Binary,
+			Args:   args,
+			Dir:    workDir,
+			Env:    append(os.Environ(), "TERM=xterm-256color"),
+			Cols:   lc.Cols,
+			Rows:   lc.Rows,
+
+			CastPath:  filepath.Join(sessionDir, castFileName),
+			CastTitle: lc.ID,
+		},
+		Backend:    backend,
+		Worktree:   worktree,
+		Branch:     branch,
+		SessionDir: sessionDir,
+		Prompt:     composed.Prompt,
+
+		ConversationID: conversationID,
+	}, nil
+}
+
+// startAgentProcess is the production implementation that launches a real PTY process.
+func startAgentProcess(cfg *ForgeConfig, lc LaunchConfig) tea.Cmd {
+	return func() tea.Msg {
+		pl, err := prepareLaunch(cfg, lc)
+		if err != nil {
+			return AgentExitedMsg{ID: lc.ID, Err: err}
+		}
 
-		ptmx, err := pty.StartWithSize(cmd, &pty.Winsize{
-			Rows: uint16(lc.Rows),
-			Cols: uint16(lc.Cols),
-		})
+		proc, err := pl.Command.start()
 		if err != nil {
-			em.Close()
 			return AgentExitedMsg{ID: lc.ID, Err: err}
 		}
 
 		// Save audit copy of effective prompt
-		go saveAuditPrompt(lc.ID, composed.Prompt, args)
+		go saveAuditPrompt(pl.SessionDir, lc.ID, pl.Command.Binary, pl.Prompt, pl.Command.Args)
 
 		return AgentStartedMsg{
 			ID:       lc.ID,
-			Cmd:      cmd,
-			PtyFile:  ptmx,
-			Emulator: em,
-			Worktree: worktree,
-			Branch:   branch,
+			Proc:     proc,
+			Emulator: vt.NewSafeEmulator(lc.Cols, lc.Rows),
+			Worktree: pl.Worktree,
+			Branch:   pl.Branch,
+			Backend:  pl.Backend,
+
+			SessionDir:     pl.SessionDir,
+			ConversationID: pl.ConversationID,
+			Handoff:        lc.HandoffContext,
 		}
 	}
 }
 
-// saveAuditPrompt writes the effective prompt to a session audit file.
-func saveAuditPrompt(agentID, prompt string, args []string) {
+// newSessionDir creates the per-launch session directory used for audit
+// files and returns its path.
+func newSessionDir(agentID string) string {
 	sessionDir := filepath.Join(sessionsDir(), agentID+"-"+time.Now().Format("20060102-150405"))
 	os.MkdirAll(sessionDir, 0755)
+	return sessionDir
+}
 
-	content := fmt.Sprintf("# Effective Prompt for %s\n\nLaunch time: %s\n\n## CLI Args\n```\nclaude %s\n```\n\n## System Prompt\n\n%s\n",
-		agentID, time.Now().Format(time.RFC3339), strings.Join(args, " "), prompt)
+// saveAuditPrompt writes the effective prompt to a session audit file.
+func saveAuditPrompt(sessionDir, agentID, binary, prompt string, args []string) {
+	content := fmt.Sprintf("# Effective Prompt for %s\n\nLaunch time: %s\n\n## CLI Args\n```\n%s %s\n```\n\n## System Prompt\n\n%s\n",
+		agentID, time.Now().Format(time.RFC3339), binary, strings.Join(args, " "), prompt)
 
 	os.WriteFile(filepath.Join(sessionDir, "effective_prompt.md"), []byte(content), 0644)
 }
@@ -178,14 +372,14 @@ func saveAuditPrompt(agentID, prompt string, args []string) {
 
 func readAgentPTY(inst *AgentInstance) tea.Cmd {
 	id := inst.ID
-	ptf := inst.ptyFile
+	proc := inst.proc
 	em := inst.emulator
 	return func() tea.Msg {
-		if ptf == nil || em == nil {
+		if proc == nil || em == nil {
 			return AgentExitedMsg{ID: id}
 		}
 		buf := ptyBufPool.Get().([]byte)
-		n, err := ptf.Read(buf)
+		n, err := proc.Read(buf)
 		if err != nil {
 			ptyBufPool.Put(buf)
 			return AgentExitedMsg{ID: id, Err: err}
@@ -196,14 +390,39 @@ func readAgentPTY(inst *AgentInstance) tea.Cmd {
 	}
 }
 
+// vt's SafeEmulator locks Write, Resize and Render, but not what it
+// inherits from Emulator: String reads the screen unguarded, and Close sets
+// a flag that Read checks without the lock. Emulators here always have a
+// PTY writer and a response reader running, so use these instead.
+
+// emulatorText is em's screen as plain text, one line per row with
+// trailing blanks trimmed.
+func emulatorText(em *vt.SafeEmulator) string {
+	lines := strings.Split(ansi.Strip(em.Render()), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " \r")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// closeEmulator closes em's response pipe, ending any reader blocked in
+// em.Read without racing it.
+func closeEmulator(em *vt.SafeEmulator) {
+	if c, ok := em.InputPipe().(io.Closer); ok {
+		c.Close()
+	}
+}
+
 // forwardResponses reads terminal query responses from the emulator and
-// writes them back to the PTY so the child process receives them.
-func forwardResponses(inst *AgentInstance) {
+// writes them back to the PTY so the child process receives them. It is
+// given the agent's process and emulator rather than the agent, whose
+// fields belong to the Update goroutine.
+func forwardResponses(em *vt.SafeEmulator, proc AgentProcess) {
 	buf := make([]byte, 1024)
 	for {
-		n, err := inst.emulator.Read(buf)
-		if n > 0 && inst.ptyFile != nil {
-			inst.ptyFile.Write(buf[:n])
+		n, err := em.Read(buf)
+		if n > 0 {
+			proc.Write(buf[:n])
 		}
 		if err != nil {
 			return
@@ -228,8 +447,7 @@ func setupWorktree(partyName, agentName, projectDir string) (string, string, err
 		return "", "", fmt.Errorf("not a git repo")
 	}
 
-	branch := fmt.Sprintf("forge/%s/%s", partyName, strings.ToLower(agentName))
-	wtPath := filepath.Join(worktreesDir(), partyName, strings.ToLower(agentName))
+	wtPath, branch := worktreeFor(partyName, agentName)
 
 	// Reuse existing valid worktree
 	if _, statErr := os.Stat(wtPath); statErr == nil {
@@ -256,6 +474,13 @@ func setupWorktree(partyName, agentName, projectDir string) (string, string, err
 	return wtPath, branch, nil
 }
 
+// worktreeFor returns the worktree path and branch setupWorktree uses for
+// an agent, without touching git.
+func worktreeFor(partyName, agentName string) (path, branch string) {
+	return filepath.Join(worktreesDir(), partyName, strings.ToLower(agentName)),
+		fmt.Sprintf("forge/%s/%s", partyName, strings.ToLower(agentName))
+}
+
 // cleanupWorktree handles worktree disposition after agent session ends.
 // Actions: "merge" (squash into main), "keep" (leave as-is), "discard" (remove).
 func cleanupWorktree(projectDir, wtPath, branch, action string) {
@@ -291,9 +516,10 @@ func cleanupPartyWorktrees(partyName, projectDir string) {
 	os.RemoveAll(wtDir)
 }
 
-// parseContextFromTerminal scans rendered terminal output for context usage info.
+// parseContextFromTerminal scans rendered terminal output for context usage
+// info using the agent backend's context pattern.
 func parseContextFromTerminal(inst *AgentInstance) {
-	if inst.emulator == nil {
+	if inst.emulator == nil || inst.backend == nil {
 		return
 	}
 	screen := inst.emulator.Render()
@@ -301,37 +527,30 @@ func parseContextFromTerminal(inst *AgentInstance) {
 	if len(screen) > 500 {
 		screen = screen[len(screen)-500:]
 	}
-	matches := contextPattern.FindStringSubmatch(screen)
-	if len(matches) >= 3 {
-		if used, err := strconv.ParseFloat(matches[1], 64); err == nil {
-			if max, err := strconv.ParseFloat(matches[2], 64); err == nil {
-				inst.ContextTokens = int(used * 1000)
-				inst.ContextMax = int(max * 1000)
-			}
-		}
+	if used, max, ok := inst.backend.ParseContext(screen); ok {
+		inst.ContextTokens = int(used * 1000)
+		inst.ContextMax = int(max * 1000)
 	}
 }
 
-// delayedResize performs a "size jiggle" to force SIGWINCH.
+// delayedResize performs a "size jiggle" to force SIGWINCH. Like
+// readAgentPTY it captures the process and emulator up front: the agent
+// may exit, and have its fields cleared, while the jiggle sleeps.
 func delayedResize(inst *AgentInstance, cols, rows int) tea.Cmd {
 	id := inst.ID
+	proc := inst.proc
+	em := inst.emulator
 	return func() tea.Msg {
-		time.Sleep(300 * time.Millisecond)
-		if inst.ptyFile == nil || inst.emulator == nil {
+		if proc == nil || em == nil {
 			return forceResizeMsg{ID: id}
 		}
-		pty.Setsize(inst.ptyFile, &pty.Winsize{
-			Rows: uint16(rows - 1),
-			Cols: uint16(cols),
-		})
-		inst.emulator.Resize(cols, rows-1)
+		time.Sleep(300 * time.Millisecond)
+		proc.Resize(cols, rows-1)
+		em.Resize(cols, rows-1)
 		time.Sleep(100 * time.Millisecond)
 
-		pty.Setsize(inst.ptyFile, &pty.Winsize{
-			Rows: uint16(rows),
-			Cols: uint16(cols),
-		})
-		inst.emulator.Resize(cols, rows)
+		proc.Resize(cols, rows)
+		em.Resize(cols, rows)
 		return forceResizeMsg{ID: id}
 	}
 }
@@ -1,39 +1,106 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
 )
 
-// runRaid executes a party headlessly — no TUI, just parallel agent processes.
-// Usage: orc raid --party <name> [--mission "description"]
+// runRaid executes a party headlessly — no TUI, just agent processes.
+// Each agent runs its backend in print mode with the mission as its task,
+// and the raid fails if any agent does.
+//
+// Slots run in parallel unless they declare `after:` dependencies in the
+// party YAML; a slot then starts once all its upstream agents have
+// finished and receives their results as handoff context. If an upstream
+// agent fails, everything downstream of it is skipped.
+//
+// For CI, --report json|junit writes a summary to --out (default stdout,
+// in which case progress goes to stderr) and --events-fd streams NDJSON
+// events to an inherited file descriptor while the raid runs.
+//
+// --max-parallel caps how many agents run at once. --timeout and
+// --agent-timeout bound the raid and each agent, and --max-tokens and
+// --max-cost bound each agent's spend; an agent past a limit gets SIGTERM,
+// then SIGKILL after --grace.
+//
+// --dry-run prints the launch plan for every slot and exits without
+// launching anything or touching git.
+//
+// Agents' output is logged to agent.log in each session directory and
+// shown live, prefixed with the agent's name; --follow shows only one
+// agent's output.
+//
+// Unknown flags and stray arguments are an error.
 func runRaid(args []string) error {
-	var partyName, mission string
-	for i := 0; i < len(args); i++ {
-		switch args[i] {
-		case "--party":
-			if i+1 < len(args) {
-				partyName = args[i+1]
-				i++
-			}
-		case "--mission":
-			if i+1 < len(args) {
-				mission = args[i+1]
-				i++
-			}
-		}
+	var partyName, mission, apiAddr, reportFormat, reportPath, follow string
+	var eventsFD int
+	var dryRun bool
+	var limits raidLimits
+	fs := newFlagSet("raid", raidUsage)
+	fs.StringVar(&partyName, "party", "", "party to run (required)")
+	fs.StringVar(&mission, "mission", "", "task given to every agent (required)")
+	fs.StringVar(&apiAddr, "api", "", "control API address (default: api_listen from config.yaml)")
+	fs.StringVar(&reportFormat, "report", "", "write a summary report: json or junit")
+	fs.StringVar(&reportPath, "out", "", "report file (default: stdout)")
+	fs.IntVar(&eventsFD, "events-fd", -1, "stream NDJSON events to this inherited file descriptor")
+	fs.StringVar(&follow, "follow", "", "show only this agent's output")
+	fs.IntVar(&limits.MaxParallel, "max-parallel", 0, "most agents running at once (0 = no limit)")
+	fs.DurationVar(&limits.RaidTimeout, "timeout", 0, "stop the whole raid after this long, e.g. 30m (0 = none)")
+	fs.DurationVar(&limits.AgentTimeout, "agent-timeout", 0, "stop an agent after it has run this long (0 = none)")
+	fs.IntVar(&limits.MaxTokens, "max-tokens", 0, "stop an agent once it has used this many tokens (0 = no limit)")
+	fs.Float64Var(&limits.MaxCostUSD, "max-cost", 0, "stop an agent once it has spent this many USD (0 = no limit)")
+	fs.DurationVar(&limits.Grace, "grace", defaultStopGrace, "time a stopped agent gets to exit before SIGKILL")
+	fs.BoolVar(&dryRun, "dry-run", false, "print the launch plan and exit without running anything")
+	if _, err := parseArgs(fs, args, 0); err != nil {
+		return err
+	}
+	if partyName == "" || mission == "" {
+		fs.Usage()
+		return fmt.Errorf("raid: --party and --mission are required")
 	}
-	if partyName == "" {
-		return fmt.Errorf("usage: orc raid --party <name> [--mission \"description\"]")
+	if err := checkPartyName(partyName); err != nil {
+		return err
+	}
+	switch reportFormat {
+	case "", ReportJSON, ReportJUnit:
+	default:
+		return fmt.Errorf("unknown report format %q (want json or junit)", reportFormat)
+	}
+	if reportPath != "" && reportFormat == "" {
+		return fmt.Errorf("--out needs --report json|junit")
+	}
+	if limits.MaxParallel < 0 || limits.RaidTimeout < 0 || limits.AgentTimeout < 0 ||
+		limits.MaxTokens < 0 || limits.MaxCostUSD < 0 || limits.Grace <= 0 {
+		return fmt.Errorf("raid: limits must be positive")
+	}
+
+	// Keep stdout clean for a report written there
+	var out io.Writer = os.Stdout
+	if reportFormat != "" && (reportPath == "" || reportPath == "-") {
+		out = os.Stderr
 	}
 
-	cfg, _, err := loadForgeConfig()
+	var events *raidEvents
+	if eventsFD >= 0 {
+		e, err := openRaidEvents(eventsFD)
+		if err != nil {
+			return err
+		}
+		events = e
+		defer events.Close()
+	}
+
+	cfg, roster, err := loadForgeConfig()
 	if err != nil {
 		return err
 	}
@@ -48,16 +115,70 @@ func runRaid(args []string) error {
 		projectDir, _ = os.Getwd()
 	}
 
-	fmt.Printf("⚔️  RAID MODE: %s\n", partyName)
-	fmt.Printf("   Project: %s\n", projectDir)
-	if mission != "" {
-		fmt.Printf("   Mission: %s\n", mission)
+	slots, err := planRaid(cfg, pf)
+	if err != nil {
+		return err
 	}
-	fmt.Printf("   Agents: %d\n\n", len(pf.Slots))
+	if apiAddr == "" {
+		apiAddr = cfg.APIListen
+	}
+	if dryRun {
+		planAddr := apiAddr
+		if planAddr == "" {
+			planAddr = "unix:" + apiSocketPath()
+		}
+		printRaidPlan(out, cfg, roster, pf, projectDir, mission, planAddr, slots)
+		return nil
+	}
+	if follow != "" && !slices.ContainsFunc(slots, func(s *raidSlot) bool { return strings.EqualFold(s.Agent, follow) }) {
+		return fmt.Errorf("--follow: party %q has no agent %s", partyName, follow)
+	}
+	console := newRaidConsole(out, slots, follow)
 
-	agentMap := make(map[string]*AgentConfig)
-	for i := range cfg.Agents {
-		agentMap[cfg.Agents[i].Name] = &cfg.Agents[i]
+	fmt.Fprintf(console, "⚔️  RAID MODE: %s\n", partyName)
+	fmt.Fprintf(console, "   Project: %s\n", projectDir)
+	fmt.Fprintf(console, "   Mission: %s\n", mission)
+	fmt.Fprintf(console, "   Agents: %d\n", len(slots))
+	for _, s := range slots {
+		if len(s.After) > 0 {
+			fmt.Fprintf(console, "   [%d] %s after %s\n", s.Index, s.Agent, strings.Join(s.After, ", "))
+		}
+		if limits.MaxCostUSD > 0 && s.Backend != nil && s.Backend.Pricing == nil {
+			fmt.Fprintf(console, "   [%d] %s: backend %s has no pricing, --max-cost applies once it reports cost\n",
+				s.Index, s.Agent, s.Backend.Name())
+		}
+	}
+	if l := describeLimits(limits); l != "" {
+		fmt.Fprintf(console, "   Limits: %s\n", l)
+	}
+	fmt.Fprintln(console)
+
+	r := &raid{
+		cfg:     cfg,
+		party:   partyName,
+		project: projectDir,
+		mission: mission,
+		slots:   slots,
+		control: &raidTarget{party: partyName, project: projectDir},
+		console: console,
+		events:  events,
+		limits:  limits,
+		stop:    make(chan struct{}),
+	}
+	if limits.MaxParallel > 0 {
+		r.running = make(chan struct{}, limits.MaxParallel)
+	}
+	raidStarted := time.Now()
+	events.emit("raid_start", map[string]any{
+		"party":   partyName,
+		"project": projectDir,
+		"mission": mission,
+		"agents":  len(slots),
+	})
+	if stop, err := startAPI(apiAddr, r.control); err == nil {
+		defer stop()
+	} else {
+		fmt.Fprintf(console, "   Warning: %s\n\n", apiUnavailable(err))
 	}
 
 	// Handle graceful shutdown
@@ -65,94 +186,556 @@ func runRaid(args []string) error {
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 
 	var wg sync.WaitGroup
-	var procs []*exec.Cmd
-	var mu sync.Mutex
+	for _, s := range slots {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.runSlot(s)
+		}()
+	}
+
+	// Wait for all agents or signal
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
 
+	var raidTimeout <-chan time.Time
+	if limits.RaidTimeout > 0 {
+		t := time.NewTimer(limits.RaidTimeout)
+		defer t.Stop()
+		raidTimeout = t.C
+	}
+
+	aborted := false
+	select {
+	case <-done:
+	case sig := <-sigCh:
+		aborted = true
+		fmt.Fprintf(console, "\n⚔️  Received %s, stopping agents...\n", sig)
+		r.abort(LimitInterrupted, fmt.Sprintf("raid interrupted by %s", sig))
+		<-done
+	case <-raidTimeout:
+		aborted = true
+		fmt.Fprintf(console, "\n⚔️  Raid timeout %s reached, stopping agents...\n", limits.RaidTimeout)
+		r.abort(LimitRaidTimeout, fmt.Sprintf("raid timeout %s exceeded", limits.RaidTimeout))
+		<-done
+	}
+
+	report := r.buildReport(raidStarted, time.Now(), aborted)
+	events.emit("raid_finish", map[string]any{
+		"status":           report.Status,
+		"passed":           report.Passed,
+		"failed":           report.Failed,
+		"skipped":          report.Skipped,
+		"duration_seconds": report.Duration,
+		"tokens":           report.Tokens,
+		"limit":            report.Limit,
+	})
+	if reportFormat != "" {
+		if err := writeRaidReport(report, reportFormat, reportPath); err != nil {
+			return fmt.Errorf("writing %s report: %w", reportFormat, err)
+		}
+	}
+
+	failed := printRaidResults(console, slots)
+	switch {
+	case aborted:
+		fmt.Fprintf(console, "\n⚔️  RAID ABORTED: %s\n", r.stopWhy.detail)
+		return fmt.Errorf("raid aborted: %s", r.stopWhy.detail)
+	case failed > 0:
+		fmt.Fprintf(console, "\n⚔️  RAID FAILED: %d of %d agents failed\n", failed, len(slots))
+		return fmt.Errorf("%d of %d agents failed", failed, len(slots))
+	}
+	fmt.Fprintln(console, "\n⚔️  RAID COMPLETE")
+	return nil
+}
+
+const raidUsage = `--party <name> --mission "description" [--api <addr>]
+	[--report json|junit] [--out <file>] [--events-fd <n>] [--follow <agent>]
+	[--max-parallel <n>] [--timeout <dur>] [--agent-timeout <dur>]
+	[--max-tokens <n>] [--max-cost <usd>] [--grace <dur>] [--dry-run]`
+
+// describeLimits summarizes the limits that are set, for the raid banner.
+func describeLimits(l raidLimits) string {
+	var parts []string
+	if l.MaxParallel > 0 {
+		parts = append(parts, fmt.Sprintf("%d at a time", l.MaxParallel))
+	}
+	if l.RaidTimeout > 0 {
+		parts = append(parts, fmt.Sprintf("raid %s", l.RaidTimeout))
+	}
+	if l.AgentTimeout > 0 {
+		parts = append(parts, fmt.Sprintf("agent %s", l.AgentTimeout))
+	}
+	if l.MaxTokens > 0 {
+		parts = append(parts, fmt.Sprintf("%s tokens", formatTokens(l.MaxTokens)))
+	}
+	if l.MaxCostUSD > 0 {
+		parts = append(parts, fmt.Sprintf("$%.2f", l.MaxCostUSD))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ── Raid Plan ─────────────────────────────────────────────────────
+
+// raidSlot is one party slot resolved for a raid, plus its outcome once
+// run. Result holds the agent's final answer (its print-mode stdout, or the
+// result event in stream-json), also saved as result.md in the session
+// directory.
+type raidSlot struct {
+	Index    int // 1-based, as printed
+	Agent    string
+	Class    string
+	AgentID  string
+	After    []string
+	Equipped []string
+	Passives []string
+	Model    string // the party's model override from the TUI; "" = backend default
+	Tint     [3]uint8
+	Def      *AgentConfig
+	Backend  *BackendConfig
+
+	upstream []*raidSlot
+	done     chan struct{} // closed when the slot has finished or been skipped
+
+	SessionDir   string
+	Worktree     string
+	Branch       string
+	Started      time.Time
+	Finished     time.Time
+	Err          error
+	ExitCode     int    // -1 if the agent never ran or was killed
+	Limit        string // the Limit* that stopped or skipped it, if any
+	Skipped      bool
+	Result       string
+	FilesChanged []string
+	Usage        tokenUsage
+
+	stdout   *headlessCapture
+	stderr   bytes.Buffer
+	stopReq  chan limitStop // stop requests from the control API
+	limitErr error          // set by watchSlot when it stopped the agent
+}
+
+// planRaid resolves every slot of pf and wires up `after:` dependencies.
+// Unknown or cyclic dependencies are an error, reported before anything
+// launches. Slots whose agent or backend can't be resolved are kept with
+// Err set, so they fail (and skip their downstream) like any other.
+func planRaid(cfg *ForgeConfig, pf *PartyFile) ([]*raidSlot, error) {
+	agentMap := make(map[string]*AgentConfig)
+	for i := range cfg.Agents {
+		agentMap[cfg.Agents[i].Name] = &cfg.Agents[i]
+	}
+
+	state, err := LoadPartyState(pf.Name)
+	if err != nil {
+		return nil, fmt.Errorf("party %q: loading state: %w", pf.Name, err)
+	}
+
+	var slots []*raidSlot
+	byName := make(map[string]*raidSlot)
 	for i, slot := range pf.Slots {
+		s := &raidSlot{
+			Index:    i + 1,
+			Agent:    slot.Agent,
+			After:    slot.After,
+			Passives: slot.Passives,
+			ExitCode: -1,
+			done:     make(chan struct{}),
+			stopReq:  make(chan limitStop, 1),
+		}
+		if byName[slot.Agent] != nil {
+			return nil, fmt.Errorf("party %q: agent %s appears in more than one slot", pf.Name, slot.Agent)
+		}
+		byName[slot.Agent] = s
+		slots = append(slots, s)
+
 		def := agentMap[slot.Agent]
 		if def == nil {
-			fmt.Printf("   [%d] %s: agent not found, skipping\n", i+1, slot.Agent)
+			s.Err = fmt.Errorf("agent not found")
 			continue
 		}
-
-		equipped := slot.Equipped
-		if len(equipped) == 0 {
-			equipped = def.DefaultEquipped
+		s.Def = def
+		s.Class = def.Class
+		s.Tint = def.Tint
+		s.AgentID = fmt.Sprintf("%s-%d-%s", pf.Name, i, def.Name)
+		s.Equipped = slot.Equipped
+		if len(s.Equipped) == 0 {
+			s.Equipped = def.DefaultEquipped
+		}
+		if st := state.Agents[def.Name]; st != nil {
+			s.Model = st.Model
 		}
 
-		directives := def.Directives
-		composed := ComposePrompt(cfg, def.Class, equipped, slot.Passives, directives)
+		backend, err := cfg.Backend(ResolveBackendName(cfg, def.Backend, def.Class))
+		if err == nil && !backend.SupportsHeadless() {
+			err = fmt.Errorf("backend %q has no headless mode (headless_args)", backend.Name())
+		}
+		s.Backend = backend
+		s.Err = err
+	}
 
-		args := []string{}
+	for _, s := range slots {
+		for _, dep := range s.After {
+			up := byName[dep]
+			if up == nil {
+				return nil, fmt.Errorf("party %q: %s runs after %s, which is not in a slot", pf.Name, s.Agent, dep)
+			}
+			if up == s {
+				return nil, fmt.Errorf("party %q: %s runs after itself", pf.Name, s.Agent)
+			}
+			s.upstream = append(s.upstream, up)
+		}
+	}
+	if cycle := findRaidCycle(slots); cycle != nil {
+		slices.Reverse(cycle) // upstream first, in run order
+		return nil, fmt.Errorf("party %q: dependency cycle %s", pf.Name, strings.Join(cycle, " → "))
+	}
+	return slots, nil
+}
 
-		prompt := composed.Prompt
-		if mission != "" {
-			prompt += fmt.Sprintf("\n\n## Mission\n%s", mission)
+// findRaidCycle returns the agent names along a dependency cycle, or nil.
+func findRaidCycle(slots []*raidSlot) []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[*raidSlot]int)
+	var path []string
+	var visit func(s *raidSlot) []string
+	visit = func(s *raidSlot) []string {
+		switch state[s] {
+		case visiting:
+			for i, name := range path {
+				if name == s.Agent {
+					return append(append([]string{}, path[i:]...), s.Agent)
+				}
+			}
+		case visited:
+			return nil
 		}
-		if prompt != "" {
-			args = append(args, "--append-system-prompt", prompt)
+		state[s] = visiting
+		path = append(path, s.Agent)
+		for _, up := range s.upstream {
+			if c := visit(up); c != nil {
+				return c
+			}
 		}
-
-		tools := BuildAllowedTools(cfg, def.Class)
-		if len(tools) > 0 {
-			args = append(args, "--allowedTools", strings.Join(tools, ","))
+		path = path[:len(path)-1]
+		state[s] = visited
+		return nil
+	}
+	for _, s := range slots {
+		if c := visit(s); c != nil {
+			return c
 		}
+	}
+	return nil
+}
+
+// ── Raid Execution ────────────────────────────────────────────────
+
+type raid struct {
+	cfg     *ForgeConfig
+	party   string
+	project string
+	mission string
+	slots   []*raidSlot
+	control *raidTarget
+	console *raidConsole // progress and live agent output
+	events  *raidEvents  // NDJSON stream; nil when --events-fd isn't set
+	limits  raidLimits
+	running chan struct{} // one token per running agent under --max-parallel; nil = unlimited
+
+	mu      sync.Mutex
+	aborted bool
+	stopWhy limitStop     // why the raid was aborted
+	stop    chan struct{} // closed on abort; stops running agents and releases waiting slots
+}
+
+// abort stops running agents and keeps waiting slots from starting.
+func (r *raid) abort(limit, detail string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.aborted {
+		return
+	}
+	r.aborted = true
+	r.stopWhy = limitStop{limit, detail}
+	close(r.stop)
+}
+
+// skipAborted marks s skipped because the raid was aborted.
+func (r *raid) skipAborted(s *raidSlot) {
+	r.mu.Lock()
+	why := r.stopWhy
+	r.mu.Unlock()
+	s.Skipped = true
+	s.Limit = why.limit
+	s.Err = fmt.Errorf("skipped: %s", why.detail)
+	r.emitFinish(s)
+}
 
-		// Setup worktree for isolation
-		workDir := projectDir
-		if wt, _, wtErr := setupWorktree(partyName, def.Name, projectDir); wtErr == nil {
-			workDir = wt
+// slotPrompt is the prompt s launches with: its composed loadout followed
+// by a handoff section per upstream agent, whose output result supplies.
+func slotPrompt(cfg *ForgeConfig, s *raidSlot, result func(up *raidSlot) string) string {
+	prompt := ComposePrompt(cfg, s.Class, s.Equipped, s.Passives, s.Def.Directives).Prompt
+	for _, up := range s.upstream {
+		prompt += handoffSection(up.Agent, up.Class, result(up))
+	}
+	return prompt
+}
+
+// runSlot waits for the slot's upstream agents, then runs it to completion.
+func (r *raid) runSlot(s *raidSlot) {
+	defer close(s.done)
+	if s.Err != nil {
+		fmt.Fprintf(r.console, "   [%d] %s: %v, skipping\n", s.Index, s.Agent, s.Err)
+		r.emitFinish(s)
+		return
+	}
+
+	// Wait for every upstream agent; a failure upstream skips this slot
+	for _, up := range s.upstream {
+		select {
+		case <-up.done:
+		case <-r.stop:
+		}
+		r.mu.Lock()
+		aborted := r.aborted
+		r.mu.Unlock()
+		if aborted {
+			r.skipAborted(s)
+			return
+		}
+		if up.Err != nil {
+			s.Skipped = true
+			s.Err = fmt.Errorf("skipped: upstream %s failed", up.Agent)
+			fmt.Fprintf(r.console, "   [%d] %s: %v\n", s.Index, s.Agent, s.Err)
+			r.emitFinish(s)
+			return
 		}
+	}
 
-		cmd := exec.Command("claude", args...)
-		cmd.Dir = workDir
-		cmd.Env = append(os.Environ(), "TERM=xterm-256color")
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+	// Wait for a free run slot under --max-parallel
+	if !r.acquire() {
+		r.skipAborted(s)
+		return
+	}
+	defer r.release()
 
-		mu.Lock()
-		procs = append(procs, cmd)
-		mu.Unlock()
+	cfg := r.cfg
+	prompt := slotPrompt(cfg, s, func(up *raidSlot) string { return up.Result })
 
-		wg.Add(1)
-		agentName := def.Name
-		idx := i + 1
+	s.SessionDir = newSessionDir(s.AgentID)
+	saveSessionMeta(s.SessionDir, sessionMeta{
+		AgentID: s.AgentID,
+		Agent:   s.Agent,
+		Class:   s.Class,
+		Party:   r.party,
+		Backend: s.Backend.Name(),
+		Started: time.Now(),
+	})
+	tools := BuildAllowedTools(cfg, s.Class)
+	mcpArgs, tools, err := forgeMCPArgs(s.Backend, tools, controlAddr, s.AgentID, s.SessionDir, true)
+	var args, hookArgs []string
+	if err == nil {
+		hookArgs, err = forgeHookArgs(s.Backend, controlAddr, s.AgentID, s.SessionDir, true)
+	}
+	if err == nil {
+		args, err = s.Backend.BuildArgs(prompt, tools, s.Model, s.SessionDir)
+	}
+	if err != nil {
+		s.Err = err
+		fmt.Fprintf(r.console, "   [%d] %s: %v, skipping\n", s.Index, s.Agent, err)
+		r.emitFinish(s)
+		return
+	}
+	args = append(append(s.Backend.HeadlessTaskArgs(args, r.mission), mcpArgs...), hookArgs...)
+	saveAuditPrompt(s.SessionDir, s.AgentID, s.Backend.Binary, prompt, args)
+
+	// Setup worktree for isolation
+	workDir := r.project
+	if wt, branch, wtErr := setupWorktree(r.party, s.Agent, r.project); wtErr == nil {
+		workDir = wt
+		s.Branch = branch
+	}
+	s.Worktree = workDir
+	base := gitHead(workDir) // files changed are measured against this
+
+	// Print mode: no stdin, stdout is the agent's final answer
+	cmd := exec.Command(s.Backend.Binary, args...)
+	cmd.Dir = workDir
+	cmd.Env = append(os.Environ(), "TERM=dumb")
+	rec, _ := newCastRecorder(filepath.Join(s.SessionDir, castFileName), 80, 24, s.AgentID)
+	var logFile io.Writer
+	if f, err := os.Create(filepath.Join(s.SessionDir, raidLogFileName)); err == nil {
+		defer f.Close()
+		logFile = f
+	}
+	outStream, errStream := r.console.stream(s, logFile), r.console.stream(s, logFile)
+	s.stdout = newHeadlessCapture(s.Backend.HeadlessFormat, func(text string) {
+		outStream.Write([]byte(text))
+	})
+	cmd.Stdout = io.MultiWriter(s.stdout, rec)
+	cmd.Stderr = io.MultiWriter(&s.stderr, errStream, rec)
+
+	ra := &raidAgent{info: apiAgent{
+		ID:       s.AgentID,
+		Agent:    s.Agent,
+		Class:    s.Class,
+		Backend:  s.Backend.Name(),
+		Status:   "idle",
+		Task:     "Starting...",
+		Worktree: workDir,
+	}}
+	r.control.add(ra)
+
+	hookEnv := passiveHookEnv{
+		AgentID:    s.AgentID,
+		Agent:      s.Agent,
+		Party:      r.party,
+		SessionDir: s.SessionDir,
+	}
+
+	s.Started = time.Now()
+	fmt.Fprintf(r.console, "   [%d] %s: starting...\n", s.Index, s.Agent)
+	r.events.emit("agent_start", map[string]any{
+		"index":    s.Index,
+		"agent":    s.Agent,
+		"agent_id": s.AgentID,
+		"backend":  s.Backend.Name(),
+		"worktree": s.Worktree,
+		"branch":   s.Branch,
+	})
+	err = hookFailure(cfg, "on_start", runPassiveHooks(cfg, s.Passives, "on_start", workDir, hookEnv))
+	started := false
+	if err == nil {
+		r.mu.Lock()
+		if r.aborted {
+			err = fmt.Errorf("stopped: %s", r.stopWhy.detail)
+			s.Limit = r.stopWhy.limit
+		} else if err = cmd.Start(); err == nil {
+			started = true
+		}
+		r.mu.Unlock()
+	}
+	input := prompt + "\n" + r.mission // what usage is estimated from
+	if started {
+		exited, watched := make(chan struct{}), make(chan struct{})
 		go func() {
-			defer wg.Done()
-			start := time.Now()
-			fmt.Printf("   [%d] %s: starting...\n", idx, agentName)
-			if err := cmd.Run(); err != nil {
-				fmt.Printf("   [%d] %s: exited with error: %v (%.1fs)\n",
-					idx, agentName, err, time.Since(start).Seconds())
-			} else {
-				fmt.Printf("   [%d] %s: completed (%.1fs)\n",
-					idx, agentName, time.Since(start).Seconds())
-			}
+			r.watchSlot(s, cmd.Process, exited, input)
+			close(watched)
 		}()
+		r.control.mu.Lock()
+		ra.stop = func() {
+			select {
+			case s.stopReq <- limitStop{LimitStopped, "stopped through the control API"}:
+			default:
+			}
+		}
+		r.control.mu.Unlock()
+		r.control.setStatus(ra, "running", fmt.Sprintf("Running %s...", s.Backend.Binary))
+		err = cmd.Wait()
+		s.ExitCode = exitCode(err)
+		close(exited)
+		<-watched
+		if s.limitErr != nil {
+			err = s.limitErr
+		}
+	}
+	s.Finished = time.Now()
+	s.stdout.Flush()
+	outStream.Flush()
+	errStream.Flush()
+	if err == nil && s.stdout.ReportedError() {
+		err = fmt.Errorf("%s reported an error", s.Backend.Binary)
+	}
+	status := "0"
+	if err != nil {
+		status = err.Error()
+	}
+	rec.Marker("exit: " + status)
+	rec.Close()
+	s.Result = s.stdout.Result()
+	s.Usage = s.stdout.Usage(input)
+	if cost, ok := s.Backend.Cost(s.Usage); ok {
+		s.Usage.CostUSD = cost
+	}
+	s.FilesChanged = changedFiles(workDir, base)
+	os.WriteFile(filepath.Join(s.SessionDir, "result.md"), []byte(s.Result+"\n"), 0644)
+	if started {
+		hookEnv.ExitStatus = status
+		for _, res := range runPassiveHooks(cfg, s.Passives, "on_exit", workDir, hookEnv) {
+			result := "ok"
+			if res.Err != nil {
+				result = res.Err.Error()
+			}
+			fmt.Fprintf(r.console, "   [%d] %s: passive %s: %s\n", s.Index, s.Agent, passiveName(cfg, res.Passive), result)
+		}
+	}
+	s.Err = err
+	if err != nil {
+		r.control.setStatus(ra, "exited", err.Error())
+		fmt.Fprintf(r.console, "   [%d] %s: exited with error: %v (%.1fs)\n",
+			s.Index, s.Agent, err, s.Finished.Sub(s.Started).Seconds())
+	} else {
+		r.control.setStatus(ra, "exited", "Completed")
+		fmt.Fprintf(r.console, "   [%d] %s: completed (%.1fs)\n",
+			s.Index, s.Agent, s.Finished.Sub(s.Started).Seconds())
 	}
+	r.emitFinish(s)
+}
 
-	// Wait for all agents or signal
-	done := make(chan struct{})
-	go func() {
-		wg.Wait()
-		close(done)
-	}()
+// emitFinish reports a slot that has finished, failed to launch or been
+// skipped, with the same fields as its entry in the JSON report.
+func (r *raid) emitFinish(s *raidSlot) {
+	if r.events == nil {
+		return
+	}
+	rep := s.report()
+	rep.Result = "" // may be long; it's in the report and result.md
+	r.events.emit("agent_finish", map[string]any{"slot": rep})
+}
 
-	select {
-	case <-done:
-		fmt.Println("\n⚔️  RAID COMPLETE")
-	case sig := <-sigCh:
-		fmt.Printf("\n⚔️  Received %s, stopping agents...\n", sig)
-		mu.Lock()
-		for _, cmd := range procs {
-			if cmd.Process != nil {
-				cmd.Process.Signal(syscall.SIGTERM)
-			}
+// ── Raid Results ──────────────────────────────────────────────────
+
+// printRaidResults prints each agent's final answer, or the tail of its
+// stderr when it failed, and returns the number of failures.
+func printRaidResults(out io.Writer, slots []*raidSlot) (failed int) {
+	for _, s := range slots {
+		mark := "✓"
+		switch {
+		case s.Skipped:
+			mark = "–"
+			failed++
+		case s.Err != nil:
+			mark = "✗"
+			failed++
+		}
+		fmt.Fprintf(out, "\n── [%d] %s %s ──\n", s.Index, s.Agent, mark)
+		body := s.Result
+		if s.Err != nil {
+			body = strings.TrimSpace(s.Err.Error() + "\n" + tailLines(s.stderr.String(), 10))
+		}
+		if body == "" {
+			body = "(no output)"
+		}
+		for _, line := range strings.Split(body, "\n") {
+			fmt.Fprintln(out, "   "+line)
 		}
-		mu.Unlock()
-		<-done
-		fmt.Println("⚔️  RAID ABORTED")
 	}
+	return failed
+}
 
-	return nil
+// tailLines returns the last n lines of s.
+func tailLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
 }
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ── Raid Dry Run ──────────────────────────────────────────────────
+//
+// raid --dry-run resolves every slot exactly as a real raid would and
+// prints the launch plan: loadout with token counts, tools, model,
+// worktree and the full CLI invocation. Nothing is launched and git is
+// not touched; the session directory in the invocation is a placeholder.
+
+// dryRunSessionDir stands in for the session directory a launch creates.
+const dryRunSessionDir = "<session>"
+
+// printRaidPlan writes the launch plan for slots to w.
+// apiAddr is where the raid would serve its control API, which agents'
+// forge MCP servers connect to.
+func printRaidPlan(w io.Writer, cfg *ForgeConfig, roster *RosterFile, pf *PartyFile, project, mission, apiAddr string, slots []*raidSlot) {
+	fmt.Fprintf(w, "⚔️  RAID PLAN: %s (dry run, nothing launched)\n", pf.Name)
+	fmt.Fprintf(w, "   Project: %s\n", project)
+	fmt.Fprintf(w, "   Mission: %s\n", mission)
+	fmt.Fprintf(w, "   Agents: %d\n", len(slots))
+	fmt.Fprintf(w, "   Sessions: %s\n", filepath.Join(sessionsDir(), "<agent-id>-<timestamp>"))
+	fmt.Fprintln(w, "   Worktrees are only used when the project is a git repository.")
+
+	for _, s := range slots {
+		fmt.Fprintf(w, "\n── [%d] %s ──\n", s.Index, s.Agent)
+		if s.Err != nil {
+			fmt.Fprintf(w, "   Error:    %v (would fail without launching)\n", s.Err)
+			continue
+		}
+		level := 1
+		if r := roster.Agents[s.Agent]; r != nil && r.Level > 0 {
+			level = r.Level
+		}
+		composed := ComposePrompt(cfg, s.Class, s.Equipped, s.Passives, s.Def.Directives)
+		// Upstream output doesn't exist yet; a placeholder stands in for it
+		prompt := slotPrompt(cfg, s, func(up *raidSlot) string { return "<" + up.Agent + "'s final output>" })
+		tools := BuildAllowedTools(cfg, s.Class)
+		model := s.Model
+		if model == "" {
+			model = "(backend default)"
+		}
+		wtPath, branch := worktreeFor(pf.Name, s.Agent)
+
+		fmt.Fprintf(w, "   Agent ID: %s\n", s.AgentID)
+		fmt.Fprintf(w, "   Class:    %s (level %d)\n", s.Class, level)
+		fmt.Fprintf(w, "   Backend:  %s\n", s.Backend.Name())
+		fmt.Fprintf(w, "   Model:    %s\n", model)
+		fmt.Fprintf(w, "   Worktree: %s (branch %s)\n", wtPath, branch)
+		if len(s.After) > 0 {
+			fmt.Fprintf(w, "   After:    %s (their results are appended to the prompt as handoff)\n", strings.Join(s.After, ", "))
+		}
+
+		fmt.Fprintln(w, "   Loadout:")
+		if len(composed.Slots) == 0 {
+			fmt.Fprintln(w, "     (none)")
+		}
+		for _, sl := range composed.Slots {
+			kind := "equipped"
+			switch {
+			case sl.IsInnate:
+				kind = "innate"
+			case sl.IsPassive:
+				kind = "passive"
+			}
+			fmt.Fprintf(w, "     %-8s %-32s %6s\n", kind, sl.SkillID, formatTokens(sl.Tokens))
+		}
+		fmt.Fprintf(w, "   Tokens:   %s in loadout, budget %s; system prompt %s\n",
+			formatTokens(LoadoutTokens(cfg, s.Class, s.Equipped)),
+			formatTokens(SkillBudget(cfg, s.Class, level)),
+			formatTokens(countTokens(composed.Prompt)))
+		if len(tools) > 0 {
+			fmt.Fprintf(w, "   Tools:    %s\n", strings.Join(tools, ", "))
+		} else {
+			fmt.Fprintln(w, "   Tools:    (backend default)")
+		}
+		for _, hook := range []string{"on_start", "on_exit"} {
+			if names := passivesWithHook(cfg, s.Passives, hook); len(names) > 0 {
+				fmt.Fprintf(w, "   %-9s %s\n", hook+":", strings.Join(names, ", "))
+			}
+		}
+
+		mcpArgs, tools, _ := forgeMCPArgs(s.Backend, tools, apiAddr, s.AgentID, dryRunSessionDir, false)
+		hookArgs, _ := forgeHookArgs(s.Backend, apiAddr, s.AgentID, dryRunSessionDir, false)
+		args, err := s.Backend.PlanArgs(prompt, tools, s.Model, dryRunSessionDir)
+		if err != nil {
+			fmt.Fprintf(w, "   Error:    %v\n", err)
+			continue
+		}
+		if len(mcpArgs) > 0 {
+			fmt.Fprintf(w, "   MCP:      %s server (agent-tui mcp) via %s\n", mcpServerName, apiAddr)
+		}
+		if len(hookArgs) > 0 {
+			fmt.Fprintf(w, "   Hooks:    tool use reported via %s\n", apiAddr)
+		}
+		args = append(append(s.Backend.HeadlessTaskArgs(args, mission), mcpArgs...), hookArgs...)
+		fmt.Fprintf(w, "   Command:  (in %s)\n", wtPath)
+		fmt.Fprintf(w, "     %s\n", shellJoin(append([]string{s.Backend.Binary}, args...)))
+	}
+}
+
+// passivesWithHook names the passives that run a command for hook.
+func passivesWithHook(cfg *ForgeConfig, passives []string, hook string) []string {
+	var names []string
+	for _, id := range passives {
+		p := cfg.Passive(id)
+		if p == nil {
+			continue
+		}
+		if (hook == "on_start" && p.OnStart != "") || (hook == "on_exit" && p.OnExit != "") {
+			names = append(names, passiveName(cfg, id))
+		}
+	}
+	return names
+}
+
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellJoin quotes args for a POSIX shell, so a printed command can be
+// pasted as-is.
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		if shellSafe.MatchString(a) {
+			quoted[i] = a
+		} else {
+			quoted[i] = "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+		}
+	}
+	return strings.Join(quoted, " ")
+}
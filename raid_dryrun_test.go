@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestShellJoin(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"claude", "-p", "--model=opus", "/tmp/x.json"}, "claude -p --model=opus /tmp/x.json"},
+		{[]string{"echo", "two words"}, "echo 'two words'"},
+		{[]string{"echo", "it's"}, `echo 'it'\''s'`},
+		{[]string{"echo", ""}, "echo ''"},
+		{[]string{"echo", "$HOME", "a*", "x;y", "line\nbreak"}, "echo '$HOME' 'a*' 'x;y' 'line\nbreak'"},
+		{[]string{"mcp__forge", "user@host:1,2"}, "mcp__forge user@host:1,2"},
+	}
+	for _, tt := range tests {
+		if got := shellJoin(tt.args); got != tt.want {
+			t.Errorf("shellJoin(%q) = %s, want %s", tt.args, got, tt.want)
+		}
+	}
+}
+
+// raidPlanSections prints the plan and splits it by slot; [0] is the header.
+func raidPlanSections(t *testing.T, cfg *ForgeConfig, roster *RosterFile, pf *PartyFile) []string {
+	t.Helper()
+	slots, err := planRaid(cfg, pf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	printRaidPlan(&out, cfg, roster, pf, "/src/api", "fix the build", "unix:/run/forge.sock", slots)
+	return strings.Split(out.String(), "\n── [")
+}
+
+func TestPrintRaidPlan(t *testing.T) {
+	cfg, pf := raidParty(t,
+		PartySlotConfig{Agent: "Planner"},
+		PartySlotConfig{Agent: "Builder", After: []string{"Planner"}},
+	)
+	roster := &RosterFile{Agents: map[string]*AgentRoster{
+		"Planner": {Level: 3, XP: 400},
+		"Builder": {XP: 10}, // rated before levels were stored
+	}}
+	sections := raidPlanSections(t, cfg, roster, pf)
+	if len(sections) != 3 {
+		t.Fatalf("%d sections, want a header and 2 slots", len(sections))
+	}
+	header, planner, builder := sections[0], sections[1], sections[2]
+
+	for _, want := range []string{"RAID PLAN: raid", "Project: /src/api", "Mission: fix the build", "Agents: 2"} {
+		if !strings.Contains(header, want) {
+			t.Errorf("header missing %q:\n%s", want, header)
+		}
+	}
+	if !strings.Contains(planner, "(level 3)") || !strings.Contains(builder, "(level 1)") {
+		t.Errorf("levels: Planner\n%s\nBuilder\n%s", planner, builder)
+	}
+	if !strings.Contains(builder, "After:    Planner") {
+		t.Errorf("Builder's dependency not shown:\n%s", builder)
+	}
+
+	// The printed command carries the same handoff block a real run appends
+	handoff := handoffSection("Planner", "developer", "<Planner's final output>")
+	quoted := shellJoin([]string{handoff})
+	if !strings.Contains(builder, strings.Trim(quoted, "'")) {
+		t.Errorf("Builder's command lacks Planner's handoff:\n%s", builder)
+	}
+	if strings.Contains(planner, "Handoff from") {
+		t.Errorf("Planner has no upstream but got a handoff:\n%s", planner)
+	}
+	for _, s := range []string{planner, builder} {
+		if !strings.Contains(s, "'fix the build'") || !strings.Contains(s, "Command:  (in ") {
+			t.Errorf("command missing the mission:\n%s", s)
+		}
+	}
+}
+
+func TestPrintRaidPlanUnresolvedSlot(t *testing.T) {
+	cfg, pf := raidParty(t, PartySlotConfig{Agent: "Builder"})
+	pf.Slots = append(pf.Slots, PartySlotConfig{Agent: "Stranger"})
+	sections := raidPlanSections(t, cfg, &RosterFile{}, pf)
+	if len(sections) != 3 || !strings.Contains(sections[2], "Error:") || strings.Contains(sections[2], "Command:") {
+		t.Errorf("unresolved slot planned as:\n%s", sections[len(sections)-1])
+	}
+}
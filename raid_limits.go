@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// ── Raid Limits ───────────────────────────────────────────────────
+//
+// A raid can cap how many agents run at once, how long each agent and the
+// whole raid may take, and how many tokens or dollars each agent may
+// spend. An agent that hits a limit is sent SIGTERM, then SIGKILL if it
+// hasn't exited after the grace period. The limit is recorded on the slot
+// so the summary and reports say which one stopped it.
+
+// Limits that can stop an agent or a raid, as reported in "limit" fields.
+const (
+	LimitAgentTimeout = "agent_timeout"
+	LimitRaidTimeout  = "raid_timeout"
+	LimitTokens       = "token_budget"
+	LimitCost         = "cost_budget"
+	LimitInterrupted  = "interrupted" // the raid got SIGINT/SIGTERM
+	LimitStopped      = "stopped"     // stopped through the control API
+)
+
+// defaultStopGrace is how long a stopped agent gets to exit after SIGTERM.
+const defaultStopGrace = 10 * time.Second
+
+// budgetCheckInterval is how often running agents' usage is checked
+// against --max-tokens and --max-cost.
+var budgetCheckInterval = time.Second
+
+// raidLimits are the raid's --max-parallel, timeout and budget flags.
+// Zero values mean no limit.
+type raidLimits struct {
+	MaxParallel  int
+	AgentTimeout time.Duration
+	RaidTimeout  time.Duration
+	MaxTokens    int     // per agent, every token processed (tokenUsage.Total)
+	MaxCostUSD   float64 // per agent
+	Grace        time.Duration
+}
+
+// limitStop is a request to stop one agent, and why.
+type limitStop struct {
+	limit  string
+	detail string
+}
+
+// acquire takes a run slot under --max-parallel, waiting for one to free
+// up. It returns false if the raid is aborted first.
+func (r *raid) acquire() bool {
+	if r.running == nil {
+		return true
+	}
+	select {
+	case r.running <- struct{}{}:
+		return true
+	case <-r.stop:
+		return false
+	}
+}
+
+func (r *raid) release() {
+	if r.running != nil {
+		<-r.running
+	}
+}
+
+// watchSlot stops s's agent when it hits a limit, is stopped through the
+// API or the raid is aborted, and returns once the process has exited
+// (exited is closed). The limit is recorded on s before returning.
+func (r *raid) watchSlot(s *raidSlot, proc *os.Process, exited <-chan struct{}, prompt string) {
+	var timeout <-chan time.Time
+	if r.limits.AgentTimeout > 0 {
+		t := time.NewTimer(r.limits.AgentTimeout)
+		defer t.Stop()
+		timeout = t.C
+	}
+	var check <-chan time.Time
+	if r.limits.MaxTokens > 0 || r.limits.MaxCostUSD > 0 {
+		t := time.NewTicker(budgetCheckInterval)
+		defer t.Stop()
+		check = t.C
+	}
+
+	var why limitStop
+	for why.limit == "" {
+		select {
+		case <-exited:
+			return
+		case <-timeout:
+			why = limitStop{LimitAgentTimeout, fmt.Sprintf("agent timeout %s exceeded", r.limits.AgentTimeout)}
+		case <-check:
+			why = r.overBudget(s, prompt)
+		case why = <-s.stopReq:
+		case <-r.stop:
+			r.mu.Lock()
+			why = r.stopWhy
+			r.mu.Unlock()
+		}
+	}
+
+	s.Limit = why.limit
+	s.limitErr = fmt.Errorf("stopped: %s", why.detail)
+	if why.limit != LimitInterrupted && why.limit != LimitRaidTimeout {
+		fmt.Fprintf(r.console, "   [%d] %s: %s, stopping\n", s.Index, s.Agent, why.detail)
+		r.events.emit("agent_limit", map[string]any{
+			"index":  s.Index,
+			"agent":  s.Agent,
+			"limit":  why.limit,
+			"detail": why.detail,
+		})
+	}
+	terminate(proc, exited, r.limits.Grace)
+}
+
+// overBudget checks s's usage so far against the token and cost budgets.
+func (r *raid) overBudget(s *raidSlot, prompt string) limitStop {
+	u := s.stdout.Usage(prompt)
+	if max := r.limits.MaxTokens; max > 0 && u.Total() > max {
+		return limitStop{LimitTokens, fmt.Sprintf("token budget %s exceeded (%s used)",
+			formatTokens(max), formatTokens(u.Total()))}
+	}
+	if max := r.limits.MaxCostUSD; max > 0 {
+		if cost, ok := s.Backend.Cost(u); ok && cost > max {
+			return limitStop{LimitCost, fmt.Sprintf("cost budget $%.2f exceeded ($%.2f spent)", max, cost)}
+		}
+	}
+	return limitStop{}
+}
+
+// terminate sends SIGTERM and escalates to SIGKILL if the process is still
+// running after grace. It returns once exited is closed.
+func terminate(proc *os.Process, exited <-chan struct{}, grace time.Duration) {
+	if grace <= 0 {
+		grace = defaultStopGrace
+	}
+	proc.Signal(syscall.SIGTERM)
+	t := time.NewTimer(grace)
+	defer t.Stop()
+	select {
+	case <-exited:
+	case <-t.C:
+		proc.Kill()
+		<-exited
+	}
+}
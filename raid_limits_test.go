@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// spawnSleeper starts a shell that sleeps for a minute and waits until it
+// is running. With ignoreTerm it ignores SIGTERM, so only SIGKILL stops it.
+// The returned channel is closed once the process has exited.
+func spawnSleeper(t *testing.T, ignoreTerm bool) (*exec.Cmd, <-chan struct{}) {
+	t.Helper()
+	script := "echo ready; exec sleep 60"
+	if ignoreTerm {
+		script = "trap '' TERM; " + script
+	}
+	cmd := exec.Command("sh", "-c", script)
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cmd.Process.Kill() })
+	if _, err := bufio.NewReader(out).ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+	return cmd, exited
+}
+
+// killedBy returns the signal that ended cmd, or -1 if it exited normally.
+func killedBy(cmd *exec.Cmd) syscall.Signal {
+	ws := cmd.ProcessState.Sys().(syscall.WaitStatus)
+	if !ws.Signaled() {
+		return -1
+	}
+	return ws.Signal()
+}
+
+func TestTerminateStopsOnSIGTERM(t *testing.T) {
+	cmd, exited := spawnSleeper(t, false)
+	start := time.Now()
+	terminate(cmd.Process, exited, 10*time.Second)
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("terminate took %s for a process that exits on SIGTERM", d)
+	}
+	if sig := killedBy(cmd); sig != syscall.SIGTERM {
+		t.Errorf("process ended by %v, want SIGTERM", sig)
+	}
+}
+
+func TestTerminateEscalatesToSIGKILL(t *testing.T) {
+	cmd, exited := spawnSleeper(t, true)
+	grace := 200 * time.Millisecond
+	start := time.Now()
+	terminate(cmd.Process, exited, grace)
+	if d := time.Since(start); d < grace {
+		t.Errorf("killed after %s, before the %s grace period", d, grace)
+	}
+	if sig := killedBy(cmd); sig != syscall.SIGKILL {
+		t.Errorf("process ended by %v, want SIGKILL", sig)
+	}
+}
+
+// budgetSlot is a slot whose agent has reported usage and cost in its
+// stream-json result.
+func budgetSlot(t *testing.T, result string) *raidSlot {
+	t.Helper()
+	claude, err := (&ForgeConfig{}).Backend("claude")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &raidSlot{Index: 1, Agent: "Builder", Backend: claude,
+		stdout: newHeadlessCapture(HeadlessFormatStreamJSON, nil)}
+	s.stdout.Write([]byte(result))
+	s.stdout.Flush()
+	return s
+}
+
+func TestOverBudget(t *testing.T) {
+	// 1000 tokens in all, $0.25
+	s := budgetSlot(t, `{"type":"result","total_cost_usd":0.25,"usage":{"input_tokens":700,"output_tokens":100,"cache_read_input_tokens":150,"cache_creation_input_tokens":50}}`)
+	tests := []struct {
+		name   string
+		limits raidLimits
+		want   string
+	}{
+		{"no limits", raidLimits{}, ""},
+		{"below token budget", raidLimits{MaxTokens: 1001}, ""},
+		{"at token budget", raidLimits{MaxTokens: 1000}, ""},
+		{"above token budget", raidLimits{MaxTokens: 999}, LimitTokens},
+		{"below cost budget", raidLimits{MaxCostUSD: 0.26}, ""},
+		{"at cost budget", raidLimits{MaxCostUSD: 0.25}, ""},
+		{"above cost budget", raidLimits{MaxCostUSD: 0.24}, LimitCost},
+		{"tokens checked first", raidLimits{MaxTokens: 999, MaxCostUSD: 0.24}, LimitTokens},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &raid{limits: tt.limits}
+			if got := r.overBudget(s, "prompt"); got.limit != tt.want {
+				t.Errorf("limit %q (%s), want %q", got.limit, got.detail, tt.want)
+			}
+		})
+	}
+}
+
+func TestOverBudgetDetail(t *testing.T) {
+	s := budgetSlot(t, `{"type":"result","total_cost_usd":1.5,"usage":{"input_tokens":12000,"output_tokens":345}}`)
+	r := &raid{limits: raidLimits{MaxTokens: 10000}}
+	if got := r.overBudget(s, "prompt").detail; got != "token budget 10K exceeded (12K used)" {
+		t.Errorf("token detail %q", got)
+	}
+	r = &raid{limits: raidLimits{MaxCostUSD: 1}}
+	if got := r.overBudget(s, "prompt").detail; got != "cost budget $1.00 exceeded ($1.50 spent)" {
+		t.Errorf("cost detail %q", got)
+	}
+}
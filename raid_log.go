@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ── Raid Logs ─────────────────────────────────────────────────────
+//
+// Every raid agent's output goes to agent.log in its session directory
+// and, line by line, to a shared console where each line is prefixed with
+// the agent's name in its tint color. With --follow only one agent's
+// output reaches the console; the others still log to their files.
+
+// raidLogFileName is the per-agent log in a raid session directory.
+const raidLogFileName = "agent.log"
+
+// raidConsole serializes everything a raid prints. Progress messages are
+// written to it directly; agent output goes through stream.
+type raidConsole struct {
+	mu     sync.Mutex
+	out    io.Writer
+	follow string // agent whose output is shown; "" = every agent
+	width  int    // widest agent name, to align prefixes
+	render *lipgloss.Renderer
+}
+
+func newRaidConsole(out io.Writer, slots []*raidSlot, follow string) *raidConsole {
+	c := &raidConsole{out: out, follow: follow, render: lipgloss.NewRenderer(out)}
+	for _, s := range slots {
+		c.width = max(c.width, len(s.Agent))
+	}
+	return c
+}
+
+// Write prints p whole, so it never lands in the middle of an agent line.
+func (c *raidConsole) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.out.Write(p)
+}
+
+// stream returns a writer for one of s's output streams. Complete lines
+// are appended to log (if non-nil) and shown on the console; call Flush
+// when the agent exits to emit a trailing partial line.
+func (c *raidConsole) stream(s *raidSlot, log io.Writer) *raidStream {
+	rs := &raidStream{console: c, log: log}
+	switch {
+	case c.follow == "":
+		tint := lipgloss.Color(fmt.Sprintf("#%02x%02x%02x", s.Tint[0], s.Tint[1], s.Tint[2]))
+		name := fmt.Sprintf("%-*s", c.width, s.Agent)
+		rs.prefix = c.render.NewStyle().Foreground(tint).Bold(true).Render(name) + " │ "
+		rs.show = true
+	case strings.EqualFold(c.follow, s.Agent):
+		rs.show = true // a single stream needs no prefix
+	}
+	return rs
+}
+
+// raidStream splits one agent output stream into lines.
+type raidStream struct {
+	console *raidConsole
+	log     io.Writer
+	prefix  string
+	show    bool
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (rs *raidStream) Write(p []byte) (int, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.buf = append(rs.buf, p...)
+	for {
+		i := bytes.IndexByte(rs.buf, '\n')
+		if i < 0 {
+			break
+		}
+		rs.emit(rs.buf[:i])
+		rs.buf = rs.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush emits any output left without a trailing newline.
+func (rs *raidStream) Flush() {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if len(rs.buf) > 0 {
+		rs.emit(rs.buf)
+		rs.buf = nil
+	}
+}
+
+func (rs *raidStream) emit(line []byte) {
+	text := strings.TrimRight(string(line), "\r")
+	if rs.log != nil {
+		io.WriteString(rs.log, text+"\n")
+	}
+	if rs.show {
+		io.WriteString(rs.console, rs.prefix+text+"\n")
+	}
+}
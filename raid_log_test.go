@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func raidLogSlots() []*raidSlot {
+	return []*raidSlot{
+		{Index: 0, Agent: "Builder", Tint: [3]uint8{200, 80, 40}},
+		{Index: 1, Agent: "Scout", Tint: [3]uint8{40, 200, 80}},
+	}
+}
+
+func TestRaidStreamPrefixesLines(t *testing.T) {
+	var out, builderLog strings.Builder
+	slots := raidLogSlots()
+	c := newRaidConsole(&out, slots, "")
+	builder, scout := c.stream(slots[0], &builderLog), c.stream(slots[1], nil)
+
+	// Lines are put together across writes; CRLF loses its CR
+	builder.Write([]byte("hel"))
+	builder.Write([]byte("lo\nwor"))
+	scout.Write([]byte("found it\n"))
+	builder.Write([]byte("ld\r\n\npartial"))
+	want := "Builder │ hello\n" +
+		"Scout   │ found it\n" +
+		"Builder │ world\n" +
+		"Builder │ \n"
+	if out.String() != want {
+		t.Errorf("console before flush:\n%q\nwant\n%q", out.String(), want)
+	}
+
+	builder.Flush()
+	builder.Flush() // nothing left the second time
+	scout.Flush()
+	want += "Builder │ partial\n"
+	if out.String() != want {
+		t.Errorf("console after flush:\n%q\nwant\n%q", out.String(), want)
+	}
+	if got := builderLog.String(); got != "hello\nworld\n\npartial\n" {
+		t.Errorf("Builder's log %q", got)
+	}
+}
+
+func TestRaidStreamFollow(t *testing.T) {
+	var out, builderLog strings.Builder
+	slots := raidLogSlots()
+	c := newRaidConsole(&out, slots, "scout")
+	builder, scout := c.stream(slots[0], &builderLog), c.stream(slots[1], nil)
+
+	builder.Write([]byte("hidden\n"))
+	scout.Write([]byte("shown\nlast"))
+	scout.Flush()
+	fmt.Fprintln(c, "raid finished")
+
+	if got := out.String(); got != "shown\nlast\nraid finished\n" {
+		t.Errorf("console %q, want only Scout's lines, unprefixed", got)
+	}
+	if builderLog.String() != "hidden\n" {
+		t.Errorf("an agent not followed still logs; got %q", builderLog.String())
+	}
+}
+
+func TestRaidStreamConcurrentLinesStayWhole(t *testing.T) {
+	var out strings.Builder
+	slots := raidLogSlots()
+	c := newRaidConsole(&out, slots, "")
+	var wg sync.WaitGroup
+	for _, s := range slots {
+		rs := c.stream(s, nil)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				// Each line arrives in two writes
+				fmt.Fprintf(rs, "%s line ", s.Agent)
+				fmt.Fprintf(rs, "%d\n", i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 400 {
+		t.Fatalf("%d lines, want 400", len(lines))
+	}
+	next := map[string]int{}
+	for _, line := range lines {
+		prefix, text, ok := strings.Cut(line, " │ ")
+		agent := strings.TrimSpace(prefix)
+		if !ok || text != fmt.Sprintf("%s line %d", agent, next[agent]) {
+			t.Fatalf("mangled or out-of-order line %q", line)
+		}
+		next[agent]++
+	}
+}
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ── Raid Reports ──────────────────────────────────────────────────
+//
+// For CI a raid can write a machine-readable report when it finishes
+// (--report json|junit --out <file>) and stream NDJSON events while it runs
+// (--events-fd <n>, e.g. `3>events.ndjson`). Both describe each agent with
+// the same raidAgentReport.
+
+// Report formats for raid --report.
+const (
+	ReportJSON  = "json"
+	ReportJUnit = "junit"
+)
+
+// raidAgentReport is one agent's outcome as reported to CI.
+type raidAgentReport struct {
+	Index        int        `json:"index"`
+	Agent        string     `json:"agent"`
+	Class        string     `json:"class,omitempty"`
+	Backend      string     `json:"backend,omitempty"`
+	AgentID      string     `json:"agent_id,omitempty"`
+	After        []string   `json:"after,omitempty"`
+	Status       string     `json:"status"` // passed, failed, skipped
+	Error        string     `json:"error,omitempty"`
+	Limit        string     `json:"limit,omitempty"` // the limit that stopped or skipped it
+	ExitCode     int        `json:"exit_code"`
+	Started      *time.Time `json:"started,omitempty"`
+	Finished     *time.Time `json:"finished,omitempty"`
+	Duration     float64    `json:"duration_seconds"`
+	Worktree     string     `json:"worktree,omitempty"`
+	Branch       string     `json:"branch,omitempty"`
+	FilesChanged []string   `json:"files_changed"`
+	Tokens       tokenUsage `json:"tokens"`
+	SessionDir   string     `json:"session_dir,omitempty"`
+	Result       string     `json:"result,omitempty"`
+}
+
+// raidReport is the JSON summary of a whole raid.
+type raidReport struct {
+	Party    string            `json:"party"`
+	Project  string            `json:"project"`
+	Mission  string            `json:"mission"`
+	Status   string            `json:"status"`          // passed, failed, aborted
+	Limit    string            `json:"limit,omitempty"` // why an aborted raid stopped
+	Started  time.Time         `json:"started"`
+	Finished time.Time         `json:"finished"`
+	Duration float64           `json:"duration_seconds"`
+	Passed   int               `json:"passed"`
+	Failed   int               `json:"failed"`
+	Skipped  int               `json:"skipped"`
+	Tokens   tokenUsage        `json:"tokens"`
+	Agents   []raidAgentReport `json:"agents"`
+}
+
+// status classifies a finished slot.
+func (s *raidSlot) status() string {
+	switch {
+	case s.Skipped:
+		return "skipped"
+	case s.Err != nil:
+		return "failed"
+	}
+	return "passed"
+}
+
+func (s *raidSlot) report() raidAgentReport {
+	rep := raidAgentReport{
+		Index:        s.Index,
+		Agent:        s.Agent,
+		Class:        s.Class,
+		AgentID:      s.AgentID,
+		After:        s.After,
+		Status:       s.status(),
+		Limit:        s.Limit,
+		ExitCode:     s.ExitCode,
+		Worktree:     s.Worktree,
+		Branch:       s.Branch,
+		FilesChanged: s.FilesChanged,
+		Tokens:       s.Usage,
+		SessionDir:   s.SessionDir,
+		Result:       s.Result,
+	}
+	if rep.FilesChanged == nil {
+		rep.FilesChanged = []string{}
+	}
+	if s.Backend != nil {
+		rep.Backend = s.Backend.Name()
+	}
+	if s.Err != nil {
+		rep.Error = s.Err.Error()
+	}
+	if !s.Started.IsZero() {
+		started, finished := s.Started, s.Finished
+		rep.Started, rep.Finished = &started, &finished
+		rep.Duration = finished.Sub(started).Seconds()
+	}
+	return rep
+}
+
+// buildReport summarizes every slot of r.
+func (r *raid) buildReport(started, finished time.Time, aborted bool) raidReport {
+	rep := raidReport{
+		Party:    r.party,
+		Project:  r.project,
+		Mission:  r.mission,
+		Status:   "passed",
+		Started:  started,
+		Finished: finished,
+		Duration: finished.Sub(started).Seconds(),
+	}
+	for _, s := range r.slots {
+		a := s.report()
+		switch a.Status {
+		case "passed":
+			rep.Passed++
+		case "failed":
+			rep.Failed++
+		case "skipped":
+			rep.Skipped++
+		}
+		rep.Tokens.Input += a.Tokens.Input
+		rep.Tokens.Output += a.Tokens.Output
+		rep.Tokens.CacheRead += a.Tokens.CacheRead
+		rep.Tokens.CacheWrite += a.Tokens.CacheWrite
+		rep.Tokens.CostUSD += a.Tokens.CostUSD
+		rep.Tokens.Estimated = rep.Tokens.Estimated || a.Tokens.Estimated
+		rep.Agents = append(rep.Agents, a)
+	}
+	switch {
+	case aborted:
+		rep.Status = "aborted"
+		rep.Limit = r.stopWhy.limit
+	case rep.Failed+rep.Skipped > 0:
+		rep.Status = "failed"
+	}
+	return rep
+}
+
+// writeRaidReport writes rep in format to path ("-" or "" = stdout).
+func writeRaidReport(rep raidReport, format, path string) error {
+	var data []byte
+	var err error
+	switch format {
+	case ReportJSON:
+		data, err = json.MarshalIndent(rep, "", "  ")
+	case ReportJUnit:
+		data, err = junitReport(rep)
+	default:
+		return fmt.Errorf("unknown report format %q (want json or junit)", format)
+	}
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if path == "" || path == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// ── JUnit ─────────────────────────────────────────────────────────
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Props     []junitProperty `xml:"properties>property,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Body    string `xml:",chardata"`
+}
+
+// junitReport renders rep with one test case per agent, so CI systems
+// show each agent as a passing, failing or skipped test.
+func junitReport(rep raidReport) ([]byte, error) {
+	suite := junitTestSuite{
+		Name:      "raid." + rep.Party,
+		Tests:     len(rep.Agents),
+		Failures:  rep.Failed,
+		Skipped:   rep.Skipped,
+		Time:      fmt.Sprintf("%.3f", rep.Duration),
+		Timestamp: rep.Started.Format("2006-01-02T15:04:05"),
+		Props: []junitProperty{
+			{Name: "mission", Value: rep.Mission},
+			{Name: "project", Value: rep.Project},
+			{Name: "status", Value: rep.Status},
+		},
+	}
+	for _, a := range rep.Agents {
+		className := "raid." + rep.Party
+		if a.Class != "" {
+			className += "." + strings.ReplaceAll(a.Class, " ", "_")
+		}
+		tc := junitTestCase{
+			Name:      a.Agent,
+			ClassName: className,
+			Time:      fmt.Sprintf("%.3f", a.Duration),
+			SystemOut: agentSummaryText(a),
+		}
+		switch a.Status {
+		case "failed":
+			tc.Failure = &junitMessage{Message: a.Error, Type: a.Limit, Body: a.Result}
+		case "skipped":
+			tc.Skipped = &junitMessage{Message: a.Error}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	out, err := xml.MarshalIndent(junitTestSuites{Suites: []junitTestSuite{suite}}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// agentSummaryText is the system-out block for a JUnit test case.
+func agentSummaryText(a raidAgentReport) string {
+	var b strings.Builder
+	if a.Branch != "" {
+		fmt.Fprintf(&b, "branch: %s\n", a.Branch)
+	}
+	fmt.Fprintf(&b, "tokens: %d in / %d out", a.Tokens.Input, a.Tokens.Output)
+	if a.Tokens.Estimated {
+		b.WriteString(" (estimated)")
+	}
+	b.WriteByte('\n')
+	if len(a.FilesChanged) > 0 {
+		fmt.Fprintf(&b, "files changed:\n  %s\n", strings.Join(a.FilesChanged, "\n  "))
+	}
+	if a.Result != "" {
+		fmt.Fprintf(&b, "\n%s\n", a.Result)
+	}
+	return b.String()
+}
+
+// ── NDJSON Events ─────────────────────────────────────────────────
+
+// raidEvents writes one JSON object per line. A nil *raidEvents drops
+// everything, so callers don't need to check whether --events-fd was set.
+type raidEvents struct {
+	mu sync.Mutex
+	w  io.WriteCloser
+}
+
+// openRaidEvents opens the NDJSON stream on an inherited file descriptor.
+func openRaidEvents(fd int) (*raidEvents, error) {
+	f := os.NewFile(uintptr(fd), fmt.Sprintf("fd%d", fd))
+	if f == nil {
+		return nil, fmt.Errorf("--events-fd %d: not a valid file descriptor", fd)
+	}
+	if _, err := f.Stat(); err != nil {
+		return nil, fmt.Errorf("--events-fd %d: %w", fd, err)
+	}
+	return &raidEvents{w: f}, nil
+}
+
+// emit writes event with fields, stamped with the current time.
+func (e *raidEvents) emit(event string, fields map[string]any) {
+	if e == nil {
+		return
+	}
+	obj := map[string]any{"time": time.Now().Format(time.RFC3339Nano), "event": event}
+	for k, v := range fields {
+		obj[k] = v
+	}
+	line, err := json.Marshal(obj)
+	if err != nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.w.Write(append(line, '\n'))
+}
+
+func (e *raidEvents) Close() {
+	if e != nil {
+		e.w.Close()
+	}
+}
+
+// ── Git Helpers ───────────────────────────────────────────────────
+
+// gitHead returns the commit checked out in dir, or "" outside git.
+func gitHead(dir string) string {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// changedFiles lists files in dir that differ from base: committed and
+// uncommitted changes plus new untracked files.
+func changedFiles(dir, base string) []string {
+	if base == "" {
+		return nil
+	}
+	seen := make(map[string]bool)
+	for _, args := range [][]string{
+		{"diff", "--name-only", base},
+		{"ls-files", "--others", "--exclude-standard"},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+		if err != nil {
+			continue
+		}
+		for _, f := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+			if f != "" {
+				seen[f] = true
+			}
+		}
+	}
+	files := make([]string, 0, len(seen))
+	for f := range seen {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// exitCode extracts a process exit status from a Wait error: 0 on
+// success, -1 when the agent never ran or was killed by a signal.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var ee *exec.ExitError
+	if errors.As(err, &ee) {
+		return ee.ExitCode()
+	}
+	return -1
+}
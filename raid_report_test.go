@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// finishedRaid is a raid whose three agents passed, failed on a limit, and
+// were skipped.
+func finishedRaid() *raid {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	claude, _ := (&ForgeConfig{}).Backend("claude")
+	return &raid{
+		party:   "ci",
+		project: "/src/app",
+		mission: "fix the build",
+		slots: []*raidSlot{
+			{Index: 1, Agent: "Planner", Class: "planner", Backend: claude,
+				Started: start, Finished: start.Add(90 * time.Second),
+				Branch: "forge/ci/planner", FilesChanged: []string{"PLAN.md"},
+				Usage: tokenUsage{Input: 1000, Output: 200, CostUSD: 0.5}, Result: "Plan written."},
+			{Index: 2, Agent: "Builder", Class: "senior dev", After: []string{"Planner"}, Backend: claude,
+				Started: start.Add(90 * time.Second), Finished: start.Add(150 * time.Second),
+				Err: errors.New("token budget exceeded"), Limit: LimitTokens, ExitCode: -1,
+				Usage: tokenUsage{Input: 5000, Output: 900, Estimated: true}, Result: "partial"},
+			{Index: 3, Agent: "Reviewer", After: []string{"Builder"}, Skipped: true,
+				Err: errors.New("Builder failed"), ExitCode: -1},
+		},
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	r := finishedRaid()
+	start := r.slots[0].Started
+	rep := r.buildReport(start, start.Add(3*time.Minute), false)
+	if rep.Status != "failed" || rep.Passed != 1 || rep.Failed != 1 || rep.Skipped != 1 {
+		t.Errorf("status %s, %d/%d/%d passed/failed/skipped", rep.Status, rep.Passed, rep.Failed, rep.Skipped)
+	}
+	if rep.Duration != 180 {
+		t.Errorf("duration %v", rep.Duration)
+	}
+	wantTokens := tokenUsage{Input: 6000, Output: 1100, CostUSD: 0.5, Estimated: true}
+	if rep.Tokens != wantTokens {
+		t.Errorf("tokens %+v, want %+v", rep.Tokens, wantTokens)
+	}
+	if a := rep.Agents[2]; a.Started != nil || a.Duration != 0 || a.Backend != "" {
+		t.Errorf("skipped agent has timing or backend: %+v", a)
+	}
+
+	r.stopWhy = limitStop{limit: LimitRaidTimeout}
+	if rep := r.buildReport(start, start, true); rep.Status != "aborted" || rep.Limit != LimitRaidTimeout {
+		t.Errorf("aborted raid reported as %s (%s)", rep.Status, rep.Limit)
+	}
+}
+
+func TestWriteRaidReportJSON(t *testing.T) {
+	r := finishedRaid()
+	start := r.slots[0].Started
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := writeRaidReport(r.buildReport(start, start.Add(3*time.Minute), false), ReportJSON, path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got struct {
+		Party  string `json:"party"`
+		Status string `json:"status"`
+		Agents []struct {
+			Agent        string    `json:"agent"`
+			Status       string    `json:"status"`
+			Limit        string    `json:"limit"`
+			Backend      string    `json:"backend"`
+			Duration     float64   `json:"duration_seconds"`
+			FilesChanged *[]string `json:"files_changed"`
+		} `json:"agents"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("%v\n%s", err, data)
+	}
+	if got.Party != "ci" || got.Status != "failed" || len(got.Agents) != 3 {
+		t.Fatalf("report %+v", got)
+	}
+	planner, builder, reviewer := got.Agents[0], got.Agents[1], got.Agents[2]
+	if planner.Status != "passed" || planner.Backend != "claude" || planner.Duration != 90 {
+		t.Errorf("Planner %+v", planner)
+	}
+	if builder.Status != "failed" || builder.Limit != LimitTokens {
+		t.Errorf("Builder %+v", builder)
+	}
+	if reviewer.Status != "skipped" || reviewer.FilesChanged == nil || len(*reviewer.FilesChanged) != 0 {
+		t.Errorf("Reviewer %+v; files_changed must be [] rather than null", reviewer)
+	}
+
+	if err := writeRaidReport(raidReport{}, "yaml", path); err == nil {
+		t.Error("unknown report format accepted")
+	}
+}
+
+func TestJUnitReport(t *testing.T) {
+	r := finishedRaid()
+	start := r.slots[0].Started
+	data, err := junitReport(r.buildReport(start, start.Add(3*time.Minute), false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got junitTestSuites
+	if err := xml.Unmarshal(data, &got); err != nil {
+		t.Fatalf("%v\n%s", err, data)
+	}
+	if len(got.Suites) != 1 {
+		t.Fatalf("%d suites", len(got.Suites))
+	}
+	suite := got.Suites[0]
+	if suite.Name != "raid.ci" || suite.Tests != 3 || suite.Failures != 1 || suite.Skipped != 1 || suite.Time != "180.000" {
+		t.Errorf("suite %s: %d tests, %d failures, %d skipped, time %s",
+			suite.Name, suite.Tests, suite.Failures, suite.Skipped, suite.Time)
+	}
+	planner, builder, reviewer := suite.Cases[0], suite.Cases[1], suite.Cases[2]
+	if planner.Failure != nil || planner.Skipped != nil || planner.ClassName != "raid.ci.planner" {
+		t.Errorf("Planner %+v", planner)
+	}
+	if builder.ClassName != "raid.ci.senior_dev" {
+		t.Errorf("Builder class name %q", builder.ClassName)
+	}
+	if f := builder.Failure; f == nil || f.Message != "token budget exceeded" || f.Type != LimitTokens || f.Body != "partial" {
+		t.Errorf("Builder failure %+v", f)
+	}
+	if s := reviewer.Skipped; s == nil || s.Message != "Builder failed" {
+		t.Errorf("Reviewer skipped %+v", s)
+	}
+	want := "branch: forge/ci/planner\ntokens: 1000 in / 200 out\nfiles changed:\n  PLAN.md\n\nPlan written.\n"
+	if planner.SystemOut != want {
+		t.Errorf("system-out %q, want %q", planner.SystemOut, want)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// raidParty is a party of slots with the given dependencies, against a
+// config that defines every agent named.
+func raidParty(t *testing.T, slots ...PartySlotConfig) (*ForgeConfig, *PartyFile) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	cfg := &ForgeConfig{}
+	for _, s := range slots {
+		cfg.Agents = append(cfg.Agents, AgentConfig{Name: s.Agent, Class: "developer"})
+	}
+	return cfg, &PartyFile{Name: "raid", Slots: slots}
+}
+
+func TestPlanRaidWiresDependencies(t *testing.T) {
+	cfg, pf := raidParty(t,
+		PartySlotConfig{Agent: "Planner"},
+		PartySlotConfig{Agent: "Builder", After: []string{"Planner"}},
+		PartySlotConfig{Agent: "Reviewer", After: []string{"Planner", "Builder"}},
+	)
+	slots, err := planRaid(cfg, pf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(slots) != 3 {
+		t.Fatalf("%d slots, want 3", len(slots))
+	}
+	planner, builder, reviewer := slots[0], slots[1], slots[2]
+	if len(planner.upstream) != 0 {
+		t.Errorf("Planner has upstream %v", planner.upstream)
+	}
+	if len(builder.upstream) != 1 || builder.upstream[0] != planner {
+		t.Error("Builder does not wait for Planner")
+	}
+	if len(reviewer.upstream) != 2 || reviewer.upstream[0] != planner || reviewer.upstream[1] != builder {
+		t.Error("Reviewer does not wait for Planner and Builder")
+	}
+	for _, s := range slots {
+		if s.Err != nil || s.Backend == nil || s.AgentID == "" {
+			t.Errorf("%s not resolved: err %v", s.Agent, s.Err)
+		}
+	}
+}
+
+func TestPlanRaidRejectsBadDependencies(t *testing.T) {
+	tests := []struct {
+		name  string
+		slots []PartySlotConfig
+		want  string
+	}{
+		{"cycle", []PartySlotConfig{
+			{Agent: "A", After: []string{"C"}},
+			{Agent: "B", After: []string{"A"}},
+			{Agent: "C", After: []string{"B"}},
+		}, "dependency cycle A → B → C → A"},
+		{"self", []PartySlotConfig{{Agent: "A", After: []string{"A"}}}, "A runs after itself"},
+		{"unknown", []PartySlotConfig{{Agent: "A", After: []string{"Ghost"}}}, "Ghost, which is not in a slot"},
+		{"duplicate", []PartySlotConfig{{Agent: "A"}, {Agent: "A"}}, "more than one slot"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, pf := raidParty(t, tt.slots...)
+			_, err := planRaid(cfg, pf)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanRaidKeepsUnresolvedSlots(t *testing.T) {
+	cfg, pf := raidParty(t, PartySlotConfig{Agent: "Builder"})
+	pf.Slots = append(pf.Slots, PartySlotConfig{Agent: "Stranger", After: []string{"Builder"}})
+	slots, err := planRaid(cfg, pf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slots[1].Err == nil {
+		t.Error("slot for an unknown agent has no error")
+	}
+}
+
+func TestFindRaidCycle(t *testing.T) {
+	a, b, c := &raidSlot{Agent: "A"}, &raidSlot{Agent: "B"}, &raidSlot{Agent: "C"}
+	b.upstream = []*raidSlot{a}
+	c.upstream = []*raidSlot{a, b}
+	if cycle := findRaidCycle([]*raidSlot{a, b, c}); cycle != nil {
+		t.Errorf("diamond reported as cycle %v", cycle)
+	}
+	a.upstream = []*raidSlot{c}
+	cycle := findRaidCycle([]*raidSlot{a, b, c})
+	if len(cycle) < 3 || cycle[0] != cycle[len(cycle)-1] {
+		t.Errorf("cycle %v, want a closed path", cycle)
+	}
+}
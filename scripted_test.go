@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/vt"
+)
+
+// ── Scripted Launcher ─────────────────────────────────────────────
+//
+// ScriptedLauncher runs a fake agent in a real PTY instead of an agent CLI,
+// so the full launch → output → exit → checkout path can be exercised
+// without claude installed. The fake agent is the test binary itself, run
+// with a fake-agent argument that TestMain hands to runFakeAgent: either a
+// tiny REPL or an asciicast replay.
+
+// ScriptedLauncher is an AgentLauncher backed by the fake agent.
+type ScriptedLauncher struct {
+	Cast   string  // replay this asciicast recording instead of the REPL
+	Speed  float64 // replay speed multiplier; 0 = real time
+	Binary string  // fake agent executable; empty = the test binary
+}
+
+func (l ScriptedLauncher) Launch(cfg *ForgeConfig, lc LaunchConfig) tea.Cmd {
+	return func() tea.Msg {
+		backend, err := cfg.Backend(lc.Backend)
+		if err != nil {
+			return AgentExitedMsg{ID: lc.ID, Err: err}
+		}
+
+		bin := l.Binary
+		if bin == "" {
+			if bin, err = os.Executable(); err != nil {
+				return AgentExitedMsg{ID: lc.ID, Err: err}
+			}
+		}
+		args := []string{"fake-agent"}
+		if l.Cast != "" {
+			args = append(args, "--cast", l.Cast)
+			if l.Speed > 0 {
+				args = append(args, "--speed", strconv.FormatFloat(l.Speed, 'g', -1, 64))
+			}
+		}
+
+		dir := lc.ProjectDir
+		if dir == "" {
+			dir = "."
+		}
+		// Recorded like a real launch, so scrollback and replay work
+		sessionDir := newSessionDir(lc.ID)
+		proc, err := AgentCommand{
+			Binary: bin,
+			Args:   args,
+			Dir:    dir,
+			Env:    append(os.Environ(), "TERM=xterm-256color"),
+			Cols:   lc.Cols,
+			Rows:   lc.Rows,
+
+			CastPath:  filepath.Join(sessionDir, castFileName),
+			CastTitle: lc.ID,
+		}.start()
+		if err != nil {
+			return AgentExitedMsg{ID: lc.ID, Err: err}
+		}
+
+		return AgentStartedMsg{
+			ID:         lc.ID,
+			Proc:       proc,
+			Emulator:   vt.NewSafeEmulator(lc.Cols, lc.Rows),
+			Backend:    backend,
+			SessionDir: sessionDir,
+			Handoff:    lc.HandoffContext,
+		}
+	}
+}
+
+// ── Fake Agent ────────────────────────────────────────────────────
+
+// fakeAgentReady is printed once the fake agent accepts input.
+const fakeAgentReady = "fake agent ready"
+
+// TestMain runs the fake agent when ScriptedLauncher starts the test
+// binary as one, and the tests otherwise.
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 && os.Args[1] == "fake-agent" {
+		if err := runFakeAgent(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runFakeAgent is the fake agent's main.
+//
+// REPL mode prints a claude-style context line and echoes each input line
+// as "echo: <line>". "exit [code]" quits with that status. "ask <command>"
+// shows a claude-style permission prompt for command; an empty line
+// approves it and anything else denies it. "lines N" prints N numbered lines.
+// With --cast it replays a recording's output with its original timing
+// (scaled by --speed) and exits with the recorded status.
+func runFakeAgent(args []string) error {
+	var castPath string
+	speed := 1.0
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--cast":
+			if i+1 < len(args) {
+				castPath = args[i+1]
+				i++
+			}
+		case "--speed":
+			if i+1 < len(args) {
+				speed, _ = strconv.ParseFloat(args[i+1], 64)
+				i++
+			}
+		}
+	}
+	if speed <= 0 {
+		speed = 1
+	}
+
+	if castPath != "" {
+		return replayFakeAgent(castPath, speed)
+	}
+
+	fmt.Println(fakeAgentReady)
+	fmt.Println("1.2K/200K tokens")
+	fmt.Print("> ")
+	sc := bufio.NewScanner(os.Stdin)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if code, ok := strings.CutPrefix(line, "exit"); ok {
+			n, _ := strconv.Atoi(strings.TrimSpace(code))
+			os.Exit(n)
+		}
+		if count, ok := strings.CutPrefix(line, "lines "); ok {
+			n, _ := strconv.Atoi(count)
+			for i := 1; i <= n; i++ {
+				fmt.Printf("line %d\n", i)
+			}
+			fmt.Print("> ")
+			continue
+		}
+		if command, ok := strings.CutPrefix(line, "ask "); ok {
+			fmt.Printf("Bash command\n  %s\nDo you want to proceed?\n❯ 1. Yes\n  2. No (esc)\n", command)
+			answer := "denied"
+			if sc.Scan() && strings.TrimSpace(sc.Text()) == "" {
+				answer = "approved"
+			}
+			// Like claude, the prompt is replaced by the outcome
+			fmt.Printf("\x1b[2J\x1b[H%s: %s\n> ", answer, command)
+			continue
+		}
+		fmt.Printf("echo: %s\n> ", line)
+	}
+	return nil
+}
+
+func replayFakeAgent(path string, speed float64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	start := time.Now()
+	exit := "0"
+	err = readCastEvents(f, nil, func(ev castEvent) {
+		due := time.Duration(ev.Time / speed * float64(time.Second))
+		time.Sleep(due - time.Since(start))
+		switch ev.Code {
+		case "o":
+			os.Stdout.WriteString(ev.Data)
+		case "m":
+			if s, ok := strings.CutPrefix(ev.Data, "exit: "); ok {
+				exit = s
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if exit != "0" {
+		os.Exit(1)
+	}
+	return nil
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ── Agent Search ──────────────────────────────────────────────────
+//
+// f opens a search over the output of every agent in every party, slots
+// and bench: the scrollback of running agents (replayed from their session
+// recordings), the transcripts of exited ones, and the saved transcripts
+// of each agent's earlier sessions. The text is loaded in the background
+// when the search opens; matching then runs on each keystroke, with the
+// same smartcase rule as copy mode. Enter opens the match in copy mode on
+// that agent, and leaving copy mode returns to the results.
+
+// searchPastSessions is how many earlier sessions per agent are searched.
+const searchPastSessions = 5
+
+// searchMaxHits caps the result list; a narrower query finds the rest.
+const searchMaxHits = 500
+
+// searchSource is one session's text. Everything that reads the agent is
+// captured when the search opens, so loading can run off the UI goroutine.
+type searchSource struct {
+	inst    *AgentInstance
+	party   string
+	session string // "live", "latest", or when an earlier session started
+	past    bool   // an earlier session, not the agent's current one
+	dir     string
+	lines   []string
+	screen  []string // fallback when the session has no recording
+}
+
+// searchHit is a match: line and rune column in its source's lines.
+type searchHit struct {
+	src       *searchSource
+	line, col int
+}
+
+// agentSearch is the state of the search palette.
+type agentSearch struct {
+	input   string
+	sources []*searchSource
+	loading bool
+	hits    []searchHit
+	capped  bool // more than searchMaxHits matched
+	cursor  int
+}
+
+// searchCorpusMsg delivers the loaded sessions to the search that asked.
+type searchCorpusMsg struct {
+	search  *agentSearch
+	sources []*searchSource
+}
+
+// searchSources captures what every agent's current session has to offer.
+func (m Model) searchSources() []*searchSource {
+	var sources []*searchSource
+	for _, p := range m.parties {
+		agents := append(p.Slots[:], p.Bench...)
+		for _, inst := range agents {
+			if inst == nil {
+				continue
+			}
+			src := &searchSource{inst: inst, party: p.Name, session: "latest", dir: inst.sessionDir}
+			switch {
+			case inst.emulator != nil:
+				src.session = "live"
+				src.screen = screenLines(inst.emulator)
+			case len(inst.Transcript) > 0:
+				src.lines = inst.Transcript
+			default:
+				src.screen = trimScreenLines(strings.Split(inst.LastOutput, "\n"))
+			}
+			sources = append(sources, src)
+		}
+	}
+	return sources
+}
+
+// loadSearchCorpus fills in each source's lines and adds the agents'
+// earlier sessions that have a saved transcript.
+func loadSearchCorpus(search *agentSearch, sources []*searchSource) tea.Cmd {
+	return func() tea.Msg {
+		entries, _ := os.ReadDir(sessionsDir())
+		var loaded []*searchSource
+		for _, src := range sources {
+			if src.lines == nil && src.dir != "" {
+				if rec, err := castScrollback(filepath.Join(src.dir, castFileName)); err == nil && len(rec) > 0 {
+					src.lines = rec
+				}
+			}
+			if src.lines == nil {
+				src.lines = src.screen
+			}
+			if len(src.lines) > 0 {
+				loaded = append(loaded, src)
+			}
+
+			// Session directories sort oldest first by name; take the newest
+			found := 0
+			for i := len(entries) - 1; i >= 0 && found < searchPastSessions; i-- {
+				sm := sessionDirName.FindStringSubmatch(entries[i].Name())
+				dir := filepath.Join(sessionsDir(), entries[i].Name())
+				if sm == nil || sm[1] != src.inst.ID || dir == src.dir {
+					continue
+				}
+				data, err := os.ReadFile(filepath.Join(dir, transcriptFileName))
+				if err != nil {
+					continue
+				}
+				found++
+				started, _ := time.ParseInLocation("20060102-150405", sm[2], time.Local)
+				loaded = append(loaded, &searchSource{
+					inst:    src.inst,
+					party:   src.party,
+					session: started.Format("Jan 2 15:04"),
+					past:    true,
+					dir:     dir,
+					lines:   strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"),
+				})
+			}
+		}
+		return searchCorpusMsg{search: search, sources: loaded}
+	}
+}
+
+func (m Model) handleSearchCorpus(msg searchCorpusMsg) (tea.Model, tea.Cmd) {
+	if m.agentSearch != msg.search {
+		return m, nil // closed or reopened since
+	}
+	s := m.agentSearch
+	s.sources = msg.sources
+	s.loading = false
+	s.find()
+	return m, nil
+}
+
+// find collects the matches of the current input, in source order.
+func (s *agentSearch) find() {
+	s.hits, s.capped, s.cursor = nil, false, 0
+	if s.input == "" {
+		return
+	}
+	pat, fold := searchPattern(s.input)
+	for _, src := range s.sources {
+		for li, l := range src.lines {
+			line := []rune(l)
+			for col := range line {
+				if !matchAt(line, col, pat, fold) {
+					continue
+				}
+				if len(s.hits) == searchMaxHits {
+					s.capped = true
+					return
+				}
+				s.hits = append(s.hits, searchHit{src: src, line: li, col: col})
+				break // one hit per line
+			}
+		}
+	}
+}
+
+func (m Model) openAgentSearch() (Model, tea.Cmd) {
+	s := &agentSearch{loading: true}
+	m.agentSearch = s
+	m.pushMode(ModeSearch)
+	return m, loadSearchCorpus(s, m.searchSources())
+}
+
+// openSearchHit shows a match in copy mode, on top of the search.
+func (m Model) openSearchHit(h searchHit) Model {
+	m.selectAgent(h.src.inst)
+	m.focus = FocusMainPane
+
+	cm := newCopyMode(h.src.inst, h.src.lines)
+	if h.src.past {
+		cm.session = h.src.session
+	}
+	cm.pattern = m.agentSearch.input
+	cm.cursor = copyPos{Line: h.line, Col: h.col}
+	cm.top = max(h.line-m.copyViewHeight()/2, 0)
+	m.scrollback = cm
+	m.pushMode(ModeCopy)
+	return m
+}
+
+// ── Agent Search Keys ─────────────────────────────────────────────
+
+func (m Model) handleSearchMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	s := m.agentSearch
+	if s == nil {
+		m.popMode()
+		return m, nil
+	}
+	switch msg.String() {
+	case "esc":
+		m.agentSearch = nil
+		m.popMode()
+	case "enter":
+		if s.cursor < len(s.hits) {
+			return m.openSearchHit(s.hits[s.cursor]), nil
+		}
+	case "up", "ctrl+p":
+		if s.cursor > 0 {
+			s.cursor--
+		}
+	case "down", "ctrl+n":
+		if s.cursor < len(s.hits)-1 {
+			s.cursor++
+		}
+	case "backspace":
+		if r := []rune(s.input); len(r) > 0 {
+			s.input = string(r[:len(r)-1])
+			s.find()
+		}
+	case "ctrl+u":
+		s.input = ""
+		s.find()
+	default:
+		switch msg.Type {
+		case tea.KeyRunes:
+			s.input += string(msg.Runes)
+			s.find()
+		case tea.KeySpace:
+			s.input += " "
+			s.find()
+		}
+	}
+	return m, nil
+}
+
+// ── Agent Search View ─────────────────────────────────────────────
+
+func (m Model) renderAgentSearch() string {
+	s := m.agentSearch
+	width := min(100, m.width-4)
+	inner := width - 6
+	dim := lipgloss.NewStyle().Foreground(colorTextDim)
+	matchStyle := lipgloss.NewStyle().Background(colorYellow).Foreground(colorBgDark)
+
+	input := lipgloss.NewStyle().
+		Foreground(colorTextBright).
+		Background(colorBgLight).
+		Width(inner).
+		Padding(0, 1).
+		Render("/ " + s.input + "█")
+
+	var status string
+	switch {
+	case s.loading:
+		status = "Loading agent output…"
+	case s.input == "":
+		status = fmt.Sprintf("Searching %d sessions", len(s.sources))
+	case len(s.hits) == 0:
+		status = "No matches"
+	case len(s.hits) == 1:
+		status = "1 match"
+	case s.capped:
+		status = fmt.Sprintf("First %d matches", len(s.hits))
+	default:
+		status = fmt.Sprintf("%d matches", len(s.hits))
+	}
+
+	maxVisible := max(m.height-14, 3)
+	start := max(s.cursor-maxVisible+1, 0)
+	whoW, sessionW := 24, 13
+	textW := max(inner-whoW-sessionW-4, 10)
+	pat, _ := searchPattern(s.input)
+
+	var lines []string
+	for i := start; i < min(start+maxVisible, len(s.hits)); i++ {
+		h := s.hits[i]
+		prefix := "  "
+		style := lipgloss.NewStyle().Foreground(colorText)
+		if i == s.cursor {
+			prefix = "> "
+			style = lipgloss.NewStyle().Foreground(colorTextBright).Bold(true)
+		}
+		who := truncLine(h.src.party+" / "+h.src.inst.AgentName, whoW)
+		session := truncLine(h.src.session, sessionW)
+
+		// Show the match even when it is far into the line
+		line := []rune(h.src.lines[h.line])
+		from := 0
+		if h.col+len(pat) > textW {
+			from = max(h.col-textW/3, 0)
+		}
+		end := min(h.col+len(pat), len(line))
+		before := string(line[from:h.col])
+		if from > 0 {
+			before = "…" + string(line[from+1:h.col])
+		}
+		after := truncLine(string(line[end:]), max(textW-lipgloss.Width(before)-(end-h.col), 0))
+
+		lines = append(lines, style.Render(fmt.Sprintf("%s%-*s ", prefix, whoW, who))+
+			dim.Render(fmt.Sprintf("%-*s ", sessionW, session))+
+			before+matchStyle.Render(string(line[h.col:end]))+after)
+	}
+
+	hint := dim.Render("type:search  ↑↓:select  enter:open in copy mode  esc:close")
+	body := lipgloss.JoinVertical(lipgloss.Left, input, "", dim.Render(status), "", strings.Join(lines, "\n"), "", hint)
+
+	box := lipgloss.NewStyle().
+		Width(width).
+		Padding(1, 2).
+		Border(lipgloss.DoubleBorder()).
+		BorderForeground(colorYellow).
+		Background(colorBgMedium).
+		Render(body)
+
+	return lipgloss.NewStyle().
+		Width(m.width).
+		Height(m.height).
+		Align(lipgloss.Center, lipgloss.Top).
+		PaddingTop(2).
+		Background(colorBgDark).
+		Render(box)
+}
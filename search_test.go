@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSearchOpensMatchInCopyMode(t *testing.T) {
+	h := newHarness(t, PartyFile{Name: "hunt", Slots: []PartySlotConfig{
+		{Agent: "Planner"}, {Agent: "Builder"},
+	}})
+	h.WaitUntil("both agents running", func(m Model) bool {
+		p := m.party()
+		return p.Slots[0].emulator != nil && p.Slots[1].emulator != nil &&
+			strings.Contains(emulatorText(p.Slots[1].emulator), fakeAgentReady)
+	})
+	h.Keys("right", "i")
+	h.Type("lines 60\r")
+	h.Keys("esc")
+	h.WaitFor("line 60")
+	h.Keys("left", "f")
+	h.Type("line 27")
+	h.WaitFor("1 match")
+	h.Keys("enter")
+
+	m := h.Model()
+	c := m.scrollback
+	if m.mode != ModeCopy || c == nil || m.agent() != m.party().Slots[1] {
+		t.Fatal("match did not open in Builder's copy mode")
+	}
+	if got := string(c.lines[c.cursor.Line]); got != "line 27" {
+		t.Errorf("cursor on %q, want the match", got)
+	}
+	h.Keys("q")
+	if h.Model().mode != ModeSearch {
+		t.Fatal("leaving copy mode did not return to the results")
+	}
+	h.Keys("esc")
+	if h.Model().mode != ModeNormal {
+		t.Error("esc did not close the search")
+	}
+}
+
+func TestAgentSearchFind(t *testing.T) {
+	a := &searchSource{lines: []string{"panic: nil map", "Panic again, panic twice", "fine"}}
+	b := &searchSource{lines: []string{"no trouble", "recovered from panic"}}
+	s := &agentSearch{sources: []*searchSource{a, b}}
+
+	s.input = "panic"
+	s.find()
+	want := []searchHit{{a, 0, 0}, {a, 1, 0}, {b, 1, 15}}
+	if len(s.hits) != len(want) {
+		t.Fatalf("%d hits, want one per matching line: %+v", len(s.hits), s.hits)
+	}
+	for i, h := range s.hits {
+		if h != want[i] {
+			t.Errorf("hit %d = %+v, want %+v", i, h, want[i])
+		}
+	}
+
+	s.input = "Panic"
+	s.find()
+	if len(s.hits) != 1 || s.hits[0] != (searchHit{a, 1, 0}) {
+		t.Errorf("uppercase query should match case exactly: %+v", s.hits)
+	}
+
+	s.input = ""
+	s.find()
+	if s.hits != nil {
+		t.Error("empty query has hits")
+	}
+}
+
+func TestAgentSearchFindCapped(t *testing.T) {
+	src := &searchSource{lines: make([]string, searchMaxHits+20)}
+	for i := range src.lines {
+		src.lines[i] = "error"
+	}
+	s := &agentSearch{sources: []*searchSource{src}, input: "err"}
+	s.find()
+	if len(s.hits) != searchMaxHits || !s.capped {
+		t.Errorf("%d hits, capped %v", len(s.hits), s.capped)
+	}
+}
+
+func TestLoadSearchCorpusAddsPastSessions(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := ensureForgeDir(); err != nil {
+		t.Fatal(err)
+	}
+	session := func(name, transcript string) string {
+		dir := filepath.Join(sessionsDir(), name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if transcript != "" {
+			os.WriteFile(filepath.Join(dir, transcriptFileName), []byte(transcript), 0644)
+		}
+		return dir
+	}
+	session("p-0-Builder-20260101-080000", "") // no transcript: skipped
+	session("p-0-Builder-20260201-093000", "old panic\n")
+	session("p-1-Scout-20260201-100000", "someone else's panic\n")
+	current := session("p-0-Builder-20260301-120000", "")
+
+	inst := &AgentInstance{ID: "p-0-Builder", AgentName: "Builder"}
+	live := &searchSource{inst: inst, party: "p", session: "latest", dir: current, screen: []string{"on screen"}}
+	msg := loadSearchCorpus(&agentSearch{}, []*searchSource{live})().(searchCorpusMsg)
+
+	if len(msg.sources) != 2 {
+		t.Fatalf("%d sources, want the current session and one earlier", len(msg.sources))
+	}
+	if got := msg.sources[0]; got != live || strings.Join(got.lines, "|") != "on screen" {
+		t.Errorf("current session %+v, want its screen without a recording", got)
+	}
+	past := msg.sources[1]
+	if !past.past || past.inst != inst || past.session != "Feb 1 09:30" || strings.Join(past.lines, "|") != "old panic" {
+		t.Errorf("past session %+v", past)
+	}
+}
@@ -0,0 +1,585 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/vt"
+	"gopkg.in/yaml.v3"
+)
+
+// ── Session Records ───────────────────────────────────────────────
+//
+// Each launch gets a directory under sessionsDir() holding
+// effective_prompt.md, session.cast and session.yaml. The sessions view
+// lists them per party/agent and replays the recording in the main pane.
+
+const sessionMetaFile = "session.yaml"
+
+// sessionMeta is written at launch; Rating is filled in at checkout.
+type sessionMeta struct {
+	AgentID string    `yaml:"agent_id"`
+	Agent   string    `yaml:"agent"`
+	Class   string    `yaml:"class,omitempty"`
+	Party   string    `yaml:"party,omitempty"`
+	Backend string    `yaml:"backend,omitempty"`
+	Started time.Time `yaml:"started"`
+	Rating  string    `yaml:"rating,omitempty"` // great, normal, rough
+}
+
+func saveSessionMeta(dir string, meta sessionMeta) error {
+	data, err := yaml.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, sessionMetaFile), data, 0644)
+}
+
+func loadSessionMeta(dir string) (*sessionMeta, error) {
+	data, err := os.ReadFile(filepath.Join(dir, sessionMetaFile))
+	if err != nil {
+		return nil, err
+	}
+	var meta sessionMeta
+	if err := yaml.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// rateSession records the checkout rating on a session.
+func rateSession(dir, rating string) {
+	if dir == "" {
+		return
+	}
+	meta, err := loadSessionMeta(dir)
+	if err != nil {
+		return
+	}
+	meta.Rating = rating
+	saveSessionMeta(dir, *meta)
+}
+
+// SessionRecord is one past launch as shown in the sessions view.
+type SessionRecord struct {
+	Dir      string
+	Meta     sessionMeta
+	Duration time.Duration
+	Exit     string // exit status from the recording; empty = unknown/running
+	HasCast  bool
+	Stale    bool // no exit marker, but nothing is running it any more
+}
+
+// sessionDirName matches <agent-id>-<YYYYMMDD>-<HHMMSS> for sessions that
+// predate session.yaml.
+var sessionDirName = regexp.MustCompile(`^(.+)-(\d{8}-\d{6})$`)
+
+// listSessions scans sessionsDir(), grouped by party and agent, newest first.
+func listSessions() []SessionRecord {
+	entries, err := os.ReadDir(sessionsDir())
+	if err != nil {
+		return nil
+	}
+	var records []SessionRecord
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(sessionsDir(), e.Name())
+		rec := SessionRecord{Dir: dir}
+		if meta, err := loadSessionMeta(dir); err == nil {
+			rec.Meta = *meta
+		} else if sm := sessionDirName.FindStringSubmatch(e.Name()); sm != nil {
+			rec.Meta.AgentID = sm[1]
+			rec.Meta.Agent = sm[1]
+			rec.Meta.Started, _ = time.ParseInLocation("20060102-150405", sm[2], time.Local)
+		} else {
+			continue
+		}
+		rec.Duration, rec.Exit, rec.HasCast = scanCast(filepath.Join(dir, castFileName))
+		records = append(records, rec)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		a, b := records[i].Meta, records[j].Meta
+		if a.Party != b.Party {
+			return a.Party < b.Party
+		}
+		if a.Agent != b.Agent {
+			return a.Agent < b.Agent
+		}
+		return a.Started.After(b.Started)
+	})
+	return records
+}
+
+// markStaleSessions flags recordings without an exit marker whose
+// directory is not in live: the agent died without the recorder closing,
+// so the session will never finish.
+func markStaleSessions(records []SessionRecord, live map[string]bool) {
+	for i := range records {
+		rec := &records[i]
+		rec.Stale = rec.HasCast && rec.Exit == "" && !live[rec.Dir]
+	}
+}
+
+// liveSessionDirs returns the session directories of agents this TUI is
+// running or reattaching.
+func (m Model) liveSessionDirs() map[string]bool {
+	live := make(map[string]bool)
+	for _, p := range m.parties {
+		for _, inst := range append(p.Slots[:], p.Bench...) {
+			if inst != nil && inst.sessionDir != "" && (inst.Status == "running" || inst.Status == "attaching") {
+				live[inst.sessionDir] = true
+			}
+		}
+	}
+	return live
+}
+
+// sessionsMsg delivers listSessions results to the sessions view.
+type sessionsMsg struct {
+	records []SessionRecord
+}
+
+// loadSessions lists recorded sessions off the UI goroutine, marking the
+// ones neither in live nor owned by the daemon as stale.
+func loadSessions(live map[string]bool) tea.Cmd {
+	return func() tea.Msg {
+		records := listSessions()
+		if sessions, err := listDaemonSessions(); err == nil {
+			for _, s := range sessions {
+				if !s.Exited && s.SessionDir != "" {
+					live[s.SessionDir] = true
+				}
+			}
+		}
+		markStaleSessions(records, live)
+		return sessionsMsg{records}
+	}
+}
+
+// scanCast reads a recording's length and exit marker without keeping
+// its events in memory.
+func scanCast(path string) (dur time.Duration, exit string, ok bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", false
+	}
+	defer f.Close()
+	err = readCastEvents(f, nil, func(ev castEvent) {
+		dur = time.Duration(ev.Time * float64(time.Second))
+		if ev.Code == "m" {
+			if s, found := strings.CutPrefix(ev.Data, "exit: "); found {
+				exit = s
+			}
+		}
+	})
+	return dur, exit, err == nil
+}
+
+// ── Cast Playback ─────────────────────────────────────────────────
+
+type castEvent struct {
+	Time float64
+	Code string
+	Data string
+}
+
+// readCastEvents parses an asciicast v2 stream, calling fn for each event.
+// Malformed event lines are skipped so a truncated recording still plays.
+func readCastEvents(r io.Reader, hdr *castHeader, fn func(castEvent)) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	if !sc.Scan() {
+		return fmt.Errorf("empty recording")
+	}
+	var h castHeader
+	if err := json.Unmarshal(sc.Bytes(), &h); err != nil || h.Version != 2 {
+		return fmt.Errorf("not an asciicast v2 recording")
+	}
+	if hdr != nil {
+		*hdr = h
+	}
+	for sc.Scan() {
+		var raw []any
+		if json.Unmarshal(sc.Bytes(), &raw) != nil || len(raw) != 3 {
+			continue
+		}
+		t, _ := raw[0].(float64)
+		code, _ := raw[1].(string)
+		data, _ := raw[2].(string)
+		fn(castEvent{Time: t, Code: code, Data: data})
+	}
+	return sc.Err()
+}
+
+// sessionReplay plays a recording through its own vt emulator.
+type sessionReplay struct {
+	Record SessionRecord
+	header castHeader
+	events []castEvent
+	em     *vt.SafeEmulator
+	next   int     // index of the next event to apply
+	clock  float64 // playback position in seconds
+	Speed  float64
+	Paused bool
+}
+
+// replayTickMsg carries the replay it was scheduled for, so a stale tick
+// chain from a closed replay dies out instead of doubling the speed.
+type replayTickMsg struct{ r *sessionReplay }
+
+const replayTick = 50 * time.Millisecond
+
+var replaySpeeds = []float64{0.25, 0.5, 1, 2, 4, 8, 16}
+
+func replayTickCmd(r *sessionReplay) tea.Cmd {
+	return tea.Tick(replayTick, func(time.Time) tea.Msg { return replayTickMsg{r} })
+}
+
+func newSessionReplay(rec SessionRecord) (*sessionReplay, error) {
+	f, err := os.Open(filepath.Join(rec.Dir, castFileName))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := &sessionReplay{Record: rec, Speed: 1}
+	if err := readCastEvents(f, &r.header, func(ev castEvent) {
+		r.events = append(r.events, ev)
+	}); err != nil {
+		return nil, err
+	}
+	r.reset()
+	return r, nil
+}
+
+// reset rewinds to the start with a fresh emulator.
+func (r *sessionReplay) reset() {
+	r.Close()
+	w, h := r.header.Width, r.header.Height
+	if w <= 0 || h <= 0 {
+		w, h = 80, 24
+	}
+	r.em = vt.NewSafeEmulator(w, h)
+	// Terminal query replies have nowhere to go during playback
+	go io.Copy(io.Discard, r.em)
+	r.next = 0
+	r.clock = 0
+}
+
+// seek moves playback to t seconds, replaying from the start when rewinding.
+func (r *sessionReplay) seek(t float64) {
+	if t < 0 {
+		t = 0
+	}
+	if t > r.Length() {
+		t = r.Length()
+	}
+	if t < r.clock {
+		r.reset()
+	}
+	r.clock = t
+	for r.next < len(r.events) && r.events[r.next].Time <= r.clock {
+		ev := r.events[r.next]
+		switch ev.Code {
+		case "o":
+			r.em.Write([]byte(ev.Data))
+		case "r":
+			var cols, rows int
+			if _, err := fmt.Sscanf(ev.Data, "%dx%d", &cols, &rows); err == nil && cols > 0 && rows > 0 {
+				r.em.Resize(cols, rows)
+			}
+		}
+		r.next++
+	}
+}
+
+// tick advances playback by one frame and pauses at the end.
+func (r *sessionReplay) tick() {
+	if r.Paused {
+		return
+	}
+	r.seek(r.clock + replayTick.Seconds()*r.Speed)
+	if r.Done() {
+		r.Paused = true
+	}
+}
+
+func (r *sessionReplay) Done() bool { return r.next >= len(r.events) }
+
+func (r *sessionReplay) Position() float64 { return r.clock }
+
+func (r *sessionReplay) Length() float64 {
+	if len(r.events) == 0 {
+		return 0
+	}
+	return r.events[len(r.events)-1].Time
+}
+
+func (r *sessionReplay) changeSpeed(delta int) {
+	i := 0
+	for i < len(replaySpeeds)-1 && replaySpeeds[i] < r.Speed {
+		i++
+	}
+	i += delta
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(replaySpeeds) {
+		i = len(replaySpeeds) - 1
+	}
+	r.Speed = replaySpeeds[i]
+}
+
+func (r *sessionReplay) Close() {
+	if r.em != nil {
+		closeEmulator(r.em)
+		r.em = nil
+	}
+}
+
+// ── Sessions Mode ─────────────────────────────────────────────────
+
+func (m Model) openSessions() (Model, tea.Cmd) {
+	m.sessions = nil
+	m.sessionsLoading = true
+	m.sessionCursor = 0
+	m.sessionShowPrompt = false
+	m.sessionPrompt = ""
+	m.pushMode(ModeSessions)
+	return m, loadSessions(m.liveSessionDirs())
+}
+
+func (m Model) handleSessions(msg sessionsMsg) (tea.Model, tea.Cmd) {
+	if m.mode != ModeSessions || !m.sessionsLoading {
+		return m, nil
+	}
+	m.sessionsLoading = false
+	m.sessions = msg.records
+	return m, nil
+}
+
+func (m Model) handleReplayTick(msg replayTickMsg) (tea.Model, tea.Cmd) {
+	if m.replay == nil || msg.r != m.replay || m.mode != ModeSessions {
+		return m, nil
+	}
+	m.replay.tick()
+	return m, replayTickCmd(m.replay)
+}
+
+func (m Model) handleSessionsMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.replay != nil {
+		return m.handleReplayKeys(msg)
+	}
+
+	switch msg.String() {
+	case "esc", "q":
+		if m.sessionShowPrompt {
+			m.sessionShowPrompt = false
+			return m, nil
+		}
+		m.sessions = nil
+		m.popMode()
+	case "up", "k":
+		if m.sessionCursor > 0 {
+			m.sessionCursor--
+			m.sessionShowPrompt = false
+		}
+	case "down", "j":
+		if m.sessionCursor < len(m.sessions)-1 {
+			m.sessionCursor++
+			m.sessionShowPrompt = false
+		}
+	case "p":
+		if m.sessionCursor < len(m.sessions) {
+			data, _ := os.ReadFile(filepath.Join(m.sessions[m.sessionCursor].Dir, "effective_prompt.md"))
+			m.sessionPrompt = string(data)
+			m.sessionShowPrompt = !m.sessionShowPrompt
+		}
+	case "enter", "r":
+		if m.sessionCursor < len(m.sessions) && m.sessions[m.sessionCursor].HasCast {
+			r, err := newSessionReplay(m.sessions[m.sessionCursor])
+			if err != nil {
+				return m, nil
+			}
+			m.replay = r
+			return m, replayTickCmd(r)
+		}
+	}
+	return m, nil
+}
+
+func (m Model) handleReplayKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	r := m.replay
+	switch msg.String() {
+	case "esc", "q":
+		r.Close()
+		m.replay = nil
+	case " ":
+		if r.Done() {
+			r.seek(0)
+		}
+		r.Paused = !r.Paused
+	case "left", "h":
+		r.seek(r.Position() - 5)
+	case "right", "l":
+		r.seek(r.Position() + 5)
+	case "shift+left", "H":
+		r.seek(r.Position() - 60)
+	case "shift+right", "L":
+		r.seek(r.Position() + 60)
+	case "0", "home":
+		r.seek(0)
+	case "+", "=":
+		r.changeSpeed(1)
+	case "-":
+		r.changeSpeed(-1)
+	}
+	return m, nil
+}
+
+// ── Sessions View ─────────────────────────────────────────────────
+
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	if d >= time.Hour {
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+func (m Model) renderSessions(tw, th int) string {
+	if m.replay != nil {
+		return m.renderReplay(tw, th)
+	}
+
+	dim := lipgloss.NewStyle().Foreground(colorTextDim)
+	var lines []string
+	lines = append(lines, csHeaderStyle.Render(fmt.Sprintf(" SESSIONS (%d)", len(m.sessions))), "")
+
+	if m.sessionsLoading {
+		lines = append(lines, dim.Render("  Loading..."))
+	} else if len(m.sessions) == 0 {
+		lines = append(lines, dim.Render("  No recorded sessions yet."))
+	}
+
+	if m.sessionShowPrompt {
+		rec := m.sessions[m.sessionCursor]
+		lines = append(lines, dim.Render("  "+filepath.Base(rec.Dir)), "")
+		for _, l := range strings.Split(m.sessionPrompt, "\n") {
+			lines = append(lines, "  "+truncLine(l, tw-4))
+		}
+	} else {
+		// Keep the cursor in view
+		visible := th - 2
+		start := 0
+		if m.sessionCursor >= visible/2 {
+			start = m.sessionCursor - visible/2
+		}
+		group := ""
+		for i := start; i < len(m.sessions) && len(lines) < th; i++ {
+			rec := m.sessions[i]
+			g := rec.Meta.Agent
+			if rec.Meta.Party != "" {
+				g = rec.Meta.Party + " / " + rec.Meta.Agent
+			}
+			if g != group {
+				group = g
+				lines = append(lines, lipgloss.NewStyle().Foreground(colorText).Bold(true).Render(" "+g))
+			}
+
+			exit := rec.Exit
+			exitColor := colorRed
+			switch {
+			case !rec.HasCast:
+				exit, exitColor = "no recording", colorTextDim
+			case rec.Stale:
+				exit, exitColor = "stale", colorTextDim
+			case exit == "":
+				exit, exitColor = "running", colorYellow
+			case exit == "0":
+				exit, exitColor = "ok", colorGreen
+			}
+			rating := rec.Meta.Rating
+			if rating == "" {
+				rating = "-"
+			}
+
+			prefix := "   "
+			style := dim
+			if i == m.sessionCursor {
+				prefix = " > "
+				style = lipgloss.NewStyle().Foreground(colorTextBright).Bold(true)
+			}
+			row := fmt.Sprintf("%s%s  %7s  %-7s ", prefix,
+				rec.Meta.Started.Format("2006-01-02 15:04"), formatDuration(rec.Duration), rating)
+			lines = append(lines, style.Render(row)+lipgloss.NewStyle().Foreground(exitColor).Render(exit))
+		}
+	}
+
+	if len(lines) > th {
+		lines = lines[:th]
+	}
+	content := lipgloss.NewStyle().Width(tw).Height(th).Render(strings.Join(lines, "\n"))
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorBlue).
+		Render(content)
+}
+
+func (m Model) renderReplay(tw, th int) string {
+	r := m.replay
+
+	state := "▶"
+	if r.Paused {
+		state = "⏸"
+	}
+	if r.Done() {
+		state = "■"
+	}
+	pos := time.Duration(r.Position() * float64(time.Second))
+	length := time.Duration(r.Length() * float64(time.Second))
+	info := fmt.Sprintf(" %s %s  %s / %s  %gx ", state, r.Record.Meta.AgentID,
+		formatDuration(pos), formatDuration(length), r.Speed)
+
+	// Progress bar across the remaining width
+	barW := tw - lipgloss.Width(info) - 2
+	bar := ""
+	if barW > 0 {
+		filled := 0
+		if r.Length() > 0 {
+			filled = int(float64(barW) * r.Position() / r.Length())
+		}
+		filled = min(filled, barW)
+		bar = lipgloss.NewStyle().Foreground(colorYellow).Render(strings.Repeat("━", filled)) +
+			lipgloss.NewStyle().Foreground(colorBorder).Render(strings.Repeat("─", barW-filled))
+	}
+	statusLine := ansi.Truncate(lipgloss.NewStyle().Foreground(colorTextBright).Bold(true).Render(info)+bar, tw, "")
+
+	// Recording may be larger than the pane; crop rather than wrap
+	screen := strings.Split(r.em.Render(), "\r\n")
+	if len(screen) > th-1 {
+		screen = screen[:th-1]
+	}
+	for i, l := range screen {
+		screen[i] = ansi.Truncate(l, tw, "")
+	}
+
+	content := lipgloss.NewStyle().Width(tw).Height(th).
+		Render(strings.Join(append([]string{statusLine}, screen...), "\n"))
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorBlue).
+		Render(content)
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordSession writes a session directory named name under
+// sessionsDir(), with meta if given and cast as its recording if not "".
+func recordSession(t *testing.T, name string, meta *sessionMeta, cast string) string {
+	t.Helper()
+	dir := filepath.Join(sessionsDir(), name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if meta != nil {
+		if err := saveSessionMeta(dir, *meta); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if cast != "" {
+		if err := os.WriteFile(filepath.Join(dir, castFileName), []byte(cast), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+const castHeaderLine = `{"version": 2, "width": 20, "height": 3}` + "\n"
+
+func TestSessionsViewMarksDeadSessionsStale(t *testing.T) {
+	h := newHarness(t, PartyFile{Name: "solo", Slots: []PartySlotConfig{{Agent: "Builder"}}})
+	h.WaitUntil("Builder running", func(m Model) bool {
+		inst := m.party().Slots[0]
+		return inst.Status == "running" && inst.sessionDir != ""
+	})
+	// A Builder run from an earlier TUI that died mid-session
+	recordSession(t, "solo-0-Builder-20260101-080000",
+		&sessionMeta{AgentID: "solo-0-Builder", Agent: "Builder", Party: "solo",
+			Started: time.Date(2026, 1, 1, 8, 0, 0, 0, time.Local)},
+		castHeaderLine+`[1.5, "o", "working"]`+"\n")
+
+	h.Keys("shift+tab", "s")
+	h.WaitFor("SESSIONS (2)")
+	m := h.Model()
+	if len(m.sessions) != 2 || m.sessionsLoading {
+		t.Fatalf("%d sessions, loading %v", len(m.sessions), m.sessionsLoading)
+	}
+	live, dead := m.sessions[0], m.sessions[1]
+	if live.Dir != m.party().Slots[0].sessionDir || live.Stale {
+		t.Errorf("running session %s reported stale", live.Dir)
+	}
+	if !dead.Stale || !strings.Contains(h.View(), "stale") {
+		t.Errorf("dead session not shown as stale:\n%s", h.View())
+	}
+	h.Keys("esc")
+	if h.Model().mode != ModeNormal {
+		t.Error("esc did not close the sessions view")
+	}
+}
+
+func TestListSessions(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := ensureForgeDir(); err != nil {
+		t.Fatal(err)
+	}
+	jan := time.Date(2026, 1, 5, 9, 0, 0, 0, time.Local)
+	recordSession(t, "b-0-Scout-x", &sessionMeta{AgentID: "b-0-Scout", Agent: "Scout", Party: "beta", Started: jan}, "")
+	recordSession(t, "a-0-Builder-old", &sessionMeta{AgentID: "a-0-Builder", Agent: "Builder", Party: "alpha", Started: jan},
+		castHeaderLine+`[0.5, "o", "hi"]`+"\n"+`[2.25, "m", "exit: 1"]`+"\n")
+	recordSession(t, "a-0-Builder-new", &sessionMeta{AgentID: "a-0-Builder", Agent: "Builder", Party: "alpha", Started: jan.Add(time.Hour)},
+		castHeaderLine)
+	// Before session.yaml, the directory name carried the agent and start
+	recordSession(t, "legacy-20251231-235959", nil, "")
+	recordSession(t, "not-a-session", nil, "")
+	os.WriteFile(filepath.Join(sessionsDir(), "stray.txt"), nil, 0644)
+
+	records := listSessions()
+	var got []string
+	for _, rec := range records {
+		got = append(got, filepath.Base(rec.Dir))
+	}
+	want := "legacy-20251231-235959 a-0-Builder-new a-0-Builder-old b-0-Scout-x"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("sessions %v, want %s (by party, agent, newest first)", got, want)
+	}
+
+	legacy := records[0]
+	if legacy.Meta.AgentID != "legacy" || !legacy.Meta.Started.Equal(time.Date(2025, 12, 31, 23, 59, 59, 0, time.Local)) || legacy.HasCast {
+		t.Errorf("legacy session %+v", legacy)
+	}
+	if old := records[2]; !old.HasCast || old.Exit != "1" || old.Duration != 2250*time.Millisecond {
+		t.Errorf("finished session: cast %v, exit %q, duration %s", old.HasCast, old.Exit, old.Duration)
+	}
+	if running := records[1]; !running.HasCast || running.Exit != "" || running.Duration != 0 {
+		t.Errorf("unfinished session %+v", running)
+	}
+}
+
+func TestMarkStaleSessions(t *testing.T) {
+	records := []SessionRecord{
+		{Dir: "finished", HasCast: true, Exit: "0"},
+		{Dir: "live", HasCast: true},
+		{Dir: "dead", HasCast: true},
+		{Dir: "unrecorded"},
+	}
+	markStaleSessions(records, map[string]bool{"live": true})
+	for _, rec := range records {
+		if rec.Stale != (rec.Dir == "dead") {
+			t.Errorf("%s: stale %v", rec.Dir, rec.Stale)
+		}
+	}
+}
+
+func TestScanCast(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(data), 0644)
+		return path
+	}
+	tests := []struct {
+		name, data string
+		dur        time.Duration
+		exit       string
+		ok         bool
+	}{
+		{"exited", castHeaderLine + `[1, "o", "a"]` + "\n" + `[3.5, "m", "exit: 0"]` + "\n", 3500 * time.Millisecond, "0", true},
+		{"signalled", castHeaderLine + `[0.25, "m", "exit: signal: killed"]` + "\n", 250 * time.Millisecond, "signal: killed", true},
+		{"other markers", castHeaderLine + `[1, "m", "resumed"]` + "\n" + `[2, "o", "b"]` + "\n", 2 * time.Second, "", true},
+		{"truncated", castHeaderLine + `[1, "o", "a"]` + "\n" + `[2, "o", "b`, time.Second, "", true},
+		{"empty", "", 0, "", false},
+		{"not a cast", `{"version": 1}` + "\n", 0, "", false},
+	}
+	for _, tt := range tests {
+		dur, exit, ok := scanCast(write(tt.name, tt.data))
+		if dur != tt.dur || exit != tt.exit || ok != tt.ok {
+			t.Errorf("%s: %s %q %v, want %s %q %v", tt.name, dur, exit, ok, tt.dur, tt.exit, tt.ok)
+		}
+	}
+	if _, _, ok := scanCast(filepath.Join(dir, "missing")); ok {
+		t.Error("missing recording reported ok")
+	}
+}
+
+func TestSessionReplaySeek(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := ensureForgeDir(); err != nil {
+		t.Fatal(err)
+	}
+	dir := recordSession(t, "r", nil, castHeaderLine+
+		`[1, "o", "one\r\n"]`+"\n"+
+		`[2, "o", "two\r\n"]`+"\n"+
+		`[3, "r", "30x5"]`+"\n"+
+		`[4, "o", "three"]`+"\n")
+	r, err := newSessionReplay(SessionRecord{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	screen := func() string { return strings.TrimSpace(emulatorText(r.em)) }
+
+	if r.Length() != 4 || screen() != "" {
+		t.Fatalf("length %v, screen %q before playback", r.Length(), screen())
+	}
+	r.seek(2)
+	if screen() != "one\ntwo" || r.Done() {
+		t.Errorf("at 2s: %q", screen())
+	}
+	r.seek(1.5) // rewinding replays from the start
+	if screen() != "one" || r.Position() != 1.5 {
+		t.Errorf("at 1.5s: %q", screen())
+	}
+	r.seek(99)
+	if r.Position() != 4 || !r.Done() || screen() != "one\ntwo\nthree" {
+		t.Errorf("past the end: position %v, %q", r.Position(), screen())
+	}
+	if r.em.Width() != 30 || r.em.Height() != 5 {
+		t.Errorf("resize event not applied: %dx%d", r.em.Width(), r.em.Height())
+	}
+	r.seek(-1)
+	if r.Position() != 0 || screen() != "" || r.em.Width() != 20 {
+		t.Errorf("before the start: position %v, %q, width %d", r.Position(), screen(), r.em.Width())
+	}
+
+	r.tick()
+	if r.Position() != replayTick.Seconds() {
+		t.Errorf("tick moved to %v", r.Position())
+	}
+	r.Paused = true
+	r.tick()
+	if r.Position() != replayTick.Seconds() {
+		t.Error("paused replay advanced")
+	}
+	r.Paused = false
+	r.seek(3.99)
+	r.tick()
+	if !r.Done() || !r.Paused {
+		t.Error("replay did not pause at the end")
+	}
+
+	r.changeSpeed(1)
+	r.changeSpeed(1)
+	if r.Speed != 4 {
+		t.Errorf("speed %v after two steps up from 1x", r.Speed)
+	}
+	for range 10 {
+		r.changeSpeed(-1)
+	}
+	if r.Speed != replaySpeeds[0] {
+		t.Errorf("speed %v, want clamped to %v", r.Speed, replaySpeeds[0])
+	}
+}
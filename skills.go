@@ -7,17 +7,27 @@ import (
 
 // ── Token Budget Constants ─────────────────────────────────────────
 
+// Skill tokens (innate + equipped) an agent may carry, as counted by
+// countTokens. A level 1 agent gets TokenBudgetTotal; every level above
+// that adds TokenBudgetPerLevel. config.yaml can override both, and
+// classes can set their own base.
+//
+// A typical SKILL.md runs 1.5–3K tokens, so the base fits a class's two
+// innate skills plus one or two equipped ones; levels pay for filling the
+// remaining slots.
 const (
-	TokenBudgetTotal = 1000
+	TokenBudgetTotal    = 8000
+	TokenBudgetPerLevel = 1000
 )
 
 // ── Prompt Composition ─────────────────────────────────────────────
 
 // SkillSlot represents a skill in an agent's loadout.
 type SkillSlot struct {
-	SkillID  string
-	IsInnate bool
-	Tokens   int
+	SkillID   string
+	IsInnate  bool
+	IsPassive bool
+	Tokens    int
 }
 
 // ComposedPrompt is the result of composing all skills into a system prompt.
@@ -28,7 +38,8 @@ type ComposedPrompt struct {
 }
 
 // ComposePrompt builds the system prompt for an agent instance.
-// Concatenates class description + agent directives + innate skill content + equipped skill content.
+// Concatenates class description + agent directives + innate skill content + equipped skill content
+// + passive prompt text.
 func ComposePrompt(cfg *ForgeConfig, className string, equipped []string, passives []string, agentDirectives string) ComposedPrompt {
 	classCfg := cfg.Classes[className]
 	if classCfg == nil {
@@ -61,11 +72,10 @@ func ComposePrompt(cfg *ForgeConfig, className string, equipped []string, passiv
 		if skill == nil {
 			continue
 		}
-		tokens := estimateTokens(skill.Content)
 		slots = append(slots, SkillSlot{
 			SkillID:  sid,
 			IsInnate: true,
-			Tokens:   tokens,
+			Tokens:   skill.Tokens,
 		})
 		parts = append(parts, fmt.Sprintf("## Skill: %s (Innate)\n%s", skill.Name, skill.Content))
 	}
@@ -79,15 +89,28 @@ func ComposePrompt(cfg *ForgeConfig, className string, equipped []string, passiv
 		if skill == nil {
 			continue
 		}
-		tokens := estimateTokens(skill.Content)
 		slots = append(slots, SkillSlot{
 			SkillID:  sid,
 			IsInnate: false,
-			Tokens:   tokens,
+			Tokens:   skill.Tokens,
 		})
 		parts = append(parts, fmt.Sprintf("## Skill: %s\n%s", skill.Name, skill.Content))
 	}
 
+	// Passives (prompt text only; hooks run around the process)
+	for _, pid := range passives {
+		passive := cfg.Passive(pid)
+		if passive == nil || passive.Prompt == "" {
+			continue
+		}
+		slots = append(slots, SkillSlot{
+			SkillID:   pid,
+			IsPassive: true,
+			Tokens:    countTokens(passive.Prompt),
+		})
+		parts = append(parts, fmt.Sprintf("## Passive: %s\n%s", passiveName(cfg, pid), passive.Prompt))
+	}
+
 	// Calculate total tokens
 	total := 0
 	for _, s := range slots {
@@ -123,8 +146,9 @@ func isInnate(class *ClassConfig, skillID string) bool {
 	return false
 }
 
-// CanEquip checks if a skill can be equipped in the given loadout.
-func CanEquip(cfg *ForgeConfig, className string, equipped []string, skillID string) bool {
+// CanEquip checks if a skill can be equipped in the given loadout: a free
+// slot, not already carried, and within the class's budget at level.
+func CanEquip(cfg *ForgeConfig, className string, level int, equipped []string, skillID string) bool {
 	classCfg := cfg.Classes[className]
 	if classCfg == nil {
 		return false
@@ -137,30 +161,74 @@ func CanEquip(cfg *ForgeConfig, className string, equipped []string, skillID str
 			return false
 		}
 	}
-	return len(equipped) < MaxEquipSlots
+	if len(equipped) >= MaxEquipSlots {
+		return false
+	}
+	skill := SkillByID(cfg, skillID)
+	if skill == nil {
+		return false
+	}
+	return LoadoutTokens(cfg, className, equipped)+skill.Tokens <= SkillBudget(cfg, className, level)
 }
 
 // ToggleEquip adds or removes a skill from the equipped list.
-func ToggleEquip(cfg *ForgeConfig, className string, equipped []string, skillID string) []string {
+func ToggleEquip(cfg *ForgeConfig, className string, level int, equipped []string, skillID string) []string {
 	for i, e := range equipped {
 		if e == skillID {
 			return append(equipped[:i], equipped[i+1:]...)
 		}
 	}
-	if CanEquip(cfg, className, equipped, skillID) {
+	if CanEquip(cfg, className, level, equipped, skillID) {
 		return append(equipped, skillID)
 	}
 	return equipped
 }
 
-// ── Helpers ────────────────────────────────────────────────────────
+// SkillBudget returns the skill token budget for a class at a level.
+func SkillBudget(cfg *ForgeConfig, className string, level int) int {
+	base := cfg.TokenBudget
+	if classCfg := cfg.Classes[className]; classCfg != nil && classCfg.TokenBudget > 0 {
+		base = classCfg.TokenBudget
+	}
+	if base <= 0 {
+		base = TokenBudgetTotal
+	}
+	perLevel := cfg.BudgetPerLevel
+	if perLevel <= 0 {
+		perLevel = TokenBudgetPerLevel
+	}
+	if level < 1 {
+		level = 1
+	}
+	return base + (level-1)*perLevel
+}
 
-// estimateTokens gives a rough token estimate for text.
-func estimateTokens(text string) int {
-	words := len(strings.Fields(text))
-	return int(float64(words) * 1.3)
+// LoadoutTokens counts the skill tokens a loadout carries: the class's
+// innate skills plus every equipped skill.
+func LoadoutTokens(cfg *ForgeConfig, className string, equipped []string) int {
+	classCfg := cfg.Classes[className]
+	if classCfg == nil {
+		return 0
+	}
+	total := 0
+	for _, sid := range classCfg.InnateSkills {
+		if skill := SkillByID(cfg, sid); skill != nil {
+			total += skill.Tokens
+		}
+	}
+	for _, sid := range equipped {
+		if isInnate(classCfg, sid) {
+			continue
+		}
+		if skill := SkillByID(cfg, sid); skill != nil {
+			total += skill.Tokens
+		}
+	}
+	return total
 }
 
+// ── Helpers ────────────────────────────────────────────────────────
+
 // SkillByID finds a skill entry by ID.
 func SkillByID(cfg *ForgeConfig, id string) *SkillEntry {
 	for _, s := range cfg.Skills {
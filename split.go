@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ── Split Panes ───────────────────────────────────────────────────
+//
+// The main pane can show several agents' terminals at once: side by side,
+// in a 2x2 grid, or as one large pane with the rest stacked beside it.
+// Panes show the active party's occupied slots in order, a page at a time;
+// the page is the one holding the selected agent, whose pane has focus
+// (←→ moves it). Every running agent's PTY and emulator are sized to its
+// own pane, or to the full main pane when it isn't shown, and z zooms the
+// focused pane to full size.
+
+// SplitLayout is how the main pane divides between agent terminals.
+type SplitLayout int
+
+const (
+	SplitSingle    SplitLayout = iota // only the selected agent
+	SplitTwo                          // two side by side
+	SplitGrid                         // up to four in a 2x2 grid
+	SplitMainStack                    // one large, up to three stacked beside it
+	splitLayoutCount
+)
+
+func (l SplitLayout) String() string {
+	switch l {
+	case SplitTwo:
+		return "2-up"
+	case SplitGrid:
+		return "4-up"
+	case SplitMainStack:
+		return "main + stack"
+	}
+	return "single"
+}
+
+// paneCount is how many agents the layout shows at once.
+func (l SplitLayout) paneCount() int {
+	switch l {
+	case SplitTwo:
+		return 2
+	case SplitGrid, SplitMainStack:
+		return 4
+	}
+	return 1
+}
+
+// splitPane is one agent terminal in the main pane. width and height are
+// the terminal's size inside the pane border.
+type splitPane struct {
+	inst          *AgentInstance
+	width, height int
+}
+
+// splitColumn is a column of panes sharing one width.
+type splitColumn []splitPane
+
+// visiblePanes lists the active party's agents on the current page.
+func (m Model) visiblePanes() []*AgentInstance {
+	p := m.party()
+	if p == nil {
+		return nil
+	}
+	n := m.splitLayout.paneCount()
+	if m.zoomed {
+		n = 1
+	}
+	var occupied []*AgentInstance
+	pos := 0
+	for i, inst := range p.Slots {
+		if inst == nil {
+			continue
+		}
+		if i == m.selectedAgent {
+			pos = len(occupied)
+		}
+		occupied = append(occupied, inst)
+	}
+	start := pos / n * n
+	return occupied[start:min(start+n, len(occupied))]
+}
+
+// splitColumns arranges the visible panes in the main pane, or returns
+// nil when only one agent is shown.
+func (m Model) splitColumns() []splitColumn {
+	agents := m.visiblePanes()
+	if len(agents) < 2 {
+		return nil
+	}
+	outerW := m.termWidth() + 2
+	outerH := m.termHeight() + 2
+
+	// Column widths (outer) and which agents go in each column
+	var widths []int
+	var cols [][]*AgentInstance
+	switch m.splitLayout {
+	case SplitMainStack:
+		stackW := outerW * 2 / 5
+		widths = []int{outerW - stackW, stackW}
+		cols = [][]*AgentInstance{agents[:1], agents[1:]}
+	default: // SplitTwo, SplitGrid: row-major across two columns
+		widths = []int{outerW / 2, outerW - outerW/2}
+		cols = make([][]*AgentInstance, 2)
+		for i, inst := range agents {
+			cols[i%2] = append(cols[i%2], inst)
+		}
+	}
+
+	columns := make([]splitColumn, len(cols))
+	for c, col := range cols {
+		for r, inst := range col {
+			// Split the height evenly, giving the remainder to the last pane
+			h := outerH / len(col)
+			if r == len(col)-1 {
+				h = outerH - h*(len(col)-1)
+			}
+			columns[c] = append(columns[c], splitPane{
+				inst:   inst,
+				width:  max(widths[c]-2, 1),
+				height: max(h-2, 1),
+			})
+		}
+	}
+	return columns
+}
+
+// agentTermSize is the terminal size inst should run at: its pane's when
+// it is shown in a split, otherwise the full main pane.
+func (m Model) agentTermSize(inst *AgentInstance) (int, int) {
+	for _, col := range m.splitColumns() {
+		for _, pane := range col {
+			if pane.inst == inst {
+				return pane.width, pane.height
+			}
+		}
+	}
+	return m.termWidth(), m.termHeight()
+}
+
+// withFittedPanes resizes agents whose pane changed after a handler ran,
+// e.g. when it switched layout or the selected agent moved to another page.
+func withFittedPanes(model tea.Model, cmd tea.Cmd) (tea.Model, tea.Cmd) {
+	if m, ok := model.(Model); ok {
+		m.resizeActivePartyAgents()
+	}
+	return model, cmd
+}
+
+func (m Model) setSplitLayout(l SplitLayout) Model {
+	m.splitLayout = l
+	m.zoomed = false
+	m.resizeActivePartyAgents()
+	return m
+}
+
+func (m Model) toggleZoom() Model {
+	m.zoomed = !m.zoomed
+	m.resizeActivePartyAgents()
+	return m
+}
+
+// ── Split View ────────────────────────────────────────────────────
+
+// renderSplit draws every visible agent in its pane.
+func (m Model) renderSplit(columns []splitColumn) string {
+	rendered := make([]string, len(columns))
+	for c, col := range columns {
+		panes := make([]string, len(col))
+		for r, pane := range col {
+			panes[r] = m.renderAgentPane(pane.inst, pane.width, pane.height, pane.inst == m.agent())
+		}
+		rendered[c] = lipgloss.JoinVertical(lipgloss.Left, panes...)
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, rendered...)
+}
+
+// renderAgentPane draws inst's terminal, or what to do when it has none,
+// in a tw×th pane. focused is the pane keys go to.
+func (m Model) renderAgentPane(inst *AgentInstance, tw, th int, focused bool) string {
+	r, g, b := inst.Tint.R, inst.Tint.G, inst.Tint.B
+	borderColor := lipgloss.Color(fmt.Sprintf("#%02x%02x%02x", r/2, g/2, b/2))
+	if focused && (m.focus == FocusMainPane || m.mode == ModeInsert) {
+		borderColor = lipgloss.Color(fmt.Sprintf("#%02x%02x%02x", r, g, b))
+	}
+	if m.mode == ModeBroadcast && m.isBroadcastTarget(inst) {
+		borderColor = colorRed
+	}
+
+	switch {
+	case inst.Status == "running" && inst.emulator != nil:
+		screen := strings.ReplaceAll(inst.emulator.Render(), "\r\n", "\n")
+		border := lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(borderColor)
+		if tw == m.termWidth() && th == m.termHeight() {
+			return border.Render(screen)
+		}
+		// Keep the grid intact while the agent catches up with a resize
+		screen = lipgloss.NewStyle().MaxWidth(tw).MaxHeight(th).Render(screen)
+		return border.Width(tw).Height(th).Render(screen)
+	case m.resumeAsk == inst:
+		return m.renderEmptyTerminal(tw, th, colorYellow,
+			fmt.Sprintf("Resume %s's last conversation?\n\nr: resume   f: fresh start   esc: cancel", inst.AgentName))
+	case inst.Status == "exited":
+		return m.renderEmptyTerminal(tw, th, borderColor, "Process exited. Press 's' to restart or 'c' to check out.")
+	case m.canResume(inst):
+		return m.renderEmptyTerminal(tw, th, borderColor, fmt.Sprintf("Press 's' to resume or restart %s", inst.Backend))
+	default:
+		return m.renderEmptyTerminal(tw, th, borderColor, fmt.Sprintf("Press 's' to start %s", inst.Backend))
+	}
+}
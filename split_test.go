@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestSplitPanesSizeAgentsToPanes(t *testing.T) {
+	h := newHarness(t, PartyFile{Name: "split", Slots: []PartySlotConfig{
+		{Agent: "Planner"}, {Agent: "Builder"},
+	}})
+	h.WaitUntil("both agents running", func(m Model) bool {
+		p := m.party()
+		return p.Slots[0].emulator != nil && p.Slots[1].emulator != nil
+	})
+	full := h.Model().termWidth()
+	h.Keys("v") // 2-up
+	p := h.Model().party()
+	for _, inst := range p.Slots[:2] {
+		if w := inst.emulator.Width(); w > full/2 {
+			t.Errorf("%s is %d columns wide in a 2-up split of %d", inst.AgentName, w, full)
+		}
+	}
+	h.Keys("z")
+	if w := p.Slots[0].emulator.Width(); w != full {
+		t.Errorf("zoomed pane is %d columns wide, want %d", w, full)
+	}
+	h.Keys("z", "right")
+	if h.Model().agent() != p.Slots[1] || p.Slots[0].emulator.Width() > full/2 {
+		t.Error("focus did not move to the second pane after unzoom")
+	}
+}
+
+func TestSplitColumnsTileMainPane(t *testing.T) {
+	p := &Party{Name: "five"}
+	for i, name := range []string{"A", "B", "C", "D", "E"} {
+		p.Slots[i] = &AgentInstance{AgentName: name}
+	}
+	m := Model{parties: []*Party{p}, width: 160, height: 50}
+	outerW, outerH := m.termWidth()+2, m.termHeight()+2
+
+	for _, l := range []SplitLayout{SplitTwo, SplitGrid, SplitMainStack} {
+		m.splitLayout = l
+		columns := m.splitColumns()
+		width, panes := 0, 0
+		for _, col := range columns {
+			height := 0
+			for _, pane := range col {
+				if pane.width != col[0].width {
+					t.Errorf("%s: panes in a column differ in width", l)
+				}
+				height += pane.height + 2
+				panes++
+			}
+			if height != outerH {
+				t.Errorf("%s: column is %d rows, want %d", l, height, outerH)
+			}
+			width += col[0].width + 2
+		}
+		if width != outerW || panes != l.paneCount() {
+			t.Errorf("%s: %d panes over %d columns, want %d over %d", l, panes, width, l.paneCount(), outerW)
+		}
+		if l == SplitMainStack && (len(columns[0]) != 1 || len(columns[1]) != 3) {
+			t.Errorf("main + stack has %d and %d panes", len(columns[0]), len(columns[1]))
+		}
+	}
+}
+
+func TestVisiblePanesPageToSelection(t *testing.T) {
+	p := &Party{Name: "five"}
+	for i, name := range []string{"A", "B", "C", "D", "E"} {
+		p.Slots[i] = &AgentInstance{AgentName: name}
+	}
+	m := Model{parties: []*Party{p}, width: 160, height: 50, splitLayout: SplitGrid, selectedAgent: 4}
+	if got := m.visiblePanes(); len(got) != 1 || got[0] != p.Slots[4] {
+		t.Errorf("second page shows %v, want only E", got)
+	}
+	if m.splitColumns() != nil {
+		t.Error("a page with one agent is split")
+	}
+	if w, _ := m.agentTermSize(p.Slots[4]); w != m.termWidth() {
+		t.Errorf("lone agent is %d columns, want the full %d", w, m.termWidth())
+	}
+
+	m.selectedAgent = 2
+	if got := m.visiblePanes(); len(got) != 4 || got[0] != p.Slots[0] {
+		t.Errorf("first page shows %d agents", len(got))
+	}
+	m.zoomed = true
+	if got := m.visiblePanes(); len(got) != 1 || got[0] != p.Slots[2] {
+		t.Errorf("zoom shows %v, want the selected agent", got)
+	}
+	if w, _ := m.agentTermSize(p.Slots[0]); w != m.termWidth() {
+		t.Errorf("agent hidden by zoom is %d columns, want the full %d", w, m.termWidth())
+	}
+}
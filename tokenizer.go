@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ── Token Counting ────────────────────────────────────────────────
+//
+// countTokens estimates how many tokens a byte-level BPE tokenizer
+// (cl100k-style) would produce, without shipping its vocabulary. Text is
+// split the way the BPE pre-tokenizer does — contractions, letter runs
+// with one leading space or symbol, digit groups of up to three,
+// punctuation runs, newline runs and whitespace — and each piece is then
+// costed by a length rule: short words are one token, long words several,
+// non-ASCII text about a token per three bytes.
+//
+// It is an approximation, not a tokenizer. Without the merge table it
+// cannot know where BPE splits a particular word, so rare words and
+// identifiers ("tiktoken", long compounds) usually come out a few tokens
+// low. On markdown and code it lands close to real counts, which is good
+// enough for skill budgets and for usage estimates when a backend
+// reports none; anything billed should use the backend's own numbers.
+
+// countTokens returns the estimated BPE token count of text.
+func countTokens(text string) int {
+	n := 0
+	for len(text) > 0 {
+		piece, cost := nextPretoken(text)
+		n += cost
+		text = text[len(piece):]
+	}
+	return n
+}
+
+// nextPretoken splits the next pre-token off s and returns it with its
+// estimated token cost. It always consumes at least one byte.
+func nextPretoken(s string) (string, int) {
+	r, size := utf8.DecodeRuneInString(s)
+
+	// Contractions: 's 't 're 've 'm 'll 'd
+	if r == '\'' && len(s) > 1 {
+		for _, c := range []string{"s", "t", "re", "ve", "m", "ll", "d"} {
+			if len(s) >= 1+len(c) && equalFoldASCII(s[1:1+len(c)], c) && !isLetterAt(s, 1+len(c)) {
+				return s[:1+len(c)], 1
+			}
+		}
+	}
+
+	// Optional single leading non-letter (usually a space), then letters
+	lead := 0
+	if !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '\r' && r != '\n' && isLetterAt(s, size) {
+		lead = size
+	}
+	if lead > 0 || unicode.IsLetter(r) {
+		end := lead
+		for end < len(s) {
+			lr, ls := utf8.DecodeRuneInString(s[end:])
+			if !unicode.IsLetter(lr) {
+				break
+			}
+			end += ls
+		}
+		return s[:end], wordCost(s[lead:end])
+	}
+
+	// Digits in groups of up to three
+	if unicode.IsNumber(r) {
+		end, digits := 0, 0
+		for end < len(s) && digits < 3 {
+			nr, ns := utf8.DecodeRuneInString(s[end:])
+			if !unicode.IsNumber(nr) {
+				break
+			}
+			end += ns
+			digits++
+		}
+		return s[:end], 1
+	}
+
+	// Whitespace: newline runs (with leading blanks) and blank runs
+	if unicode.IsSpace(r) {
+		end := 0
+		newline := false
+		for end < len(s) {
+			wr, ws := utf8.DecodeRuneInString(s[end:])
+			if !unicode.IsSpace(wr) {
+				break
+			}
+			if wr == '\n' || wr == '\r' {
+				newline = true
+			} else if newline {
+				break // indentation after a newline is its own piece
+			}
+			end += ws
+		}
+		// A lone space before punctuation joins the punctuation run
+		if end == 1 && r == ' ' && len(s) > 1 && isPunctAt(s, 1) {
+			p, cost := punctRun(s[1:])
+			return s[:1+len(p)], cost
+		}
+		return s[:end], 1
+	}
+
+	return punctRun(s)
+}
+
+// punctRun takes a run of symbols plus any trailing newlines. Common
+// markdown and code runs ("##", "```", "->", ":=") merge into one token;
+// longer runs cost about one token per two symbols.
+func punctRun(s string) (string, int) {
+	end, count := 0, 0
+	for end < len(s) && isPunctAt(s, end) {
+		_, ps := utf8.DecodeRuneInString(s[end:])
+		end += ps
+		count++
+	}
+	if end == 0 { // control characters and the like
+		_, ps := utf8.DecodeRuneInString(s)
+		return s[:ps], 1
+	}
+	for end < len(s) && (s[end] == '\n' || s[end] == '\r') {
+		end++
+	}
+	if count <= 3 {
+		return s[:end], 1
+	}
+	return s[:end], (count + 1) / 2
+}
+
+// wordCost estimates BPE tokens for a run of letters. Everyday English
+// words up to about ten letters are a single vocabulary entry; longer
+// ones split into subwords of around six letters. Non-ASCII letters
+// merge far less, costing about one token per three bytes.
+func wordCost(w string) int {
+	ascii := true
+	for i := 0; i < len(w); i++ {
+		if w[i] >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if !ascii {
+		return (len(w) + 2) / 3
+	}
+	if len(w) <= 10 {
+		return 1
+	}
+	return 1 + (len(w)-10+5)/6
+}
+
+func isLetterAt(s string, i int) bool {
+	if i >= len(s) {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(s[i:])
+	return unicode.IsLetter(r)
+}
+
+func isPunctAt(s string, i int) bool {
+	r, _ := utf8.DecodeRuneInString(s[i:])
+	return !unicode.IsLetter(r) && !unicode.IsNumber(r) && !unicode.IsSpace(r) && unicode.IsGraphic(r)
+}
+
+func equalFoldASCII(a, b string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := 0; i < len(a); i++ {
+		ca, cb := a[i], b[i]
+		if 'A' <= ca && ca <= 'Z' {
+			ca += 'a' - 'A'
+		}
+		if ca != cb {
+			return false
+		}
+	}
+	return true
+}
+
+// formatTokens renders a token count compactly: 850, 1.2K, 12K.
+func formatTokens(n int) string {
+	switch {
+	case n < 1000:
+		return fmt.Sprintf("%d", n)
+	case n < 10000:
+		return fmt.Sprintf("%.1fK", float64(n)/1000)
+	default:
+		return fmt.Sprintf("%dK", (n+500)/1000)
+	}
+}
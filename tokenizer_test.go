@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestCountTokens(t *testing.T) {
+	// want is countTokens's estimate, which matches cl100k_base except
+	// where noted: it runs low on rare words.
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"hello world", 2},
+		{"Hello, world!", 4},
+		{"The quick brown fox jumps over the lazy dog.", 10},
+		{"2 + 2 = 4", 7},
+		{"12345678", 3},
+		{"don't", 2},
+		{"\n\n", 1},
+		{"    return nil\n", 4},
+		{"## Heading\n", 3},
+		{"```go\n", 3},
+		{"お誕生日おめでとう", 9},
+		{"tiktoken is great!", 4},           // cl100k_base: 6
+		{"antidisestablishmentarianism", 4}, // cl100k_base: 6
+	}
+	for _, tt := range tests {
+		if got := countTokens(tt.text); got != tt.want {
+			t.Errorf("countTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestFormatTokens(t *testing.T) {
+	tests := map[int]string{0: "0", 850: "850", 1234: "1.2K", 12345: "12K"}
+	for n, want := range tests {
+		if got := formatTokens(n); got != want {
+			t.Errorf("formatTokens(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
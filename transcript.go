@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/vt"
+)
+
+// ── Transcripts ───────────────────────────────────────────────────
+//
+// When an agent exits, its session recording is replayed into a plain-text
+// transcript (see castScrollback), bounded to scrollbackLines and saved as
+// transcript.txt in the session directory. The transcript stays on the
+// agent after its emulator is closed, so copy mode can still browse it,
+// and the checkout handoff can pass the last lines, the whole transcript,
+// or a structured summary of the session.
+
+const transcriptFileName = "transcript.txt"
+
+// handoffTailLines is how much of the final screen a default handoff carries.
+const handoffTailLines = 40
+
+// summaryTailLines is how much final output a handoff summary quotes.
+const summaryTailLines = 15
+
+// HandoffContent is what a checkout handoff passes to the next agent.
+type HandoffContent int
+
+const (
+	HandoffTail       HandoffContent = iota // last handoffTailLines of output
+	HandoffTranscript                       // the whole transcript
+	HandoffSummary                          // task, activity, changes and final lines
+	handoffContentCount
+)
+
+func (c HandoffContent) String() string {
+	switch c {
+	case HandoffTranscript:
+		return "full transcript"
+	case HandoffSummary:
+		return "summary"
+	}
+	return fmt.Sprintf("last %d lines", handoffTailLines)
+}
+
+// transcriptMsg delivers an exited agent's transcript. SessionDir tells a
+// late result for an earlier run apart from the current one.
+type transcriptMsg struct {
+	ID         string
+	SessionDir string
+	Lines      []string
+}
+
+// captureTranscript rebuilds a session's transcript from its recording,
+// falling back to screen when there is none, and saves it next to it.
+func captureTranscript(id, sessionDir string, screen []string) tea.Cmd {
+	return func() tea.Msg {
+		lines := screen
+		if sessionDir != "" {
+			if rec, err := castScrollback(filepath.Join(sessionDir, castFileName)); err == nil && len(rec) > 0 {
+				lines = rec
+			}
+			os.WriteFile(filepath.Join(sessionDir, transcriptFileName), []byte(strings.Join(lines, "\n")+"\n"), 0644)
+		}
+		return transcriptMsg{ID: id, SessionDir: sessionDir, Lines: lines}
+	}
+}
+
+func (m Model) handleTranscript(msg transcriptMsg) (tea.Model, tea.Cmd) {
+	inst := m.agentByID(msg.ID)
+	if inst == nil || inst.sessionDir != msg.SessionDir || inst.Status == "running" {
+		return m, nil
+	}
+	inst.Transcript = msg.Lines
+	return m, nil
+}
+
+// screenLines is the emulator's screen as plain text, without the blank
+// rows below the output.
+func screenLines(em *vt.SafeEmulator) []string {
+	return trimScreenLines(strings.Split(emulatorText(em), "\n"))
+}
+
+// handoffOutput is what of from's session a handoff of kind c carries.
+func handoffOutput(from *AgentInstance, c HandoffContent) string {
+	switch c {
+	case HandoffTranscript:
+		if len(from.Transcript) > 0 {
+			return strings.Join(from.Transcript, "\n") + from.hookReport
+		}
+	case HandoffSummary:
+		return handoffSummary(from)
+	}
+	return from.LastOutput
+}
+
+// handoffSummary describes an agent's session in a few sections: how it
+// ended, what its tools touched and how its output ended.
+func handoffSummary(inst *AgentInstance) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Agent: %s (%s)\n", inst.AgentName, inst.ClassName)
+	if inst.exitStatus != "" {
+		fmt.Fprintf(&b, "Exit status: %s\n", inst.exitStatus)
+	}
+	if inst.Branch != "" {
+		fmt.Fprintf(&b, "Branch: %s\n", inst.Branch)
+	}
+
+	// What the agent's tools did, from the hook activity feed
+	var files, commands []string
+	seen := map[string]bool{}
+	for _, a := range inst.Activity {
+		switch a.Tool {
+		case "Edit", "MultiEdit", "Write", "NotebookEdit":
+			_, file, _ := strings.Cut(a.Text, " ")
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		case "Bash":
+			commands = append(commands, strings.TrimPrefix(a.Text, "Running "))
+		}
+	}
+	if len(files) > 0 {
+		fmt.Fprintf(&b, "\nFiles changed:\n")
+		for _, f := range files {
+			fmt.Fprintf(&b, "- %s\n", f)
+		}
+	}
+	if len(commands) > 0 {
+		fmt.Fprintf(&b, "\nCommands run (latest last):\n")
+		for _, c := range commands[max(len(commands)-10, 0):] {
+			fmt.Fprintf(&b, "- %s\n", c)
+		}
+	}
+
+	output := strings.Join(inst.Transcript, "\n")
+	if output == "" {
+		output = strings.TrimSuffix(inst.LastOutput, inst.hookReport)
+	}
+	fmt.Fprintf(&b, "\nFinal output:\n%s", tailLines(output, summaryTailLines))
+	return b.String() + inst.hookReport
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHandoffPassesFullTranscript(t *testing.T) {
+	h := newHarness(t, PartyFile{Name: "relay", Slots: []PartySlotConfig{
+		{Agent: "Planner"}, {Agent: "Builder"},
+	}})
+	h.WaitFor(fakeAgentReady)
+	h.Keys("i")
+	h.Type("lines 80\r")
+	h.WaitFor("line 80")
+	h.Type("exit\r")
+	h.WaitFor("How did it go?")
+	h.Keys("2")
+	h.WaitUntil("transcript captured", func(m Model) bool {
+		return len(m.party().Slots[0].Transcript) > 0
+	})
+	h.Keys("right")
+	h.WaitFor("◂ full transcript ▸")
+	h.Keys("enter")
+
+	m := h.Model()
+	ctx := m.party().Slots[1].HandoffContext
+	if !strings.Contains(ctx, fakeAgentReady) || !strings.Contains(ctx, "line 1\n") {
+		t.Errorf("handoff context is not the full transcript: %q", ctx)
+	}
+	planner := m.party().Slots[0]
+	if _, err := os.Stat(filepath.Join(planner.sessionDir, transcriptFileName)); err != nil {
+		t.Errorf("transcript not saved: %v", err)
+	}
+	h.Keys("[", "g")
+	h.WaitFor(fakeAgentReady)
+}
+
+func TestHandoffOutput(t *testing.T) {
+	var transcript []string
+	for i := 1; i <= 30; i++ {
+		transcript = append(transcript, fmt.Sprintf("line %d", i))
+	}
+	inst := &AgentInstance{
+		AgentName:  "Builder",
+		ClassName:  "developer",
+		exitStatus: "exit status 1",
+		Branch:     "forge/p/builder",
+		LastOutput: "line 30\n\n[on_exit] tests failed",
+		hookReport: "\n\n[on_exit] tests failed",
+		Transcript: transcript,
+		Activity: activityFeed{
+			{Tool: "Edit", Text: "Editing auth.go"},
+			{Tool: "Bash", Text: "Running go test ./..."},
+			{Tool: "Edit", Text: "Editing auth.go"},
+			{Tool: "Write", Text: "Writing auth_test.go"},
+		},
+	}
+
+	if got := handoffOutput(inst, HandoffTail); got != inst.LastOutput {
+		t.Errorf("tail %q", got)
+	}
+	if got := handoffOutput(inst, HandoffTranscript); !strings.HasPrefix(got, "line 1\nline 2\n") || !strings.HasSuffix(got, "line 30"+inst.hookReport) {
+		t.Errorf("transcript %q", got)
+	}
+
+	summary := handoffOutput(inst, HandoffSummary)
+	for _, want := range []string{
+		"Agent: Builder (developer)\n",
+		"Exit status: exit status 1\n",
+		"Branch: forge/p/builder\n",
+		"Files changed:\n- auth.go\n- auth_test.go\n\n",
+		"Commands run (latest last):\n- go test ./...\n",
+		"Final output:\nline 16\n",
+	} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary missing %q:\n%s", want, summary)
+		}
+	}
+	if strings.Contains(summary, "line 15\n") || !strings.HasSuffix(summary, inst.hookReport) {
+		t.Errorf("summary should quote the last %d lines and end with the hook report:\n%s", summaryTailLines, summary)
+	}
+
+	inst.Transcript = nil
+	if got := handoffOutput(inst, HandoffTranscript); got != inst.LastOutput {
+		t.Errorf("transcript without one captured: %q", got)
+	}
+}
+
+func TestCaptureTranscriptFallsBackToScreen(t *testing.T) {
+	dir := t.TempDir()
+	msg := captureTranscript("a-0-Builder", dir, []string{"on screen"})().(transcriptMsg)
+	if len(msg.Lines) != 1 || msg.Lines[0] != "on screen" {
+		t.Errorf("lines %q", msg.Lines)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, transcriptFileName))
+	if err != nil || string(data) != "on screen\n" {
+		t.Errorf("saved transcript %q, %v", data, err)
+	}
+}
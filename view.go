@@ -51,17 +51,34 @@ func statusColor(status string) lipgloss.Color {
 func displayStatus(inst *AgentInstance) (string, lipgloss.Color) {
 	switch inst.Status {
 	case "running":
+		if inst.Approval != nil {
+			return "WAITING", colorRed
+		}
 		if time.Since(inst.lastOutputAt) > 3*time.Second {
 			return "IDLE", colorYellow
 		}
 		return "WORKING", colorGreen
 	case "exited":
 		return "EXITED", colorRed
+	case "attaching":
+		return "ATTACHING", colorYellow
 	default:
 		return "STANDBY", colorTextDim
 	}
 }
 
+// commsBadges marks an agent's unread messages and open review request.
+func commsBadges(inst *AgentInstance) string {
+	var badges []string
+	if n := inst.Inbox.Unread; n > 0 {
+		badges = append(badges, fmt.Sprintf("✉%d", n))
+	}
+	if inst.ReviewRequest != "" {
+		badges = append(badges, "⚑")
+	}
+	return strings.Join(badges, " ")
+}
+
 // ── View ───────────────────────────────────────────────────────────
 
 func (m Model) View() string {
@@ -77,6 +94,10 @@ func (m Model) View() string {
 		return clearKittyImages() + m.renderCommandPalette()
 	}
 
+	if m.mode == ModeSearch && m.agentSearch != nil {
+		return clearKittyImages() + m.renderAgentSearch()
+	}
+
 	if m.wizard != nil {
 		return m.renderWizard() + m.renderWizardKittyOverlay()
 	}
@@ -85,11 +106,14 @@ func (m Model) View() string {
 	leftPanel := m.renderLeftPanel()
 	mainPane := m.renderMainPane()
 
-	body := lipgloss.JoinHorizontal(lipgloss.Top, leftPanel, mainPane)
+	panes := []string{leftPanel, mainPane}
+	if m.showActivity {
+		panes = append(panes, m.renderActivityPanel())
+	}
 	if m.showGitPanel {
-		gitPanel := m.renderGitPanel()
-		body = lipgloss.JoinHorizontal(lipgloss.Top, leftPanel, mainPane, gitPanel)
+		panes = append(panes, m.renderGitPanel())
 	}
+	body := lipgloss.JoinHorizontal(lipgloss.Top, panes...)
 
 	// Status bar
 	statusBar := m.renderStatusBar()
@@ -180,6 +204,21 @@ func (m Model) renderHeader() string {
 	case ModeCommandPalette:
 		modeStr = "COMMAND"
 		modeColor = colorYellow
+	case ModeSessions:
+		modeStr = "SESSIONS"
+		modeColor = colorBlue
+	case ModeApprovals:
+		modeStr = "APPROVALS"
+		modeColor = colorRed
+	case ModeCopy:
+		modeStr = "COPY"
+		modeColor = colorYellow
+	case ModeSearch:
+		modeStr = "SEARCH"
+		modeColor = colorYellow
+	case ModeBroadcast:
+		modeStr = fmt.Sprintf("BROADCAST ×%d", len(m.broadcastTargets()))
+		modeColor = colorRed
 	}
 
 	modeIndicator := lipgloss.NewStyle().
@@ -293,42 +332,42 @@ func (m Model) renderTerminal() string {
 	tw := m.termWidth()
 	th := m.termHeight()
 
-	termBorderColor := colorBorder
-	if inst != nil {
-		r, g, b := inst.Tint.R, inst.Tint.G, inst.Tint.B
-		if m.focus == FocusMainPane || m.mode == ModeInsert {
-			termBorderColor = lipgloss.Color(fmt.Sprintf("#%02x%02x%02x", r, g, b))
-		} else {
-			termBorderColor = lipgloss.Color(fmt.Sprintf("#%02x%02x%02x", r/2, g/2, b/2))
-		}
-	} else if m.focus == FocusMainPane {
-		termBorderColor = colorBorderGold
-	}
-
 	// Character sheet overlay
 	if m.mode == ModeCharSheet && inst != nil {
 		return m.renderCharSheet(inst, tw, th)
 	}
 
+	// Sessions browser / replay
+	if m.mode == ModeSessions {
+		return m.renderSessions(tw, th)
+	}
+
+	// Approval queue across parties
+	if m.mode == ModeApprovals {
+		return m.renderApprovals(tw, th)
+	}
+
+	// Scrollback browser
+	if m.mode == ModeCopy && m.scrollback != nil {
+		return m.renderCopyMode(tw, th)
+	}
+
 	// Checkout modal overlay
 	if m.mode == ModeCheckout && m.checkoutAgent != nil {
 		return m.renderCheckoutModal(tw, th)
 	}
 
-	switch {
-	case inst == nil:
-		return m.renderEmptyTerminal(tw, th, termBorderColor, "No agent selected")
-	case inst.Status == "running" && inst.emulator != nil:
-		screen := strings.ReplaceAll(inst.emulator.Render(), "\r\n", "\n")
-		return lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(termBorderColor).
-			Render(screen)
-	case inst.Status == "exited":
-		return m.renderEmptyTerminal(tw, th, termBorderColor, "Process exited. Press 's' to restart.")
-	default:
-		return m.renderEmptyTerminal(tw, th, termBorderColor, "Press 's' to start claude")
+	if inst == nil {
+		borderColor := colorBorder
+		if m.focus == FocusMainPane {
+			borderColor = colorBorderGold
+		}
+		return m.renderEmptyTerminal(tw, th, borderColor, "No agent selected")
+	}
+	if columns := m.splitColumns(); columns != nil {
+		return m.renderSplit(columns)
 	}
+	return m.renderAgentPane(inst, tw, th, true)
 }
 
 func (m Model) renderEmptyTerminal(tw, th int, borderColor lipgloss.Color, msg string) string {
@@ -479,11 +518,14 @@ func (m Model) renderHandoffModal(tw, th int) string {
 	}
 
 	targets := strings.Join(targetLines, "\n")
+	content := lipgloss.NewStyle().Foreground(colorText).Render("Pass: ") +
+		lipgloss.NewStyle().Foreground(colorYellow).Bold(true).
+			Render(fmt.Sprintf("◂ %s ▸", m.handoffContent))
 	hint := lipgloss.NewStyle().Foreground(colorTextDim).
-		Render("↑↓:select  enter:handoff  esc:skip")
+		Render("↑↓:select  ←→:what to pass\nenter:handoff  esc:skip")
 
-	content := lipgloss.JoinVertical(lipgloss.Center, title, "", question, "", targets, "", hint)
-	box := modal.Render(content)
+	body := lipgloss.JoinVertical(lipgloss.Center, title, "", question, "", targets, "", content, "", hint)
+	box := modal.Render(body)
 
 	return lipgloss.NewStyle().
 		Width(tw + 2).
@@ -577,6 +619,13 @@ func (m Model) renderPartyBar() string {
 					Background(colorBgLight)
 			}
 		}
+		broadcastTarget := m.isBroadcastTarget(inst)
+		if broadcastTarget {
+			style = style.Border(lipgloss.ThickBorder())
+			if m.mode == ModeBroadcast {
+				style = style.BorderForeground(colorRed)
+			}
+		}
 
 		// Avatar placeholder for Kitty overlay (centered with margin)
 		var avatar string
@@ -606,17 +655,38 @@ func (m Model) renderPartyBar() string {
 		// Activity-based status display
 		statusText, sc := displayStatus(displayInst)
 		statStyle := lipgloss.NewStyle().Foreground(sc)
+		statusLine := statStyle.Render(statusText)
+		if badges := commsBadges(displayInst); badges != "" {
+			statusLine += " " + styleYellow.Render(badges)
+		}
+		if broadcastTarget {
+			statusLine += " " + lipgloss.NewStyle().Foreground(colorRed).Bold(true).Render("⇶")
+		}
 
 		// HP bar (context window usage)
 		hpBar := renderHPBar(displayInst, cardWidth-2)
 
+		// Active passives
+		var passiveNames []string
+		for _, pid := range displayInst.Passives {
+			passiveNames = append(passiveNames, passiveName(m.config, pid))
+		}
+		taskLine := styleTextDim.Render(truncLine(displayInst.Task, cardWidth-2))
+
+		passiveLine := ""
+		if len(passiveNames) > 0 {
+			passiveLine = styleGreen.Render(truncLine("◆ "+strings.Join(passiveNames, " · "), cardWidth-2))
+		}
+
 		content := lipgloss.JoinVertical(
 			lipgloss.Center,
 			avatar,
 			nameStyle.Render(displayInst.AgentName)+lvlStyle.Render(lvlStr),
 			classStyle.Render(className),
-			statStyle.Render(statusText),
+			statusLine,
+			taskLine,
 			hpBar,
+			passiveLine,
 		)
 
 		cards = append(cards, style.Render(content))
@@ -661,6 +731,60 @@ func (m Model) renderPartyBar() string {
 		)
 }
 
+// ── Activity Panel ─────────────────────────────────────────────────
+
+// renderActivityPanel shows the selected agent's recent tool uses, newest
+// at the bottom.
+func (m Model) renderActivityPanel() string {
+	ph := m.layout.PartyHeight
+	th := m.termHeight()
+	bodyHeight := th + 2 + ph // terminal with border + party bar
+
+	contentHeight := bodyHeight - 2 // border top/bottom
+
+	title := " ACTIVITY  (a:close)"
+	var lines []string
+	inst := m.agent()
+	switch {
+	case inst == nil:
+		lines = append(lines, styleTextDim.Render(" No agent selected"))
+	case len(inst.Activity) == 0:
+		title = truncLine(" ACTIVITY · "+inst.AgentName, activityPanelWidth)
+		lines = append(lines, styleTextDim.Render(" No tool use yet"))
+	default:
+		title = truncLine(" ACTIVITY · "+inst.AgentName, activityPanelWidth)
+		start := max(len(inst.Activity)-contentHeight, 0)
+		for _, a := range inst.Activity[start:] {
+			mark := lipgloss.NewStyle().Foreground(colorYellow).Render("▸")
+			if !a.Finished.IsZero() {
+				mark = styleGreen.Render("✓")
+			}
+			stamp := styleTextDim.Render(a.Started.Format("15:04:05"))
+			text := truncLine(a.Text, activityPanelWidth-12)
+			lines = append(lines, fmt.Sprintf(" %s %s %s", stamp, mark, text))
+		}
+	}
+
+	// Pad remaining height
+	for len(lines) < contentHeight {
+		lines = append(lines, "")
+	}
+
+	return lipgloss.NewStyle().
+		Width(activityPanelWidth).
+		Height(bodyHeight).
+		BorderLeft(true).
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(colorBorder).
+		Background(colorBgDark).
+		Render(
+			lipgloss.NewStyle().
+				Foreground(colorYellow).
+				Bold(true).
+				Render(title) + "\n" + strings.Join(lines, "\n"),
+		)
+}
+
 // ── Git Panel ──────────────────────────────────────────────────────
 
 func (m Model) renderGitPanel() string {
@@ -829,18 +953,32 @@ func (m Model) renderStatusBar() string {
 	partyName := ""
 	agentName := ""
 	agentStatus := ""
+	agentTask := ""
 	if p != nil {
 		partyName = p.Name
 	}
 	if inst != nil {
 		agentName = inst.AgentName
 		agentStatus = strings.ToUpper(inst.Status)
+		if inst.Task != "" {
+			agentTask = truncLine(inst.Task, 40) + " │ "
+		}
+	}
+	if n := len(m.pendingApprovals()); n > 0 {
+		agentTask += lipgloss.NewStyle().Foreground(colorRed).Bold(true).
+			Render(fmt.Sprintf("⚠ %d waiting (p)", n)) + " │ "
+	}
+	if m.apiWarning != "" {
+		agentTask += lipgloss.NewStyle().Foreground(colorYellow).
+			Render(truncLine("⚠ "+m.apiWarning, 60)) + " │ "
 	}
 
 	var hints string
 	switch m.mode {
 	case ModeInsert:
 		hints = "esc:normal"
+	case ModeBroadcast:
+		hints = fmt.Sprintf("typing to %d agents  esc:normal", len(m.broadcastTargets()))
 	case ModeSwap:
 		benchAgent := ""
 		benchLen := 0
@@ -854,6 +992,19 @@ func (m Model) renderStatusBar() string {
 			benchAgent, m.swapIndex+1, benchLen)
 	case ModeCharSheet:
 		hints = "↑↓:navigate  tab:section  space:equip  []:scroll  s:start  esc:close"
+	case ModeSessions:
+		switch {
+		case m.replay != nil:
+			hints = "space:pause  ←→:seek 5s  HL:seek 1m  +/-:speed  0:restart  esc:back"
+		case m.sessionShowPrompt:
+			hints = "p:hide prompt  ↑↓:session  esc:back"
+		default:
+			hints = "↑↓:session  enter:replay  p:prompt  esc:close"
+		}
+	case ModeApprovals:
+		hints = "↑↓:request  y:approve  n:deny  enter:go to agent  esc:close"
+	case ModeCopy:
+		hints = "hjkl:move  ^u^d:page  g/G:top/bottom  /?:search  n/N:next  v/V:select  y:yank  esc:close"
 	case ModeCheckout:
 		switch m.checkoutStep {
 		case 0:
@@ -861,18 +1012,21 @@ func (m Model) renderStatusBar() string {
 		case 1:
 			hints = "type:name  enter:save  esc:skip"
 		case 2:
-			hints = "↑↓:select  enter:handoff  esc:skip"
+			hints = "↑↓:select  ←→:what to pass  enter:handoff  esc:skip"
 		case 3:
 			hints = "1:merge  2:keep  3:discard  esc:keep"
 		}
 	default:
 		switch m.focus {
 		case FocusLeftPanel:
-			hints = "↑↓:party  n:new  d:delete  enter:switch  tab:focus"
+			hints = "↑↓:party  n:new  d:delete  s:sessions  enter:switch  tab:focus"
 		case FocusMainPane:
-			hints = "s:start  i:insert  x:stop  enter:sheet  space:swap  g:files  ←→:agent  tab:focus"
+			hints = "s:start  i:insert  x:stop  c:checkout  enter:sheet  space:swap  g:files  a:activity  b/B:mark  I:broadcast  p:approvals  f:find  v:layout  z:zoom  [:scrollback  ←→:agent  tab:focus"
+			if m.daemon {
+				hints += "  q:detach"
+			}
 		case FocusPartyBar:
-			hints = "←→:agent  enter:sheet  s:start  g:files  tab:focus"
+			hints = "←→:agent  enter:sheet  s:start  c:checkout  g:files  a:activity  b/B:mark  I:broadcast  p:approvals  f:find  v:layout  z:zoom  tab:focus"
 		}
 	}
 
@@ -881,10 +1035,11 @@ func (m Model) renderStatusBar() string {
 		Foreground(colorText).
 		Width(m.width).
 		Padding(0, 2).
-		Render(fmt.Sprintf("Party: %s │ Agent: %s │ %s │ %s",
+		Render(fmt.Sprintf("Party: %s │ Agent: %s │ %s │ %s%s",
 			lipgloss.NewStyle().Bold(true).Render(partyName),
 			lipgloss.NewStyle().Bold(true).Render(agentName),
 			lipgloss.NewStyle().Foreground(statusColor(strings.ToLower(agentStatus))).Render(agentStatus),
+			agentTask,
 			lipgloss.NewStyle().Foreground(colorTextDim).Render(hints),
 		))
 }
@@ -312,17 +312,21 @@ func (m Model) autoStartPartyAgents() (Model, tea.Cmd) {
 		return m, nil
 	}
 
-	tw := m.termWidth()
-	th := m.termHeight()
-	if tw <= 0 || th <= 0 {
+	if m.termWidth() <= 0 || m.termHeight() <= 0 {
 		return m, nil
 	}
 
 	var cmds []tea.Cmd
 	for _, inst := range p.Slots {
 		if inst != nil && inst.AgentName != "Empty" && inst.Status == "idle" {
+			// Leave resumable agents for the user to choose resume or fresh
+			if m.canResume(inst) {
+				inst.Task = "Resumable"
+				continue
+			}
 			inst.Task = "Starting..."
-			cmds = append(cmds, startAgent(inst, tw, th, m.config, p.Project, p.Name))
+			tw, th := m.agentTermSize(inst) // its split pane, when the layout shows several
+			cmds = append(cmds, startAgent(inst, tw, th, m.config, p.Project, p.Name, false))
 		}
 	}
 